@@ -5,11 +5,17 @@ import (
 	"claude-squad/keys"
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/session/notify"
+	"claude-squad/session/tmux"
+	"claude-squad/session/transcript"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -17,14 +23,18 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-const GlobalInstanceLimit = 10
+const GlobalInstanceLimit = 50
 
 // Run is the main entrypoint into the application.
 func Run(ctx context.Context, program string, autoYes bool) error {
+	out := &terminal{file: os.Stdout}
+	notify.SetTerminal(out)
+	defer notify.SetTerminal(nil)
 	p := tea.NewProgram(
 		newHome(ctx, program, autoYes),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(), // Mouse scroll
+		tea.WithOutput(out),
 	)
 	_, err := p.Run()
 	return err
@@ -42,6 +52,18 @@ const (
 	stateHelp
 	// stateConfirm is the state when a confirmation modal is displayed.
 	stateConfirm
+	// stateInput is the state when the user is entering text which is passed to inputHandler.
+	stateInput
+	// stateTranscript is the state when the transcript of an instance is displayed.
+	stateTranscript
+	// stateSearch is the state when the search across all instances is displayed.
+	stateSearch
+	// stateFilter is the state when the user is typing the filter of the list.
+	stateFilter
+	// stateCompare is the state when the instances of a group are compared.
+	stateCompare
+	// statePromptLibrary is the state when a prompt of the library is picked while entering a prompt.
+	statePromptLibrary
 )
 
 type home struct {
@@ -69,6 +91,12 @@ type home struct {
 
 	// promptAfterName tracks if we should enter prompt mode after naming
 	promptAfterName bool
+	// pendingPrompts are the prompts entered for instances which are still starting. They're sent once the
+	// instance has started.
+	pendingPrompts map[*session.Instance]string
+
+	// inputHandler is called with the entered text when the text input is submitted in stateInput.
+	inputHandler func(value string) tea.Cmd
 
 	// keySent is used to manage underlining menu items
 	keySent bool
@@ -91,6 +119,29 @@ type home struct {
 	textOverlay *overlay.TextOverlay
 	// confirmationOverlay displays confirmation modals
 	confirmationOverlay *overlay.ConfirmationOverlay
+	// transcriptView displays the transcript of an instance in stateTranscript
+	transcriptView *ui.TranscriptView
+	// compareView compares the instances of a group in stateCompare
+	compareView *ui.CompareView
+	// searchOverlay searches the panes and transcripts of all instances in stateSearch
+	searchOverlay *ui.SearchOverlay
+	// promptPicker picks a prompt of the library in statePromptLibrary
+	promptPicker *ui.PromptPicker
+	// promptTargets are the instances the prompt entered in statePrompt is sent to. It's nil when the prompt is
+	// the first one of a new instance.
+	promptTargets []*session.Instance
+	// promptBroadcast is true if the promptTargets are the marked instances, which are unmarked once they got the
+	// prompt.
+	promptBroadcast bool
+	// promptPerInstance is true if the prompt entered in statePrompt is a template which is rendered for each of
+	// the promptTargets, see insertPrompt.
+	promptPerInstance bool
+
+	// confirmed is the message returned by the action of the confirmation overlay once it was confirmed.
+	confirmed tea.Msg
+
+	// conflicts holds the files modified by more than one instance. Recomputed on every metadata tick.
+	conflicts *session.ConflictReport
 }
 
 func newHome(ctx context.Context, program string, autoYes bool) *home {
@@ -162,6 +213,18 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	if m.textOverlay != nil {
 		m.textOverlay.SetWidth(int(float32(msg.Width) * 0.6))
 	}
+	if m.transcriptView != nil {
+		m.transcriptView.SetSize(msg.Width, msg.Height)
+	}
+	if m.compareView != nil {
+		m.compareView.SetSize(msg.Width, msg.Height)
+	}
+	if m.searchOverlay != nil {
+		m.searchOverlay.SetSize(int(float32(msg.Width)*0.8), int(float32(msg.Height)*0.7))
+	}
+	if m.promptPicker != nil {
+		m.promptPicker.SetSize(int(float32(msg.Width)*0.6), int(float32(msg.Height)*0.6))
+	}
 
 	previewWidth, previewHeight := m.tabbedWindow.GetPreviewSize()
 	if err := m.list.SetSessionPreviewSize(previewWidth, previewHeight); err != nil {
@@ -180,6 +243,7 @@ func (m *home) Init() tea.Cmd {
 			return previewTickMsg{}
 		},
 		tickUpdateMetadataCmd,
+		tickReapCmd,
 	)
 }
 
@@ -199,9 +263,11 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case keyupMsg:
 		m.menu.ClearKeydown()
 		return m, nil
+	case tickReapMessage:
+		return m, tea.Batch(m.reap(), tickReapCmd)
 	case tickUpdateMetadataMessage:
 		for _, instance := range m.list.GetInstances() {
-			if !instance.Started() || instance.Paused() {
+			if !instance.Started() || instance.Paused() || instance.Status == session.Loading {
 				continue
 			}
 			updated, prompt := instance.HasUpdated()
@@ -209,7 +275,7 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				instance.SetStatus(session.Running)
 			} else {
 				if prompt {
-					instance.TapEnter()
+					instance.AnswerPrompt()
 				} else {
 					instance.SetStatus(session.Ready)
 				}
@@ -218,6 +284,9 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				log.WarningLog.Printf("could not update diff stats: %v", err)
 			}
 		}
+		m.conflicts = session.DetectConflicts(m.list.GetInstances())
+		m.list.SetConflicts(m.conflicts)
+		m.tabbedWindow.SetConflicts(m.conflicts)
 		return m, tickUpdateMetadataCmd
 	case tea.MouseMsg:
 		// Handle mouse wheel events for scrolling the diff/preview pane
@@ -248,6 +317,19 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case instanceChangedMsg:
 		// Handle instance changed after confirmation action
 		return m, m.instanceChanged()
+	case instanceStartedMsg:
+		return m, m.handleInstanceStarted(msg)
+	case verifiedMsg:
+		if m.compareView != nil {
+			m.compareView.SetVerification(msg.instance, msg.result, msg.err)
+		}
+		return m, nil
+	case winnerKeptMsg:
+		return m, m.handleWinnerKept(msg)
+	case reapPlannedMsg:
+		return m, m.handleReapPlanned(msg)
+	case reapedMsg:
+		return m, m.handleReaped(msg)
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -270,7 +352,9 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm {
+	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateInput ||
+		m.state == stateTranscript || m.state == stateSearch || m.state == stateFilter ||
+		m.state == stateCompare || m.state == statePromptLibrary {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -306,6 +390,46 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	if m.state == stateHelp {
 		return m.handleHelpState(msg)
 	}
+	if m.state == stateTranscript {
+		if m.transcriptView.HandleKeyPress(msg) {
+			m.transcriptView = nil
+			m.state = stateDefault
+		}
+		return m, nil
+	}
+	if m.state == stateCompare {
+		return m, m.handleCompareKeyPress(msg)
+	}
+	if m.state == stateFilter {
+		if m.list.FilterKey(msg) {
+			m.state = stateDefault
+		}
+		return m, m.instanceChanged()
+	}
+	if m.state == statePromptLibrary {
+		closed, choice := m.promptPicker.HandleKeyPress(msg)
+		if !closed {
+			return m, nil
+		}
+		m.promptPicker = nil
+		m.state = statePrompt
+		if choice == nil {
+			return m, nil
+		}
+		return m, m.insertPrompt(choice)
+	}
+	if m.state == stateSearch {
+		closed, match := m.searchOverlay.HandleKeyPress(msg)
+		if !closed {
+			return m, nil
+		}
+		m.searchOverlay = nil
+		m.state = stateDefault
+		if match == nil {
+			return m, nil
+		}
+		return m, m.showSearchMatch(*match)
+	}
 
 	if m.state == stateNew {
 		// Handle quit commands first. Don't handle q because the user might want to type that.
@@ -330,38 +454,28 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				return m, m.handleError(fmt.Errorf("title cannot be empty"))
 			}
 
-			if err := instance.Start(true); err != nil {
-				m.list.Kill()
-				m.state = stateDefault
-				return m, m.handleError(err)
-			}
-			// Save after adding new instance
-			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
-				return m, m.handleError(err)
-			}
-			// Instance added successfully, call the finalizer.
-			m.newInstanceFinalizer()
+			// Setting up the worktree may take a while, so the instance starts in the background while the
+			// preview shows the setup output.
+			instance.SetStatus(session.Loading)
 			if m.autoYes {
 				instance.AutoYes = true
 			}
+			start := m.startInstance(instance, m.newInstanceFinalizer)
 
-			m.newInstanceFinalizer()
 			m.state = stateDefault
 			if m.promptAfterName {
-				m.state = statePrompt
-				m.menu.SetState(ui.StatePrompt)
-				// Initialize the text input overlay
-				m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", "")
+				m.openPrompt(nil, "Enter prompt")
 				m.promptAfterName = false
 			} else {
 				m.menu.SetState(ui.StateDefault)
 				m.showHelpScreen(helpStart(instance), nil)
 			}
 
-			return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
+			return m, tea.Batch(tea.WindowSize(), m.instanceChanged(), start)
 		case tea.KeyRunes:
-			if len(instance.Title) >= 32 {
-				return m, m.handleError(fmt.Errorf("title cannot be longer than 32 characters"))
+			if len(instance.Title) >= session.MaxTitleLength {
+				return m, m.handleError(
+					fmt.Errorf("title cannot be longer than %d characters", session.MaxTitleLength))
 			}
 			if err := instance.SetTitle(instance.Title + string(msg.Runes)); err != nil {
 				return m, m.handleError(err)
@@ -393,6 +507,9 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 		return m, nil
 	} else if m.state == statePrompt {
+		if msg.String() == "ctrl+l" {
+			return m, m.openPromptLibrary()
+		}
 		// Use the new TextInputOverlay component to handle all key events
 		shouldClose := m.textInputOverlay.HandleKeyPress(msg)
 
@@ -403,36 +520,71 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			if selected == nil {
 				return m, nil
 			}
+			// Without targets, the prompt is the first one of the new instance.
+			targets := m.promptTargets
+			isNew := targets == nil
+			if isNew {
+				targets = []*session.Instance{selected}
+			}
+			var sendCmd tea.Cmd
 			if m.textInputOverlay.IsSubmitted() {
-				if err := selected.SendPrompt(m.textInputOverlay.GetValue()); err != nil {
-					// TODO: we probably end up in a bad state here.
-					return m, m.handleError(err)
+				prompt := m.textInputOverlay.GetValue()
+				m.rememberPrompt(prompt)
+				if err := m.sendPrompt(targets, prompt, m.promptPerInstance); err != nil {
+					sendCmd = m.handleError(err)
+				} else if m.promptBroadcast {
+					m.list.ClearMarks()
 				}
 			}
 
 			// Close the overlay and reset state
 			m.textInputOverlay = nil
+			m.resetPromptTargets()
 			m.state = stateDefault
-			return m, tea.Sequence(
+			if !isNew {
+				m.menu.SetState(ui.StateDefault)
+				return m, tea.Batch(tea.WindowSize(), sendCmd)
+			}
+			return m, tea.Batch(sendCmd, tea.Sequence(
 				tea.WindowSize(),
 				func() tea.Msg {
 					m.menu.SetState(ui.StateDefault)
 					m.showHelpScreen(helpStart(selected), nil)
 					return nil
 				},
-			)
+			))
 		}
 
 		return m, nil
+	} else if m.state == stateInput {
+		if !m.textInputOverlay.HandleKeyPress(msg) {
+			return m, nil
+		}
+
+		// Reset the state before calling the handler so that it can open another overlay.
+		submitted, value, handler := m.textInputOverlay.IsSubmitted(), m.textInputOverlay.GetValue(), m.inputHandler
+		m.textInputOverlay = nil
+		m.inputHandler = nil
+		m.state = stateDefault
+		m.menu.SetState(ui.StateDefault)
+
+		var cmd tea.Cmd
+		if submitted && handler != nil {
+			cmd = handler(value)
+		}
+		return m, tea.Batch(tea.WindowSize(), cmd)
 	}
 
 	// Handle confirmation state
 	if m.state == stateConfirm {
 		shouldClose := m.confirmationOverlay.HandleKeyPress(msg)
 		if shouldClose {
-			m.state = stateDefault
+			// The callbacks may have gone to another state.
+			if m.state == stateConfirm {
+				m.state = stateDefault
+			}
 			m.confirmationOverlay = nil
-			return m, nil
+			return m, m.takeConfirmed()
 		}
 		return m, nil
 	}
@@ -458,6 +610,13 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m.handleQuit()
 	}
 
+	// Keys that only apply to the diff tab take precedence while it is active.
+	if m.tabbedWindow.IsInDiffTab() {
+		if name, ok := keys.DiffKeyStringsMap[msg.String()]; ok {
+			return m.handleDiffKeyPress(name)
+		}
+	}
+
 	name, ok := keys.GlobalKeyStringsMap[msg.String()]
 	if !ok {
 		return m, nil
@@ -466,6 +625,178 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	switch name {
 	case keys.KeyHelp:
 		return m.showHelpScreen(helpTypeGeneral{}, nil)
+	case keys.KeyConflicts:
+		m.textOverlay = overlay.NewTextOverlay(ui.RenderConflictMatrix(m.conflicts))
+		m.state = stateHelp
+		return m, nil
+	case keys.KeySearch:
+		m.searchOverlay = ui.NewSearchOverlay(session.CollectSearchDocuments(m.list.GetInstances()))
+		m.state = stateSearch
+		return m, tea.WindowSize()
+	case keys.KeyFilter:
+		m.list.StartFilter()
+		m.state = stateFilter
+		return m, nil
+	case keys.KeySort:
+		m.list.CycleSort()
+		return m, m.instanceChanged()
+	case keys.KeyGroup:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		return m, m.promptText("Group of the session, empty for none", selected.Group, func(value string) tea.Cmd {
+			selected.Group = strings.TrimSpace(value)
+			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				return m.handleError(err)
+			}
+			m.list.SelectInstance(selected)
+			return m.instanceChanged()
+		})
+	case keys.KeyTags:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		return m, m.promptText("Tags of the session, separated by spaces", strings.Join(selected.Tags, " "),
+			func(value string) tea.Cmd {
+				selected.Tags = session.ParseTags(value)
+				if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+					return m.handleError(err)
+				}
+				return m.instanceChanged()
+			})
+	case keys.KeyRename:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		return m, m.promptText("New title of the session", selected.Title, func(value string) tea.Cmd {
+			return m.renameInstance(selected, strings.TrimSpace(value))
+		})
+	case keys.KeyDuplicate, keys.KeyFork:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		if m.list.NumInstances() >= GlobalInstanceLimit {
+			return m, m.handleError(
+				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+		}
+		title := fmt.Sprintf("Title of the duplicate of %s", selected.Title)
+		if name == keys.KeyFork {
+			title = fmt.Sprintf("Title of the fork of %s", selected.Title)
+		}
+		return m, m.promptText(title, m.unusedTitle(selected.Title), func(value string) tea.Cmd {
+			title := strings.TrimSpace(value)
+			if err := m.validateTitle(title); err != nil {
+				return m.handleError(err)
+			}
+			if name == keys.KeyFork {
+				fork, err := selected.Fork(title)
+				if err != nil {
+					return m.handleError(err)
+				}
+				return m.launchInstance(fork, "")
+			}
+			duplicate, err := selected.Duplicate(title)
+			if err != nil {
+				return m.handleError(err)
+			}
+			return m.launchInstance(duplicate, duplicate.Prompt)
+		})
+	case keys.KeyFanOut:
+		return m, m.promptText("Best-of-N: title of the group", "", func(value string) tea.Cmd {
+			title := strings.TrimSpace(value)
+			if title == "" {
+				return m.handleError(fmt.Errorf("title cannot be empty"))
+			}
+			return m.promptText("Programs, like \"claude x2, aider\"", m.program+" x3", func(value string) tea.Cmd {
+				programs, err := session.ParsePrograms(value)
+				if err != nil {
+					return m.handleError(err)
+				}
+				if m.list.NumInstances()+len(programs) > GlobalInstanceLimit {
+					return m.handleError(
+						fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+				}
+				return m.promptText("Prompt for all of them", "", func(value string) tea.Cmd {
+					return m.fanOut(title, programs, value)
+				})
+			})
+		})
+	case keys.KeyMark:
+		m.list.ToggleMark()
+		return m, nil
+	case keys.KeyAutoYes:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		// The setting is stored with the instance, so that the daemon answers its prompts too.
+		selected.AutoYes = !selected.AutoYes
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case keys.KeySendPrompt:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		if selected.Paused() {
+			return m, m.handleError(fmt.Errorf("instance %s is paused", selected.Title))
+		}
+		return m, m.openPrompt([]*session.Instance{selected}, fmt.Sprintf("Send a prompt to %s", selected.Title))
+	case keys.KeyBroadcast:
+		marked := m.list.Marked()
+		if len(marked) == 0 {
+			return m, m.handleError(fmt.Errorf("mark sessions with space to send all of them the same prompt"))
+		}
+		cmd := m.openPrompt(marked, fmt.Sprintf("Send a prompt to %d marked sessions", len(marked)))
+		m.promptBroadcast = true
+		return m, cmd
+	case keys.KeyCompare:
+		group := m.list.SelectedGroup()
+		if group == "" {
+			return m, m.handleError(fmt.Errorf("select a session of a group to compare its sessions"))
+		}
+		var candidates []*session.Instance
+		for _, instance := range m.list.GetInstances() {
+			if instance.Group == group {
+				candidates = append(candidates, instance)
+			}
+		}
+		if len(candidates) < 2 {
+			return m, m.handleError(fmt.Errorf("group %s has only one session", group))
+		}
+		m.compareView = ui.NewCompareView(group, candidates)
+		m.state = stateCompare
+		return m, tea.WindowSize()
+	case keys.KeyWindow:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || m.tabbedWindow.IsInDiffTab() {
+			return m, nil
+		}
+		if err := selected.NextWindow(); err != nil {
+			return m, m.handleError(err)
+		}
+		if err := m.tabbedWindow.ResetPreviewToNormalMode(selected); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case keys.KeyTranscript:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		t, err := transcript.Load(selected.Title)
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		m.transcriptView = ui.NewTranscriptView(selected.Title, t.Text())
+		m.state = stateTranscript
+		return m, tea.WindowSize()
 	case keys.KeyPrompt:
 		if m.list.NumInstances() >= GlobalInstanceLimit {
 			return m, m.handleError(
@@ -481,7 +812,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 
 		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+		m.list.SelectInstance(instance)
 		m.state = stateNew
 		m.menu.SetState(ui.StateNewInstance)
 		m.promptAfterName = true
@@ -502,7 +833,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 
 		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+		m.list.SelectInstance(instance)
 		m.state = stateNew
 		m.menu.SetState(ui.StateNewInstance)
 
@@ -528,31 +859,15 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		if selected == nil {
 			return m, nil
 		}
+		if selected.Status == session.Loading {
+			return m, m.handleError(fmt.Errorf("instance %s is still starting", selected.Title))
+		}
 
 		// Create the kill action as a tea.Cmd
 		killAction := func() tea.Msg {
-			// Get worktree and check if branch is checked out
-			worktree, err := selected.GetGitWorktree()
-			if err != nil {
+			if err := m.killInstance(selected); err != nil {
 				return err
 			}
-
-			checkedOut, err := worktree.IsBranchCheckedOut()
-			if err != nil {
-				return err
-			}
-
-			if checkedOut {
-				return fmt.Errorf("instance %s is currently checked out", selected.Title)
-			}
-
-			// Delete from storage first
-			if err := m.storage.DeleteInstance(selected.Title); err != nil {
-				return err
-			}
-
-			// Then kill the instance
-			m.list.Kill()
 			return instanceChangedMsg{}
 		}
 
@@ -606,22 +921,21 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 		return m, tea.WindowSize()
 	case keys.KeyEnter:
+		if m.list.ToggleGroup() {
+			return m, m.instanceChanged()
+		}
 		if m.list.NumInstances() == 0 {
 			return m, nil
 		}
 		selected := m.list.GetSelectedInstance()
-		if selected == nil || selected.Paused() || !selected.TmuxAlive() {
+		if selected == nil || !selected.Started() || selected.Paused() || !selected.TmuxAlive() {
 			return m, nil
 		}
 		// Show help screen before attaching
 		m.showHelpScreen(helpTypeInstanceAttach{}, func() {
-			ch, err := m.list.Attach()
-			if err != nil {
-				m.handleError(err)
-				return
-			}
-			<-ch
+			m.attachSelected()
 			m.state = stateDefault
+			m.instanceChanged()
 		})
 		return m, nil
 	default:
@@ -629,6 +943,498 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	}
 }
 
+// renameInstance renames the instance and offers to rename its branch to match.
+func (m *home) renameInstance(instance *session.Instance, title string) tea.Cmd {
+	if title == instance.Title {
+		return nil
+	}
+	if err := m.validateTitle(title); err != nil {
+		return m.handleError(err)
+	}
+	if err := instance.Rename(title); err != nil {
+		return m.handleError(err)
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	cmd := m.instanceChanged()
+
+	branch := instance.BranchForTitle()
+	if branch == instance.Branch {
+		return cmd
+	}
+	renameBranchAction := func() tea.Msg {
+		if err := instance.RenameBranch(); err != nil {
+			return err
+		}
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return err
+		}
+		return instanceChangedMsg{}
+	}
+	message := fmt.Sprintf("[!] Also rename branch '%s' to '%s'?", instance.Branch, branch)
+	return tea.Batch(cmd, m.confirmAction(message, renameBranchAction))
+}
+
+// validateTitle returns an error if the title can't be given to an instance, like because another one has it.
+func (m *home) validateTitle(title string) error {
+	if title == "" {
+		return fmt.Errorf("title cannot be empty")
+	}
+	if len(title) > session.MaxTitleLength {
+		return fmt.Errorf("title cannot be longer than %d characters", session.MaxTitleLength)
+	}
+	for _, instance := range m.list.GetInstances() {
+		if instance.Title == title {
+			return fmt.Errorf("a session named %s already exists", title)
+		}
+	}
+	return nil
+}
+
+// unusedTitle returns the title with the first number appended which no instance has.
+func (m *home) unusedTitle(title string) string {
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		candidate := title[:min(len(title), session.MaxTitleLength-len(suffix))] + suffix
+		if m.validateTitle(candidate) == nil {
+			return candidate
+		}
+	}
+}
+
+// launchInstance adds an instance created from another one, like a duplicate, and starts it in the background.
+// The prompt, if any, is sent once it has started.
+func (m *home) launchInstance(instance *session.Instance, prompt string) tea.Cmd {
+	finalizer := m.list.AddInstance(instance)
+	m.list.SelectInstance(instance)
+	instance.SetStatus(session.Loading)
+	if m.autoYes {
+		instance.AutoYes = true
+	}
+	if prompt != "" {
+		if m.pendingPrompts == nil {
+			m.pendingPrompts = make(map[*session.Instance]string)
+		}
+		m.pendingPrompts[instance] = prompt
+	}
+	return tea.Batch(tea.WindowSize(), m.instanceChanged(), m.startInstance(instance, finalizer))
+}
+
+// killInstance kills the instance and forgets it, unless its branch is checked out.
+func (m *home) killInstance(instance *session.Instance) error {
+	// Get worktree and check if branch is checked out
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		return err
+	}
+
+	checkedOut, err := worktree.IsBranchCheckedOut()
+	if err != nil {
+		return err
+	}
+
+	if checkedOut {
+		return fmt.Errorf("instance %s is currently checked out", instance.Title)
+	}
+
+	// Delete from storage first
+	if err := m.storage.DeleteInstance(instance.Title); err != nil {
+		return err
+	}
+
+	// Then kill the instance
+	err = instance.Kill()
+	m.list.RemoveKilled(instance)
+	return err
+}
+
+// fanOut starts an instance for each program in a new group named after the title, and sends all of them the
+// prompt once they have started.
+func (m *home) fanOut(title string, programs []string, prompt string) tea.Cmd {
+	instances, err := session.FanOut(".", title, programs)
+	if err != nil {
+		return m.handleError(err)
+	}
+	for _, instance := range instances {
+		if err := m.validateTitle(instance.Title); err != nil {
+			return m.handleError(err)
+		}
+	}
+	m.rememberPrompt(prompt)
+	cmds := make([]tea.Cmd, 0, len(instances))
+	for _, instance := range instances {
+		cmds = append(cmds, m.launchInstance(instance, prompt))
+	}
+	return tea.Batch(cmds...)
+}
+
+// verifiedMsg is sent when the verify command finished for an instance in the compare view.
+type verifiedMsg struct {
+	instance *session.Instance
+	result   *session.Verification
+	err      error
+}
+
+// winnerKeptMsg is sent when the other instances of the compared group were killed, or some of them failed to.
+type winnerKeptMsg struct {
+	winner *session.Instance
+	err    error
+}
+
+// handleCompareKeyPress handles a key in the compare view.
+func (m *home) handleCompareKeyPress(msg tea.KeyMsg) tea.Cmd {
+	switch m.compareView.HandleKeyPress(msg) {
+	case ui.CompareClose:
+		m.compareView = nil
+		m.state = stateDefault
+		return m.instanceChanged()
+	case ui.CompareVerify:
+		var cmds []tea.Cmd
+		for _, instance := range m.compareView.Candidates() {
+			if m.compareView.Verifying(instance) {
+				continue
+			}
+			if !instance.Started() || instance.Paused() {
+				m.compareView.SetVerification(instance, nil,
+					fmt.Errorf("instance %s has no worktree", instance.Title))
+				continue
+			}
+			m.compareView.SetVerifying(instance)
+			cmds = append(cmds, func() tea.Msg {
+				result, err := instance.Verify()
+				return verifiedMsg{instance: instance, result: result, err: err}
+			})
+		}
+		return tea.Batch(cmds...)
+	case ui.CompareKeep:
+		return m.keepWinner()
+	}
+	return nil
+}
+
+// keepWinner asks whether to kill the instances of the compared group other than the selected one, and closes
+// the compare view with the selected one selected in the list if they were killed.
+func (m *home) keepWinner() tea.Cmd {
+	winner := m.compareView.Selected()
+	var losers []*session.Instance
+	for _, instance := range m.compareView.Candidates() {
+		if instance == winner {
+			continue
+		}
+		if instance.Status == session.Loading {
+			return m.handleError(fmt.Errorf("instance %s is still starting", instance.Title))
+		}
+		losers = append(losers, instance)
+	}
+
+	keepAction := func() tea.Msg {
+		var errs []error
+		for _, instance := range losers {
+			if err := m.killInstance(instance); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return winnerKeptMsg{winner: winner, err: errors.Join(errs...)}
+	}
+	message := fmt.Sprintf("[!] Keep '%s' and kill the %d other sessions of group '%s'?",
+		winner.Title, len(losers), m.compareView.Group())
+	cmd := m.confirmAction(message, keepAction)
+	m.confirmationOverlay.OnCancel = func() {
+		m.state = stateCompare
+	}
+	return cmd
+}
+
+// handleWinnerKept closes the compare view with the winner selected in the list.
+func (m *home) handleWinnerKept(msg winnerKeptMsg) tea.Cmd {
+	m.compareView = nil
+	m.list.SelectInstance(msg.winner)
+	if msg.err != nil {
+		return tea.Batch(m.handleError(msg.err), m.instanceChanged())
+	}
+	return m.instanceChanged()
+}
+
+// openPrompt shows the prompt overlay to send a prompt to the targets. Without targets, the prompt is sent to the
+// selected instance, which was just created.
+func (m *home) openPrompt(targets []*session.Instance, title string) tea.Cmd {
+	m.resetPromptTargets()
+	m.promptTargets = targets
+	m.state = statePrompt
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay(title+" (ctrl+l: prompt library and history)", "")
+	return tea.WindowSize()
+}
+
+// resetPromptTargets forgets where the prompt entered in statePrompt would have been sent.
+func (m *home) resetPromptTargets() {
+	m.promptTargets = nil
+	m.promptBroadcast = false
+	m.promptPerInstance = false
+}
+
+// sendPrompt sends the prompt to the instances. Instances which are still starting get it once they have started.
+// With perInstance, the prompt is a template which is rendered for each instance.
+func (m *home) sendPrompt(instances []*session.Instance, prompt string, perInstance bool) error {
+	var tmpl *session.PromptTemplate
+	if perInstance {
+		var err error
+		if tmpl, err = session.ParsePromptTemplate("prompt", prompt); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for _, instance := range instances {
+		text := prompt
+		if tmpl != nil {
+			var err error
+			if text, err = tmpl.Render(instance.PromptContext(), nil); err != nil {
+				errs = append(errs, fmt.Errorf("could not send the prompt to %s: %w", instance.Title, err))
+				continue
+			}
+		}
+		switch {
+		case instance.Status == session.Loading:
+			if m.pendingPrompts == nil {
+				m.pendingPrompts = make(map[*session.Instance]string)
+			}
+			m.pendingPrompts[instance] = text
+		case !instance.Started() || instance.Paused():
+			errs = append(errs, fmt.Errorf("instance %s is paused", instance.Title))
+		default:
+			if err := instance.SendPrompt(text); err != nil {
+				errs = append(errs, fmt.Errorf("could not send the prompt to %s: %w", instance.Title, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rememberPrompt adds the prompt to the history which the prompt library offers.
+func (m *home) rememberPrompt(prompt string) {
+	if err := m.appState.AddPromptHistory(prompt); err != nil {
+		log.WarningLog.Printf("could not save the prompt history: %v", err)
+	}
+}
+
+// openPromptLibrary shows the prompts of the user and of the repository of the selected instance, to insert one
+// into the prompt being entered.
+func (m *home) openPromptLibrary() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	// Instances outside of a repository still get the prompts of the user.
+	repoPath, err := git.FindRepoRoot(selected.Path)
+	if err != nil {
+		repoPath = ""
+	}
+	prompts, err := config.LoadPrompts(repoPath)
+	if err != nil {
+		return m.handleError(err)
+	}
+	m.promptPicker = ui.NewPromptPicker(prompts, m.appState.GetPromptHistory())
+	m.state = statePromptLibrary
+	return tea.WindowSize()
+}
+
+// insertPrompt renders the chosen prompt for the instance the prompt is sent to, and inserts it into the prompt
+// being entered. For several instances, a prompt which differs per instance is rendered for each of them when it's
+// sent, so only the variables of the user are filled in. A prompt of the history which was sent that way is rendered
+// again.
+func (m *home) insertPrompt(choice *ui.PromptChoice) tea.Cmd {
+	target := m.list.GetSelectedInstance()
+	if len(m.promptTargets) == 1 {
+		target = m.promptTargets[0]
+	}
+	if target == nil {
+		return nil
+	}
+	tmpl := choice.Template
+	if tmpl == nil {
+		if parsed, err := session.ParsePromptTemplate("recent", choice.Text); err == nil && parsed.PerInstance() &&
+			len(parsed.Variables()) == 0 {
+			tmpl = parsed
+		}
+	}
+
+	text := choice.Text
+	var err error
+	switch {
+	case tmpl == nil:
+	case len(m.promptTargets) > 1 && tmpl.PerInstance():
+		text, err = tmpl.RenderDeferred(choice.Values)
+		m.promptPerInstance = true
+	default:
+		text, err = tmpl.Render(target.PromptContext(), choice.Values)
+	}
+	if err != nil {
+		return m.handleError(err)
+	}
+	m.textInputOverlay.InsertString(text)
+	return tea.WindowSize()
+}
+
+// handleDiffKeyPress handles the keys which navigate the diff of the selected instance in the diff tab.
+func (m *home) handleDiffKeyPress(name keys.KeyName) (tea.Model, tea.Cmd) {
+	diff := m.tabbedWindow.GetDiffPane()
+	switch name {
+	case keys.KeyDiffNextFile:
+		diff.NextFile()
+	case keys.KeyDiffPrevFile:
+		diff.PrevFile()
+	case keys.KeyDiffNextHunk:
+		diff.NextHunk()
+	case keys.KeyDiffPrevHunk:
+		diff.PrevHunk()
+	case keys.KeyDiffToggleFile:
+		diff.ToggleFile()
+	case keys.KeyDiffToggleAll:
+		diff.ToggleAllFiles()
+	case keys.KeyDiffNextLine:
+		diff.NextLine()
+	case keys.KeyDiffPrevLine:
+		diff.PrevLine()
+	case keys.KeyDiffMarkHunk:
+		diff.ToggleHunkMark()
+	case keys.KeyDiffSideBySide:
+		diff.ToggleSideBySide()
+	default:
+		return m.handleDiffReviewKeyPress(name)
+	}
+	return m, nil
+}
+
+// handleDiffReviewKeyPress handles the keys which modify the worktree or the review of the selected instance from
+// the diff tab.
+func (m *home) handleDiffReviewKeyPress(name keys.KeyName) (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() || selected.Paused() {
+		return m, nil
+	}
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	diff := m.tabbedWindow.GetDiffPane()
+
+	switch name {
+	case keys.KeyDiffStage:
+		patch, count := diff.MarkedPatch()
+		if count == 0 {
+			return m, nil
+		}
+		// The hunks of the other scopes aren't relative to the index, so they can't be staged.
+		if scope, _ := selected.DiffScope(); scope != session.DiffScopeUncommitted {
+			return m, m.handleError(fmt.Errorf("press v to show the uncommitted changes, only their hunks can be staged"))
+		}
+		if err := worktree.StagePatch(patch); err != nil {
+			return m, m.handleError(err)
+		}
+		diff.ClearHunkMarks()
+		return m, nil
+	case keys.KeyDiffRevert:
+		patch, count := diff.MarkedPatch()
+		if count == 0 {
+			return m, nil
+		}
+		revertAction := func() tea.Msg {
+			if err := worktree.RevertPatch(patch); err != nil {
+				return err
+			}
+			diff.ClearHunkMarks()
+			if err := selected.UpdateDiffStats(); err != nil {
+				return err
+			}
+			return instanceChangedMsg{}
+		}
+		message := fmt.Sprintf("[!] Revert %d hunk(s) in session '%s'?", count, selected.Title)
+		return m, m.confirmAction(message, revertAction)
+	case keys.KeyDiffCommit:
+		return m, m.promptText("Commit message for the staged hunks", "", func(value string) tea.Cmd {
+			if strings.TrimSpace(value) == "" {
+				return m.handleError(fmt.Errorf("commit message cannot be empty"))
+			}
+			if err := worktree.CommitStaged(value); err != nil {
+				return m.handleError(err)
+			}
+			if err := selected.UpdateDiffStats(); err != nil {
+				return m.handleError(err)
+			}
+			return m.instanceChanged()
+		})
+	case keys.KeyDiffComment:
+		comment, ok := diff.CommentTarget()
+		if !ok {
+			return m, nil
+		}
+		title := fmt.Sprintf("Comment on %s:%d", comment.Path, comment.Line)
+		return m, m.promptText(title, "", func(value string) tea.Cmd {
+			if strings.TrimSpace(value) == "" {
+				return nil
+			}
+			comment.Text = strings.TrimSpace(value)
+			selected.AddReviewComment(comment)
+			return m.instanceChanged()
+		})
+	case keys.KeyDiffSendComments:
+		if err := selected.SendReviewComments(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case keys.KeyDiffScope:
+		if err := selected.CycleDiffScope(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case keys.KeyDiffRef:
+		return m, m.promptText("Diff against branch, tag or commit", "", func(value string) tea.Cmd {
+			ref := strings.TrimSpace(value)
+			if ref == "" {
+				return nil
+			}
+			if err := selected.SetDiffScope(session.DiffScopeRef, ref); err != nil {
+				return m.handleError(err)
+			}
+			return m.instanceChanged()
+		})
+	}
+	return m, nil
+}
+
+// showSearchMatch selects the instance of the match and shows the match in the preview pane's scroll mode, or in
+// the transcript view if it is not in the pane.
+func (m *home) showSearchMatch(match ui.SearchMatch) tea.Cmd {
+	instance := match.Document.Instance
+	m.list.SelectInstance(instance)
+	if cmd := m.instanceChanged(); cmd != nil {
+		return cmd
+	}
+
+	if match.Document.Source == session.SearchSourceTranscript {
+		m.transcriptView = ui.NewTranscriptView(instance.Title, strings.Join(match.Document.Lines, "\n"))
+		m.transcriptView.ShowMatch(match.Query, match.Line)
+		m.state = stateTranscript
+		return tea.WindowSize()
+	}
+	if err := m.tabbedWindow.ShowPreviewLine(instance, match.Line); err != nil {
+		return m.handleError(err)
+	}
+	m.menu.SetInDiffTab(false)
+	return nil
+}
+
+// promptText shows the text input overlay, filled with value, and calls handler with the entered text once it is
+// submitted.
+func (m *home) promptText(title, value string, handler func(value string) tea.Cmd) tea.Cmd {
+	m.state = stateInput
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay(title, value)
+	m.inputHandler = handler
+	return tea.WindowSize()
+}
+
 // instanceChanged updates the preview pane, menu, and diff pane based on the selected instance. It returns an error
 // Cmd if there was any error.
 func (m *home) instanceChanged() tea.Cmd {
@@ -647,6 +1453,125 @@ func (m *home) instanceChanged() tea.Cmd {
 	return nil
 }
 
+// attachSelected attaches to the selected instance until it's detached. The next and previous keys attach to the
+// neighbouring instances which can be attached, wrapping around.
+func (m *home) attachSelected() {
+	for {
+		selected := m.list.GetSelectedInstance()
+		ch, err := m.list.Attach()
+		if err != nil {
+			m.handleError(err)
+			return
+		}
+		<-ch
+
+		step := 0
+		switch selected.AttachResult() {
+		case tmux.AttachNext:
+			step = 1
+		case tmux.AttachPrevious:
+			step = -1
+		}
+		if step == 0 || !m.list.SelectNext(step, attachable) {
+			return
+		}
+	}
+}
+
+// reap plans which idle instances to pause and which paused ones whose branch was merged to kill, as the reaper
+// config says. Checking the branches takes a while, so the plan is made in the background. See handleReapPlanned.
+func (m *home) reap() tea.Cmd {
+	cfg := m.appConfig.Reaper
+	if cfg.PauseIdleHours <= 0 && cfg.KillMergedDays <= 0 {
+		return nil
+	}
+	var data []session.InstanceData
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() && instance.Status != session.Loading {
+			data = append(data, instance.ToInstanceData())
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	now := time.Now()
+	return func() tea.Msg {
+		return reapPlannedMsg{plan: session.PlanReaping(cfg, data, now)}
+	}
+}
+
+// reapPlannedMsg is the plan of the reaper.
+type reapPlannedMsg struct {
+	plan []session.Reaping
+}
+
+// reaped is an instance which the reaper paused or killed in the background.
+type reaped struct {
+	instance *session.Instance
+	reaping  session.Reaping
+	// status is the status of the instance before it was reaped, which it gets back if reaping failed.
+	status session.Status
+	err    error
+}
+
+// reapedMsg is sent when the reaper carried out its plan.
+type reapedMsg struct {
+	reaped []reaped
+}
+
+// handleReapPlanned carries out the plan of the reaper in the background, for the instances which are still in the
+// state it was made for. They're Loading until handleReaped applies the result.
+func (m *home) handleReapPlanned(msg reapPlannedMsg) tea.Cmd {
+	instances := make(map[string]*session.Instance)
+	for _, instance := range m.list.GetInstances() {
+		instances[instance.Title] = instance
+	}
+	var targets []reaped
+	for _, reaping := range msg.plan {
+		instance := instances[reaping.Title]
+		if instance == nil || !instance.Started() || instance.Status == session.Loading ||
+			instance.Paused() != (reaping.Action == session.ReapKill) {
+			continue
+		}
+		targets = append(targets, reaped{instance: instance, reaping: reaping, status: instance.Status})
+		instance.SetStatus(session.Loading)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		for i := range targets {
+			targets[i].err = targets[i].instance.Reap(targets[i].reaping.Action)
+		}
+		return reapedMsg{reaped: targets}
+	}
+}
+
+// handleReaped applies the actions of the reaper to the instances and saves them.
+func (m *home) handleReaped(msg reapedMsg) tea.Cmd {
+	for _, r := range msg.reaped {
+		if r.err != nil {
+			log.ErrorLog.Printf("reaper could not %s %s: %v", r.reaping.Action, r.reaping.Title, r.err)
+			r.instance.SetStatus(r.status)
+			continue
+		}
+		log.InfoLog.Printf("reaper: %s %s, %s", r.reaping.Action, r.reaping.Title, r.reaping.Reason)
+		r.instance.CompleteReap(r.reaping.Action)
+		if r.reaping.Action == session.ReapKill {
+			m.list.RemoveKilled(r.instance)
+		}
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	return m.instanceChanged()
+}
+
+// attachable returns true if the instance has a running session to attach to.
+func attachable(instance *session.Instance) bool {
+	return instance.Started() && !instance.Paused() && instance.TmuxAlive()
+}
+
 type keyupMsg struct{}
 
 // keydownCallback clears the menu option highlighting after 500ms.
@@ -670,8 +1595,67 @@ type previewTickMsg struct{}
 
 type tickUpdateMetadataMessage struct{}
 
+type tickReapMessage struct{}
+
 type instanceChangedMsg struct{}
 
+// instanceStartedMsg is sent when a new instance has started in the background, or failed to.
+type instanceStartedMsg struct {
+	instance  *session.Instance
+	launch    *session.Launch
+	finalizer func()
+	err       error
+}
+
+// startInstance returns a Cmd which starts a new instance. The instance itself is only changed once the message is
+// handled, since the list keeps rendering it meanwhile. The finalizer of the instance is called once it has started.
+func (m *home) startInstance(instance *session.Instance, finalizer func()) tea.Cmd {
+	return func() tea.Msg {
+		launch, err := instance.Launch()
+		return instanceStartedMsg{instance: instance, launch: launch, finalizer: finalizer, err: err}
+	}
+}
+
+// handleInstanceStarted registers and saves an instance which has started, and sends the prompt entered while it
+// was starting. An instance which failed to start is removed from the list.
+func (m *home) handleInstanceStarted(msg instanceStartedMsg) tea.Cmd {
+	prompt, hasPrompt := m.pendingPrompts[msg.instance]
+	delete(m.pendingPrompts, msg.instance)
+
+	if msg.err != nil {
+		// Close the prompt which was opened for the instance.
+		if (m.state == statePrompt || m.state == statePromptLibrary) && m.list.GetSelectedInstance() == msg.instance {
+			m.textInputOverlay = nil
+			m.promptPicker = nil
+			m.resetPromptTargets()
+			m.state = stateDefault
+			m.menu.SetState(ui.StateDefault)
+		}
+		m.list.Remove(msg.instance)
+		notify.Notify(notify.EventError, msg.instance.Title, fmt.Sprintf("%s failed to start", msg.instance.Title))
+		return tea.Batch(m.handleError(fmt.Errorf("failed to start %s: %w", msg.instance.Title, msg.err)),
+			m.instanceChanged())
+	}
+
+	msg.instance.CompleteLaunch(msg.launch)
+	msg.finalizer()
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	if hasPrompt {
+		if err := msg.instance.SendPrompt(prompt); err != nil {
+			return m.handleError(err)
+		}
+	}
+	return tea.Batch(tea.WindowSize(), m.instanceChanged())
+}
+
+// tickReapCmd is the callback to check every minute whether instances need to be paused or killed. See reap.
+var tickReapCmd = func() tea.Msg {
+	time.Sleep(time.Minute)
+	return tickReapMessage{}
+}
+
 // tickUpdateMetadataCmd is the callback to update the metadata of the instances every 500ms. Note that we iterate
 // overall the instances and capture their output. It's a pretty expensive operation. Let's do it 2x a second only.
 var tickUpdateMetadataCmd = func() tea.Msg {
@@ -694,6 +1678,18 @@ func (m *home) handleError(err error) tea.Cmd {
 	}
 }
 
+// takeConfirmed returns a Cmd which delivers the message of the action confirmed in stateConfirm, if any.
+func (m *home) takeConfirmed() tea.Cmd {
+	msg := m.confirmed
+	m.confirmed = nil
+	if msg == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
 // confirmAction shows a confirmation modal and stores the action to execute on confirm
 func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 	m.state = stateConfirm
@@ -706,9 +1702,9 @@ func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 	// Set callbacks for confirmation and cancellation
 	m.confirmationOverlay.OnConfirm = func() {
 		m.state = stateDefault
-		// Execute the action if it exists
+		// Execute the action if it exists. Its message, like an error, is handled next.
 		if action != nil {
-			_ = action()
+			m.confirmed = action()
 		}
 	}
 
@@ -720,6 +1716,13 @@ func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 }
 
 func (m *home) View() string {
+	if m.state == stateTranscript && m.transcriptView != nil {
+		return m.transcriptView.String()
+	}
+	if m.state == stateCompare && m.compareView != nil {
+		return m.compareView.String()
+	}
+
 	listWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
 	previewWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
 	listAndPreview := lipgloss.JoinHorizontal(lipgloss.Top, listWithPadding, previewWithPadding)
@@ -731,7 +1734,7 @@ func (m *home) View() string {
 		m.errBox.String(),
 	)
 
-	if m.state == statePrompt {
+	if m.state == statePrompt || m.state == stateInput {
 		if m.textInputOverlay == nil {
 			log.ErrorLog.Printf("text input overlay is nil")
 		}
@@ -741,6 +1744,10 @@ func (m *home) View() string {
 			log.ErrorLog.Printf("text overlay is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.textOverlay.Render(), mainView, true, true)
+	} else if m.state == stateSearch {
+		return overlay.PlaceOverlay(0, 0, m.searchOverlay.Render(), mainView, true, true)
+	} else if m.state == statePromptLibrary {
+		return overlay.PlaceOverlay(0, 0, m.promptPicker.Render(), mainView, true, true)
 	} else if m.state == stateConfirm {
 		if m.confirmationOverlay == nil {
 			log.ErrorLog.Printf("confirmation overlay is nil")
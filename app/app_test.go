@@ -9,6 +9,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -357,6 +359,20 @@ func TestConfirmActionWithDifferentTypes(t *testing.T) {
 		_, ok := receivedMsg.(instanceChangedMsg)
 		assert.True(t, ok, "Expected instanceChangedMsg but got %T", receivedMsg)
 	})
+
+	t.Run("delivers the message of the action", func(t *testing.T) {
+		expectedErr := fmt.Errorf("test error")
+		h.confirmAction("Error action?", func() tea.Msg {
+			return expectedErr
+		})
+		require.Equal(t, stateConfirm, h.state)
+
+		_, cmd := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		assert.Equal(t, stateDefault, h.state)
+		assert.Nil(t, h.confirmationOverlay)
+		require.NotNil(t, cmd)
+		assert.Equal(t, expectedErr, cmd())
+	})
 }
 
 // TestMultipleConfirmationsDontInterfere tests that multiple confirmations don't interfere with each other
@@ -464,3 +480,266 @@ func TestConfirmationModalVisualAppearance(t *testing.T) {
 	// Test that the danger indicator is preserved
 	assert.Contains(t, rendered, "[!")
 }
+
+// TestInstanceFailingToStartIsRemoved tests that an instance which fails to start in the background is removed from
+// the list, together with the prompt entered for it.
+func TestInstanceFailingToStartIsRemoved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := spinner.New()
+	h := &home{
+		ctx:          context.Background(),
+		state:        stateDefault,
+		appConfig:    config.DefaultConfig(),
+		appState:     config.DefaultState(),
+		list:         ui.NewList(&s, false),
+		menu:         ui.NewMenu(),
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+		errBox:       ui.NewErrBox(),
+	}
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "broken", Path: t.TempDir(), Program: "claude"})
+	require.NoError(t, err)
+	finalizer := h.list.AddInstance(instance)
+	instance.SetStatus(session.Loading)
+
+	// The prompt is entered while the instance is starting.
+	h.state = statePrompt
+	h.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", "fix the tests")
+	h.textInputOverlay.HandleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	_, _ = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "fix the tests", h.pendingPrompts[instance])
+
+	_, cmd := h.Update(instanceStartedMsg{instance: instance, finalizer: finalizer, err: fmt.Errorf("setup command failed")})
+	assert.NotNil(t, cmd)
+	assert.Equal(t, 0, h.list.NumInstances())
+	assert.Empty(t, h.pendingPrompts)
+	assert.Equal(t, stateDefault, h.state)
+	assert.Contains(t, h.errBox.String(), "failed to start broken")
+}
+
+// TestInstanceStartsInBackground tests that the list can keep rendering an instance while it starts in the
+// background, and that the instance only changes once it has started. Run it with -race.
+// testHomeWithRepo returns a home which saves to a temporary home directory, and a git repository to start
+// instances in. Skips the test if tmux is not installed.
+func testHomeWithRepo(t *testing.T) (*home, string) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux is not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test User", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	s := spinner.New()
+	appState := config.DefaultState()
+	storage, err := session.NewStorage(appState)
+	require.NoError(t, err)
+	h := &home{
+		ctx:          context.Background(),
+		state:        stateDefault,
+		appConfig:    config.DefaultConfig(),
+		appState:     appState,
+		storage:      storage,
+		list:         ui.NewList(&s, false),
+		menu:         ui.NewMenu(),
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+		errBox:       ui.NewErrBox(),
+	}
+	h.list.SetSize(80, 20)
+	return h, repo
+}
+
+func TestInstanceStartsInBackground(t *testing.T) {
+	h, repo := testHomeWithRepo(t)
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title: fmt.Sprintf("start-test-%d", os.Getpid()), Path: repo, Program: "sh"})
+	require.NoError(t, err)
+	finalizer := h.list.AddInstance(instance)
+	instance.SetStatus(session.Loading)
+
+	msgs := make(chan tea.Msg)
+	go func() { msgs <- h.startInstance(instance, finalizer)() }()
+	var msg tea.Msg
+	for msg == nil {
+		select {
+		case msg = <-msgs:
+		default:
+			_ = h.list.String()
+			_ = instance.PromptContext()
+			assert.False(t, instance.Started())
+		}
+	}
+	h.Update(msg)
+	defer func() { _ = instance.Kill() }()
+
+	require.True(t, instance.Started())
+	assert.Equal(t, session.Running, instance.Status)
+	assert.NotEmpty(t, instance.Branch)
+	assert.True(t, instance.TmuxAlive())
+}
+
+func TestReaperRunsInBackground(t *testing.T) {
+	h, repo := testHomeWithRepo(t)
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title: fmt.Sprintf("reap-test-%d", os.Getpid()), Path: repo, Program: "sh"})
+	require.NoError(t, err)
+	finalizer := h.list.AddInstance(instance)
+	instance.SetStatus(session.Loading)
+	h.Update(h.startInstance(instance, finalizer)())
+	defer func() { _ = instance.Kill() }()
+	require.True(t, instance.Started())
+	instance.SetStatus(session.Ready)
+
+	_, cmd := h.Update(reapPlannedMsg{plan: []session.Reaping{
+		{Title: instance.Title, Action: session.ReapPause, Reason: "idle for 5h"},
+		{Title: instance.Title, Action: session.ReapKill, Reason: "paused for 8d, branch merged"},
+		{Title: "gone", Action: session.ReapPause, Reason: "idle for 5h"},
+	}})
+	require.NotNil(t, cmd)
+	assert.Equal(t, session.Loading, instance.Status, "the instance is busy while it's reaped")
+
+	msgs := make(chan tea.Msg)
+	go func() { msgs <- cmd() }()
+	var msg tea.Msg
+	for msg == nil {
+		select {
+		case msg = <-msgs:
+		default:
+			_ = h.list.String()
+			h.Update(tickUpdateMetadataMessage{})
+			assert.ErrorContains(t, instance.Pause(), "busy")
+		}
+	}
+	require.Len(t, msg.(reapedMsg).reaped, 1, "the plan is only carried out for instances in the state it was made for")
+	h.Update(msg)
+	assert.True(t, instance.Paused())
+	assert.NoDirExists(t, instance.ToInstanceData().Worktree.WorktreePath)
+}
+
+// TestBroadcastPrompt tests that a prompt is sent to every marked instance, queued for those which are starting, and
+// added to the history.
+func TestBroadcastPrompt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := spinner.New()
+	h := &home{
+		ctx:          context.Background(),
+		state:        stateDefault,
+		appConfig:    config.DefaultConfig(),
+		appState:     config.DefaultState(),
+		list:         ui.NewList(&s, false),
+		menu:         ui.NewMenu(),
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+		errBox:       ui.NewErrBox(),
+	}
+	var instances []*session.Instance
+	for _, title := range []string{"a", "b", "c"} {
+		instance, err := session.NewInstance(session.InstanceOptions{Title: title, Path: t.TempDir(), Program: "claude"})
+		require.NoError(t, err)
+		_ = h.list.AddInstance(instance)
+		instance.SetStatus(session.Loading)
+		instances = append(instances, instance)
+	}
+
+	// Global keys are handled once the menu highlighted them.
+	press := func(msg tea.KeyMsg) {
+		h.keySent = true
+		_, _ = h.handleKeyPress(msg)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	assert.Equal(t, stateDefault, h.state, "nothing is marked yet")
+	assert.Contains(t, h.errBox.String(), "mark sessions")
+
+	h.list.SetSelectedInstance(0)
+	press(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	h.list.SetSelectedInstance(2)
+	press(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	assert.Equal(t, []*session.Instance{instances[0], instances[2]}, h.list.Marked())
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	require.Equal(t, statePrompt, h.state)
+	h.textInputOverlay.InsertString("rebase on main")
+	h.textInputOverlay.HandleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	_, _ = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stateDefault, h.state)
+	assert.Equal(t, map[*session.Instance]string{instances[0]: "rebase on main", instances[2]: "rebase on main"},
+		h.pendingPrompts)
+	assert.Equal(t, []string{"rebase on main"}, h.appState.GetPromptHistory())
+	assert.Empty(t, h.list.Marked(), "the marks are cleared once the prompt was sent")
+
+	// A prompt of the library is rendered for each instance.
+	h.list.SetSelectedInstance(0)
+	press(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	h.list.SetSelectedInstance(1)
+	press(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	require.Equal(t, statePrompt, h.state)
+	tmpl, err := session.ParsePromptTemplate("fix", "Fix {{.Issue}} on {{.Branch}}")
+	require.NoError(t, err)
+	h.insertPrompt(&ui.PromptChoice{Template: tmpl, Values: map[string]string{"Issue": "#42"}})
+	assert.Equal(t, "Fix #42 on {{.Branch}}", h.textInputOverlay.GetValue())
+	h.textInputOverlay.HandleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	_, _ = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "Fix #42 on "+instances[0].PromptContext().Branch, h.pendingPrompts[instances[0]])
+	assert.Equal(t, "Fix #42 on "+instances[1].PromptContext().Branch, h.pendingPrompts[instances[1]])
+	assert.NotEqual(t, h.pendingPrompts[instances[0]], h.pendingPrompts[instances[1]])
+}
+
+// TestKeepWinner tests that canceling the confirmation returns to the compare view, and that the compare view is
+// closed with the winner selected once the others were killed.
+func TestKeepWinner(t *testing.T) {
+	s := spinner.New()
+	h := &home{
+		ctx:          context.Background(),
+		state:        stateDefault,
+		appConfig:    config.DefaultConfig(),
+		list:         ui.NewList(&s, false),
+		menu:         ui.NewMenu(),
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+		errBox:       ui.NewErrBox(),
+	}
+	var candidates []*session.Instance
+	for _, title := range []string{"fix-a", "fix-b"} {
+		instance, err := session.NewInstance(session.InstanceOptions{Title: title, Path: t.TempDir(), Program: "claude"})
+		require.NoError(t, err)
+		instance.Group = "fix"
+		instance.SetStatus(session.Ready)
+		_ = h.list.AddInstance(instance)
+		candidates = append(candidates, instance)
+	}
+	h.compareView = ui.NewCompareView("fix", candidates)
+	h.state = stateCompare
+
+	h.keepWinner()
+	require.Equal(t, stateConfirm, h.state)
+	_, _ = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.Equal(t, stateCompare, h.state)
+	assert.NotNil(t, h.compareView)
+
+	h.list.SetSelectedInstance(1)
+	_, cmd := h.Update(winnerKeptMsg{winner: candidates[0], err: fmt.Errorf("could not kill fix-b")})
+	assert.NotNil(t, cmd)
+	assert.Nil(t, h.compareView)
+	assert.Equal(t, candidates[0], h.list.GetSelectedInstance())
+	assert.Contains(t, h.errBox.String(), "could not kill fix-b")
+}
+
+func TestUnusedTitle(t *testing.T) {
+	spinner := spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	list := ui.NewList(&spinner, false)
+	for _, title := range []string{"feature", "feature-2", strings.Repeat("x", 32)} {
+		instance, err := session.NewInstance(session.InstanceOptions{Title: title, Path: t.TempDir(), Program: "claude"})
+		require.NoError(t, err)
+		_ = list.AddInstance(instance)
+	}
+	h := &home{ctx: context.Background(), list: list}
+
+	assert.Equal(t, "feature-3", h.unusedTitle("feature"))
+	assert.Equal(t, strings.Repeat("x", 30)+"-2", h.unusedTitle(strings.Repeat("x", 32)))
+	assert.ErrorContains(t, h.validateTitle("feature-2"), "already exists")
+	assert.ErrorContains(t, h.validateTitle(""), "empty")
+	assert.NoError(t, h.validateTitle("other"))
+}
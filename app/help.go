@@ -3,9 +3,11 @@ package app
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/tmux"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -34,6 +36,13 @@ func helpStart(instance *session.Instance) helpText {
 }
 
 func (h helpTypeGeneral) toContent() string {
+	detachKey, next, previous := tmux.AttachKeyNames()
+	nextKeys := strings.Trim(next+", "+previous, ", ")
+	nextHelp := keyStyle.Render(nextKeys) + descStyle.Render(strings.Repeat(" ", max(10-len(nextKeys), 1))+
+		"- Attach to the next/previous session while attached")
+	if nextKeys == "" {
+		nextHelp = descStyle.Render("Set attach.next_key and attach.previous_key in the config to switch sessions while attached")
+	}
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		titleStyle.Render("Claude Squad"),
 		"",
@@ -42,10 +51,27 @@ func (h helpTypeGeneral) toContent() string {
 		headerStyle.Render("Managing:"),
 		keyStyle.Render("n")+descStyle.Render("         - Create a new session"),
 		keyStyle.Render("N")+descStyle.Render("         - Create a new session with a prompt"),
+		keyStyle.Render("i")+descStyle.Render("         - Send a prompt to the selected session"),
+		keyStyle.Render("space")+descStyle.Render("     - Mark the selected session"),
+		keyStyle.Render("I")+descStyle.Render("         - Send the same prompt to all marked sessions"),
+		keyStyle.Render("A")+descStyle.Render("         - Toggle answering the prompts of the selected session (auto-yes)"),
+		keyStyle.Render("ctrl+l")+descStyle.Render("    - Insert a prompt of the library or history while entering a prompt"),
 		keyStyle.Render("D")+descStyle.Render("         - Kill (delete) the selected session"),
+		keyStyle.Render("R")+descStyle.Render("         - Rename the selected session, and optionally its branch"),
+		keyStyle.Render("y")+descStyle.Render("         - Duplicate: start a new session with the same prompt and base"),
+		keyStyle.Render("F")+descStyle.Render("         - Fork: start a new session from the current state of this one"),
+		keyStyle.Render("B")+descStyle.Render("         - Best-of-N: start a group of sessions with the same prompt"),
+		keyStyle.Render("=")+descStyle.Render("         - Compare the sessions of a group and keep the best one"),
 		keyStyle.Render("↑/j, ↓/k")+descStyle.Render("  - Navigate between sessions"),
 		keyStyle.Render("↵/o")+descStyle.Render("       - Attach to the selected session"),
-		keyStyle.Render("ctrl-q")+descStyle.Render("    - Detach from session"),
+		keyStyle.Render(detachKey)+descStyle.Render(strings.Repeat(" ", max(10-len(detachKey), 1))+"- Detach from session"),
+		nextHelp,
+		"",
+		headerStyle.Render("Organizing:"),
+		keyStyle.Render("g")+descStyle.Render("         - Set the group of the selected session, ↵ on a group folds it"),
+		keyStyle.Render("t")+descStyle.Render("         - Set the tags of the selected session"),
+		keyStyle.Render("f")+descStyle.Render("         - Filter the sessions, like tag:api status:ready repo:web"),
+		keyStyle.Render("s")+descStyle.Render("         - Sort by creation, last activity or diff size"),
 		"",
 		headerStyle.Render("Handoff:"),
 		keyStyle.Render("p")+descStyle.Render("         - Commit and push branch to github"),
@@ -55,7 +81,24 @@ func (h helpTypeGeneral) toContent() string {
 		headerStyle.Render("Other:"),
 		keyStyle.Render("tab")+descStyle.Render("       - Switch between preview and diff tabs"),
 		keyStyle.Render("shift-↓/↑")+descStyle.Render(" - Scroll in diff view"),
+		keyStyle.Render("X")+descStyle.Render("         - Show files modified by more than one session"),
+		keyStyle.Render("T")+descStyle.Render("         - Show the transcript of the session, / to search"),
+		keyStyle.Render("/")+descStyle.Render("         - Search the panes and transcripts of all sessions"),
+		keyStyle.Render("w")+descStyle.Render("         - Show the next window of the session (see .claude-squad.json)"),
 		keyStyle.Render("q")+descStyle.Render("         - Quit the application"),
+		"",
+		headerStyle.Render("Diff tab:"),
+		keyStyle.Render("[/]")+descStyle.Render("       - Jump to previous/next file"),
+		keyStyle.Render("{/}")+descStyle.Render("       - Jump to previous/next hunk"),
+		keyStyle.Render("z/Z")+descStyle.Render("       - Collapse/expand the file/all files"),
+		keyStyle.Render("v")+descStyle.Render("         - Cycle scope: session start, uncommitted, last agent turn"),
+		keyStyle.Render("V")+descStyle.Render("         - Diff against a branch, tag or commit"),
+		keyStyle.Render("|")+descStyle.Render("         - Toggle the side-by-side view on wide screens"),
+		keyStyle.Render("J/K")+descStyle.Render("       - Move the line cursor down/up"),
+		keyStyle.Render("space")+descStyle.Render("     - Mark the hunk for staging or reverting"),
+		keyStyle.Render("a/u")+descStyle.Render("       - Stage/revert the marked hunks, staging in the uncommitted scope"),
+		keyStyle.Render("C")+descStyle.Render("         - Commit the staged hunks"),
+		keyStyle.Render("m/M")+descStyle.Render("       - Comment on the line/send comments to the agent"),
 	)
 	return content
 }
@@ -83,12 +126,19 @@ func (h helpTypeInstanceStart) toContent() string {
 }
 
 func (h helpTypeInstanceAttach) toContent() string {
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	detachKey, next, previous := tmux.AttachKeyNames()
+	lines := []string{
 		titleStyle.Render("Attaching to Instance"),
 		"",
-		descStyle.Render("To detach from a session, press ")+keyStyle.Render("ctrl-q"),
-	)
-	return content
+		descStyle.Render("To detach from a session, press ") + keyStyle.Render(detachKey),
+	}
+	if next != "" {
+		lines = append(lines, descStyle.Render("To attach to the next session, press ")+keyStyle.Render(next))
+	}
+	if previous != "" {
+		lines = append(lines, descStyle.Render("To attach to the previous session, press ")+keyStyle.Render(previous))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 func (h helpTypeInstanceCheckout) toContent() string {
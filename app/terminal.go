@@ -0,0 +1,32 @@
+package app
+
+import (
+	"os"
+	"sync"
+)
+
+// terminal is the output of the program. The notifications of the terminal backends are written to it as well, and
+// the lock makes them go between the frames of the renderer rather than into one. It keeps the methods of a file, so
+// that the program still finds the terminal.
+type terminal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Write(p)
+}
+
+func (t *terminal) Read(p []byte) (int, error) {
+	return t.file.Read(p)
+}
+
+func (t *terminal) Close() error {
+	return t.file.Close()
+}
+
+func (t *terminal) Fd() uintptr {
+	return t.file.Fd()
+}
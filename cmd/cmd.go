@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"io"
 	"os/exec"
 	"strings"
 
@@ -22,10 +23,49 @@ func (e Exec) Output(cmd *exec.Cmd) ([]byte, error) {
 	return cmd.Output()
 }
 
+// Streamer is implemented by executors which can start long-running commands, like a tmux control mode client.
+// Callers should fall back to polling with Executor if it isn't implemented or Stream fails.
+type Streamer interface {
+	// Stream starts the command and returns pipes to its stdin and from its stdout. Closing stdout waits for the
+	// command to exit, so it should be closed after reading until EOF.
+	Stream(cmd *exec.Cmd) (io.WriteCloser, io.ReadCloser, error)
+}
+
+func (e Exec) Stream(cmd *exec.Cmd) (io.WriteCloser, io.ReadCloser, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	return stdin, waitCloser{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+// waitCloser waits for the command when its stdout is closed, so it doesn't linger as a zombie.
+type waitCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (w waitCloser) Close() error {
+	_ = w.ReadCloser.Close()
+	return w.cmd.Wait()
+}
+
 func MakeExecutor() Executor {
 	return Exec{}
 }
 
+// ShellQuote quotes s as a single word for sh.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func ToString(cmd *exec.Cmd) string {
 	if cmd == nil {
 		return "<nil>"
@@ -33,7 +73,9 @@ func ToString(cmd *exec.Cmd) string {
 	return strings.Join(cmd.Args, " ")
 }
 
-// GetWTaskCmd returns the wtask command for registration with main
-func GetWTaskCmd() *cobra.Command {
+// GetWTaskCmd returns the wtask command for registration with main. setup loads the agent config once logging is
+// initialized, since the packages it configures import this one.
+func GetWTaskCmd(setup func() error) *cobra.Command {
+	wtaskSetup = setup
 	return wtaskCmd
 }
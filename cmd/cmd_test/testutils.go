@@ -1,12 +1,16 @@
 package cmd_test
 
 import (
+	"errors"
+	"io"
 	"os/exec"
 )
 
 type MockCmdExec struct {
 	RunFunc    func(cmd *exec.Cmd) error
 	OutputFunc func(cmd *exec.Cmd) ([]byte, error)
+	// StreamFunc is optional. Without it, Stream fails and callers fall back to polling.
+	StreamFunc func(cmd *exec.Cmd) (io.WriteCloser, io.ReadCloser, error)
 }
 
 func (e MockCmdExec) Run(cmd *exec.Cmd) error {
@@ -16,3 +20,10 @@ func (e MockCmdExec) Run(cmd *exec.Cmd) error {
 func (e MockCmdExec) Output(cmd *exec.Cmd) ([]byte, error) {
 	return e.OutputFunc(cmd)
 }
+
+func (e MockCmdExec) Stream(cmd *exec.Cmd) (io.WriteCloser, io.ReadCloser, error) {
+	if e.StreamFunc == nil {
+		return nil, nil, errors.New("streaming is not supported by this mock")
+	}
+	return e.StreamFunc(cmd)
+}
@@ -18,6 +18,9 @@ var (
 	wtaskTimeoutFlag string
 	wtaskWebhookFlag string
 	wtaskProgramFlag string
+
+	// wtaskSetup loads the agent profiles, the approval policy, the audit log and the notifications. See GetWTaskCmd.
+	wtaskSetup func() error
 )
 
 // wtaskCmd represents the wtask command
@@ -54,6 +57,12 @@ func runWTask(cmd *cobra.Command, args []string) error {
 	log.Initialize(false)
 	defer log.Close()
 
+	if wtaskSetup != nil {
+		if err := wtaskSetup(); err != nil {
+			return err
+		}
+	}
+
 	log.InfoLog.Printf("Loading main task from: %s", taskFile)
 
 	// Load main task from file
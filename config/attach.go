@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AttachConfig configures the keys handled by claude squad while attached to a session. Keys are written like
+// "ctrl-q", "alt-n", "esc" or a single character, and a sequence of keys is separated by spaces, like "ctrl-b d".
+type AttachConfig struct {
+	// DetachKey returns to the list of sessions. Defaults to "ctrl-q".
+	DetachKey string `json:"detach_key,omitempty"`
+	// NextKey attaches to the next session in the list, like "ctrl-]". Unset by default, so that every key but the
+	// detach key reaches the agent.
+	NextKey string `json:"next_key,omitempty"`
+	// PreviousKey attaches to the previous session in the list, like "ctrl-\". Unset by default.
+	PreviousKey string `json:"previous_key,omitempty"`
+}
+
+// WithDefaults returns a copy of the config with unset values replaced by their defaults.
+func (c AttachConfig) WithDefaults() AttachConfig {
+	if c.DetachKey == "" {
+		c.DetachKey = "ctrl-q"
+	}
+	return c
+}
+
+// ParseKeySequence returns the bytes a terminal sends for the keys in spec. See AttachConfig for the syntax.
+func ParseKeySequence(spec string) ([]byte, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+	var seq []byte
+	for _, field := range fields {
+		key, err := parseKey(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key sequence %q: %w", spec, err)
+		}
+		seq = append(seq, key...)
+	}
+	return seq, nil
+}
+
+func parseKey(key string) ([]byte, error) {
+	lower := strings.ToLower(key)
+	switch {
+	case lower == "esc" || lower == "escape":
+		return []byte{0x1b}, nil
+	case lower == "tab":
+		return []byte{'\t'}, nil
+	case lower == "enter":
+		return []byte{'\r'}, nil
+	case lower == "space":
+		return []byte{' '}, nil
+	case strings.HasPrefix(lower, "ctrl-") && len(key) == len("ctrl-")+1:
+		c := key[len(key)-1]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		switch {
+		case c >= 'a' && c <= 'z':
+			return []byte{c - 'a' + 1}, nil
+		case c >= '@' && c <= '_':
+			// ctrl-@, ctrl-[, ctrl-\, ctrl-], ctrl-^ and ctrl-_ map to the control characters below ctrl-a and
+			// after ctrl-z.
+			return []byte{c - '@'}, nil
+		}
+		return nil, fmt.Errorf("no control character for %q", key)
+	case strings.HasPrefix(lower, "alt-") && len(key) > len("alt-"):
+		rest, err := parseKey(key[len("alt-"):])
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{0x1b}, rest...), nil
+	case len([]rune(key)) == 1:
+		return []byte(key), nil
+	}
+	return nil, fmt.Errorf("unknown key %q", key)
+}
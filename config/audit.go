@@ -0,0 +1,29 @@
+package config
+
+// AuditConfig configures the audit log, which records every prompt, keystroke and approval sent to an agent.
+type AuditConfig struct {
+	// Disabled turns the audit log off.
+	Disabled bool `json:"disabled,omitempty"`
+	// HashPayloads records only the SHA-256 of prompts and keystrokes instead of their text.
+	HashPayloads bool `json:"hash_payloads,omitempty"`
+	// PaneLines is how many of the last lines of the pane are recorded with each entry. Defaults to 10.
+	PaneLines int `json:"pane_lines,omitempty"`
+	// MaxSizeKB is the size at which the log of an instance is rotated. Defaults to 5120.
+	MaxSizeKB int `json:"max_size_kb,omitempty"`
+	// MaxFiles is how many rotated logs are kept per instance. Defaults to 5.
+	MaxFiles int `json:"max_files,omitempty"`
+}
+
+// WithDefaults returns a copy of the config with unset values replaced by their defaults.
+func (c AuditConfig) WithDefaults() AuditConfig {
+	if c.PaneLines <= 0 {
+		c.PaneLines = 10
+	}
+	if c.MaxSizeKB <= 0 {
+		c.MaxSizeKB = 5120
+	}
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = 5
+	}
+	return c
+}
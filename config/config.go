@@ -36,6 +36,19 @@ type Config struct {
 	DaemonPollInterval int `json:"daemon_poll_interval"`
 	// BranchPrefix is the prefix used for git branches created by the application.
 	BranchPrefix string `json:"branch_prefix"`
+	// Profiles are agent profiles which add to or replace the built-in ones. See AgentProfile.
+	Profiles []AgentProfile `json:"profiles,omitempty"`
+	// ApprovalPolicy restricts which prompts are approved in auto-yes mode. Without a policy, every prompt is
+	// approved.
+	ApprovalPolicy *ApprovalPolicy `json:"approval_policy,omitempty"`
+	// Audit configures the audit log of the instances. See AuditConfig.
+	Audit AuditConfig `json:"audit,omitempty"`
+	// Attach configures the keys handled while attached to a session. See AttachConfig.
+	Attach AttachConfig `json:"attach,omitempty"`
+	// Notifications configures how you're notified when instances need attention. See NotificationConfig.
+	Notifications NotificationConfig `json:"notifications,omitempty"`
+	// Reaper configures pausing idle instances and killing merged ones. See ReaperConfig.
+	Reaper ReaperConfig `json:"reaper,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
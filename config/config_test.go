@@ -237,3 +237,163 @@ func TestSaveConfig(t *testing.T) {
 		assert.Equal(t, testConfig.BranchPrefix, loadedConfig.BranchPrefix)
 	})
 }
+
+func TestAgentProfiles(t *testing.T) {
+	t.Run("matches programs by executable name", func(t *testing.T) {
+		assert.Equal(t, "claude", AgentProfileFor("/usr/local/bin/claude").Name)
+		assert.Equal(t, "aider", AgentProfileFor("aider --model ollama_chat/gemma3:1b").Name)
+		assert.Equal(t, "codex --full-auto", AgentProfileFor("codex --full-auto").Name)
+		assert.Empty(t, AgentProfileFor("codex --full-auto").ApprovalPatterns)
+	})
+
+	t.Run("loads profiles from config and profiles directory", func(t *testing.T) {
+		tempHome := t.TempDir()
+		profilesDir := filepath.Join(tempHome, ".claude-squad", ProfilesDirName)
+		require.NoError(t, os.MkdirAll(profilesDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(profilesDir, "codex-auto.json"),
+			[]byte(`{"commands": ["codex"], "command": "codex --full-auto", "env": {"CODEX_HOME": "/tmp"}}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(profilesDir, "broken.json"),
+			[]byte(`{"busy_patterns": ["("]}`), 0644))
+
+		originalHome := os.Getenv("HOME")
+		os.Setenv("HOME", tempHome)
+		defer os.Setenv("HOME", originalHome)
+
+		profiles := LoadAgentProfiles(&Config{Profiles: []AgentProfile{
+			{Name: "claude", Commands: []string{"claude"}, ApprovalKeys: "1"},
+		}})
+		SetAgentProfiles(profiles)
+		defer SetAgentProfiles(DefaultAgentProfiles())
+
+		names := make([]string, 0, len(profiles))
+		for _, profile := range profiles {
+			names = append(names, profile.Name)
+		}
+		assert.Equal(t, []string{"aider", "gemini", "claude", "codex-auto"}, names)
+		assert.Equal(t, "1", AgentProfileFor("claude").ApprovalKeys)
+
+		codex := AgentProfileFor("codex-auto")
+		assert.Equal(t, "codex --full-auto", codex.LaunchCommand("codex-auto"))
+		assert.Equal(t, "codex --model o3", codex.LaunchCommand("codex --model o3"))
+		assert.Equal(t, "/tmp", codex.Env["CODEX_HOME"])
+	})
+}
+
+func TestParseKeySequence(t *testing.T) {
+	for spec, want := range map[string][]byte{
+		"ctrl-q":     {0x11},
+		"Ctrl-Q":     {0x11},
+		`ctrl-\`:     {0x1c},
+		"ctrl-]":     {0x1d},
+		"ctrl-b d":   {0x02, 'd'},
+		"alt-n":      {0x1b, 'n'},
+		"esc  space": {0x1b, ' '},
+		"é":          []byte("é"),
+	} {
+		seq, err := ParseKeySequence(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, seq, spec)
+	}
+
+	for _, spec := range []string{"", "ctrl-", "ctrl-1", "shift-x", "alt-"} {
+		_, err := ParseKeySequence(spec)
+		assert.Error(t, err, spec)
+	}
+
+	defaults := AttachConfig{NextKey: "alt-n"}.WithDefaults()
+	assert.Equal(t, "ctrl-q", defaults.DetachKey)
+	assert.Equal(t, "alt-n", defaults.NextKey)
+}
+
+func TestLoadRepoConfig(t *testing.T) {
+	repo := t.TempDir()
+	cfg, err := LoadRepoConfig(repo)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Windows, "a repository without a config gets an empty one")
+
+	path := filepath.Join(repo, RepoConfigFileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"windows": [{"name": "shell"}, {"name": "test", "command": "go test ./..."}]}`), 0644))
+	cfg, err = LoadRepoConfig(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []WindowConfig{{Name: "shell"}, {Name: "test", Command: "go test ./..."}}, cfg.Windows)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"windows": [{"name": "shell"}, {"name": "shell"}]}`), 0644))
+	_, err = LoadRepoConfig(repo)
+	assert.ErrorContains(t, err, "duplicate window")
+}
+
+func TestLoadRepoConfigSetup(t *testing.T) {
+	repo := t.TempDir()
+	path := filepath.Join(repo, RepoConfigFileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"copy": [".env"],
+		"symlink": ["node_modules"],
+		"setup": ["npm run generate"],
+		"env": {"PORT": "3001"}
+	}`), 0644))
+	cfg, err := LoadRepoConfig(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".env"}, cfg.Copy)
+	assert.Equal(t, []string{"node_modules"}, cfg.Symlink)
+	assert.Equal(t, []string{"npm run generate"}, cfg.Setup)
+	assert.Equal(t, map[string]string{"PORT": "3001"}, cfg.Env)
+
+	for _, outside := range []string{`{"copy": ["../secrets"]}`, `{"symlink": ["/etc"]}`} {
+		require.NoError(t, os.WriteFile(path, []byte(outside), 0644))
+		_, err = LoadRepoConfig(repo)
+		assert.ErrorContains(t, err, "not a path inside the repository")
+	}
+}
+
+func TestLoadPrompts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	prompts, err := LoadPrompts("")
+	require.NoError(t, err)
+	assert.Empty(t, prompts, "a missing library has no prompts")
+
+	configDir, err := GetConfigDir()
+	require.NoError(t, err)
+	userDir := filepath.Join(configDir, PromptsDirName)
+	repo := t.TempDir()
+	repoDir := filepath.Join(repo, RepoPromptsDir)
+	for path, text := range map[string]string{
+		filepath.Join(userDir, "review.md"):   "Review {{.Branch}}",
+		filepath.Join(userDir, "tests.txt"):   "Write tests",
+		filepath.Join(userDir, ".hidden"):     "ignored",
+		filepath.Join(repoDir, "review.md"):   "Review {{.Branch}} like we do here",
+		filepath.Join(repoDir, "fix-issue"):   "Fix {{.Issue}}",
+		filepath.Join(repoDir, "dir", "x.md"): "ignored",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(text), 0644))
+	}
+
+	prompts, err = LoadPrompts("")
+	require.NoError(t, err)
+	require.Len(t, prompts, 2)
+	assert.Equal(t, "Review {{.Branch}}", prompts[0].Text)
+
+	prompts, err = LoadPrompts(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []Prompt{
+		{Name: "fix-issue", Path: filepath.Join(repoDir, "fix-issue"), Repo: true, Text: "Fix {{.Issue}}"},
+		{Name: "review", Path: filepath.Join(repoDir, "review.md"), Repo: true, Text: "Review {{.Branch}} like we do here"},
+		{Name: "tests", Path: filepath.Join(userDir, "tests.txt"), Text: "Write tests"},
+	}, prompts)
+}
+
+func TestAddPromptHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	state := DefaultState()
+	for _, prompt := range []string{"fix the tests", "add docs", " ", "fix the tests"} {
+		require.NoError(t, state.AddPromptHistory(prompt))
+	}
+	assert.Equal(t, []string{"fix the tests", "add docs"}, state.GetPromptHistory())
+	assert.Equal(t, state.GetPromptHistory(), LoadState().GetPromptHistory(), "the history is saved")
+
+	for i := 0; i < maxPromptHistory+5; i++ {
+		require.NoError(t, state.AddPromptHistory(strings.Repeat("x", i+1)))
+	}
+	assert.Len(t, state.GetPromptHistory(), maxPromptHistory)
+	assert.Equal(t, strings.Repeat("x", maxPromptHistory+5), state.GetPromptHistory()[0])
+}
@@ -0,0 +1,16 @@
+package config
+
+// NotificationConfig configures the notifications sent when instances need attention.
+type NotificationConfig struct {
+	// Events maps the events "ready", "prompt", "error" and "wtask_finished" to the backends which notify about them:
+	// "bell", "osc9" and "osc777" write to the terminal of the TUI, "desktop" runs notify-send or osascript, and
+	// "command" runs Command. Events which aren't listed aren't notified, so without events there are no
+	// notifications.
+	Events map[string][]string `json:"events,omitempty"`
+	// Command is run with the shell by the "command" backend. The event, the title of the instance and the message
+	// are in the environment variables CS_EVENT, CS_INSTANCE and CS_MESSAGE.
+	Command string `json:"command,omitempty"`
+	// RateLimitSeconds is the minimum time between two notifications of the same event for the same instance.
+	// Defaults to 30.
+	RateLimitSeconds int `json:"rate_limit_seconds,omitempty"`
+}
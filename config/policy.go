@@ -0,0 +1,34 @@
+package config
+
+// Approval decisions of an ApprovalPolicy.
+const (
+	// ApprovalAllow approves the request.
+	ApprovalAllow = "allow"
+	// ApprovalDeny rejects the request with the reject keys of the agent profile.
+	ApprovalDeny = "deny"
+	// ApprovalAsk leaves the request for the user to answer.
+	ApprovalAsk = "ask"
+)
+
+// ApprovalPolicy decides which approval prompts are answered automatically in auto-yes mode.
+type ApprovalPolicy struct {
+	// Rules are checked in order and the first matching rule decides.
+	Rules []ApprovalRule `json:"rules"`
+	// Default is the decision if no rule matches. Defaults to ApprovalAsk.
+	Default string `json:"default,omitempty"`
+}
+
+// ApprovalRule matches the requests of an agent. Empty fields match everything.
+type ApprovalRule struct {
+	// Action is ApprovalAllow, ApprovalDeny or ApprovalAsk.
+	Action string `json:"action"`
+	// Tool is a glob matched case-insensitively against the requested tool, like "Bash command" or "Edit*".
+	Tool string `json:"tool,omitempty"`
+	// Command is a glob matched against the requested command or file, like "go test *". Unlike file globs,
+	// * also matches slashes.
+	Command string `json:"command,omitempty"`
+	// Regex is a regular expression matched against the requested command or file.
+	Regex string `json:"regex,omitempty"`
+	// Repos are globs matched against the path or name of the repository. Empty matches all repositories.
+	Repos []string `json:"repos,omitempty"`
+}
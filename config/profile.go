@@ -0,0 +1,222 @@
+package config
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProfilesDirName is the directory in the config directory which holds one agent profile per JSON file.
+const ProfilesDirName = "profiles"
+
+// AgentProfile describes how to launch an agent CLI and how to read its screen. All patterns are regular
+// expressions matched against the captured tmux pane.
+type AgentProfile struct {
+	// Name identifies the profile. Profiles with the same name replace each other.
+	Name string `json:"name"`
+	// Commands are the executable names which select this profile. They are matched against the base name of
+	// the first word of the program, so "/usr/local/bin/aider --model x" selects the profile with "aider".
+	Commands []string `json:"commands,omitempty"`
+	// Command is the command to launch when the program is the name of the profile. This allows profiles like
+	// "codex-auto" which start "codex --full-auto".
+	Command string `json:"command,omitempty"`
+	// Env holds environment variables which are set for the agent.
+	Env map[string]string `json:"env,omitempty"`
+
+	// TrustPattern matches the screen the agent shows on start up asking whether to trust the folder.
+	TrustPattern string `json:"trust_pattern,omitempty"`
+	// TrustKeys are sent to the agent when the trust screen is shown. Defaults to enter.
+	TrustKeys string `json:"trust_keys,omitempty"`
+	// TrustTimeout is how many seconds to wait for the trust screen. Defaults to 30.
+	TrustTimeout int `json:"trust_timeout,omitempty"`
+
+	// ApprovalPatterns match prompts asking the user to approve an action.
+	ApprovalPatterns []string `json:"approval_patterns,omitempty"`
+	// ApprovalKeys are sent to the agent to approve an action in auto-yes mode. Defaults to enter.
+	ApprovalKeys string `json:"approval_keys,omitempty"`
+	// RejectKeys are sent to the agent to reject an action denied by the approval policy. Defaults to escape.
+	RejectKeys string `json:"reject_keys,omitempty"`
+	// RequestPattern extracts what the agent asks to do from an approval prompt. It should have the named
+	// groups "tool" and "command", which may span several lines. The last match on the screen is used. Without
+	// it, the approval policy leaves every request of the agent to the user.
+	RequestPattern string `json:"request_pattern,omitempty"`
+	// BusyPatterns match output that is only shown while the agent is working, even if the pane doesn't change.
+	BusyPatterns []string `json:"busy_patterns,omitempty"`
+	// IdlePatterns match output that is only shown while the agent waits for input, even if the pane changes.
+	IdlePatterns []string `json:"idle_patterns,omitempty"`
+	// ExitPatterns match output shown after the agent exited, like the shell prompt of a wrapper script.
+	ExitPatterns []string `json:"exit_patterns,omitempty"`
+}
+
+// DefaultAgentProfiles returns the built-in profiles for the agents claude squad supports out of the box.
+func DefaultAgentProfiles() []AgentProfile {
+	return []AgentProfile{
+		{
+			Name:             "claude",
+			Commands:         []string{"claude"},
+			TrustPattern:     regexp.QuoteMeta("Do you trust the files in this folder?"),
+			TrustKeys:        "\r",
+			TrustTimeout:     30,
+			ApprovalPatterns: []string{regexp.QuoteMeta("No, and tell Claude what to do differently")},
+			ApprovalKeys:     "\r",
+			RejectKeys:       "\x1b",
+			// The tool is the title of the permission box, followed by the command or file, e.g.
+			// "Bash command", an empty line and "go test ./...". The command takes every line up to the next empty
+			// line, except for a last line which reads like a description, e.g. "Run all tests".
+			RequestPattern: `(?m)^\s*(?P<tool>Bash command|Edit file|Create file|Write file|Update file|Read file|Fetch|` +
+				`Web Search|[A-Z][\w-]*(?:\s\w+)?)\s*\n(?:\s*\n)*(?P<command>[ \t]*\S.*(?:\n[ \t]*\S.*)*?)` +
+				`(?:\n[ \t]*(?P<description>[A-Z][^;&|$<>` + "`" + `\n]*))?\n(?:[ \t]*\n)+(?:.*\n)*?.*Do you want`,
+		},
+		{
+			Name:             "aider",
+			Commands:         []string{"aider"},
+			TrustPattern:     regexp.QuoteMeta("Open documentation url for more info"),
+			TrustKeys:        "D\r",
+			TrustTimeout:     45,
+			ApprovalPatterns: []string{regexp.QuoteMeta("(Y)es/(N)o/(D)on't ask again")},
+			ApprovalKeys:     "\r",
+			RejectKeys:       "n\r",
+			// Aider prints the command or file name on the line before the question, e.g. "Run shell command?".
+			RequestPattern: `(?m)^\s*(?P<command>\S.*?)\s*\n(?P<tool>[^\n?]+)\? \(Y\)es/\(N\)o`,
+		},
+		{
+			Name:             "gemini",
+			Commands:         []string{"gemini"},
+			TrustPattern:     regexp.QuoteMeta("Open documentation url for more info"),
+			TrustKeys:        "D\r",
+			TrustTimeout:     45,
+			ApprovalPatterns: []string{regexp.QuoteMeta("Yes, allow once")},
+			ApprovalKeys:     "\r",
+			RejectKeys:       "\x1b",
+		},
+	}
+}
+
+// Validate returns an error if the profile has no name or one of its patterns is not a valid regular expression.
+func (p *AgentProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("agent profile has no name")
+	}
+	patterns := append([]string{p.TrustPattern, p.RequestPattern}, p.ApprovalPatterns...)
+	patterns = append(patterns, p.BusyPatterns...)
+	patterns = append(patterns, p.IdlePatterns...)
+	patterns = append(patterns, p.ExitPatterns...)
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("agent profile %s has an invalid pattern: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// LaunchCommand returns the command to run for the given program. This is the command of the profile if the
+// program is its name, and the program itself otherwise.
+func (p *AgentProfile) LaunchCommand(program string) string {
+	if p.Command != "" && strings.TrimSpace(program) == p.Name {
+		return p.Command
+	}
+	return program
+}
+
+// matches returns true if the profile should be used for the given program.
+func (p *AgentProfile) matches(program string) bool {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return false
+	}
+	if fields[0] == p.Name && len(fields) == 1 {
+		return true
+	}
+	executable := filepath.Base(fields[0])
+	for _, command := range p.Commands {
+		if executable == command {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	agentProfilesMu sync.RWMutex
+	agentProfiles   = DefaultAgentProfiles()
+)
+
+// SetAgentProfiles replaces the profiles used by AgentProfileFor. Until this is called, only the built-in
+// profiles are used.
+func SetAgentProfiles(profiles []AgentProfile) {
+	agentProfilesMu.Lock()
+	defer agentProfilesMu.Unlock()
+	agentProfiles = profiles
+}
+
+// AgentProfileFor returns the profile for the given program. Programs without a profile get an empty one
+// named after the program, which launches it as is and never detects prompts.
+func AgentProfileFor(program string) AgentProfile {
+	agentProfilesMu.RLock()
+	defer agentProfilesMu.RUnlock()
+	// Later profiles take precedence, since user profiles are loaded after the built-in ones.
+	for i := len(agentProfiles) - 1; i >= 0; i-- {
+		if agentProfiles[i].matches(program) {
+			return agentProfiles[i]
+		}
+	}
+	return AgentProfile{Name: program}
+}
+
+// LoadAgentProfiles returns the built-in profiles, followed by the profiles in the config and in the profiles
+// directory. A profile replaces any earlier profile with the same name. Invalid profiles are logged and skipped.
+func LoadAgentProfiles(cfg *Config) []AgentProfile {
+	profiles := DefaultAgentProfiles()
+	add := func(profile AgentProfile, source string) {
+		if err := profile.Validate(); err != nil {
+			log.WarningLog.Printf("skipping agent profile from %s: %v", source, err)
+			return
+		}
+		for i := range profiles {
+			if profiles[i].Name == profile.Name {
+				profiles = append(profiles[:i], profiles[i+1:]...)
+				break
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+
+	for _, profile := range cfg.Profiles {
+		add(profile, ConfigFileName)
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		log.ErrorLog.Printf("failed to get config directory: %v", err)
+		return profiles
+	}
+	paths, err := filepath.Glob(filepath.Join(configDir, ProfilesDirName, "*.json"))
+	if err != nil {
+		log.WarningLog.Printf("failed to list agent profiles: %v", err)
+		return profiles
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.WarningLog.Printf("failed to read agent profile %s: %v", path, err)
+			continue
+		}
+		var profile AgentProfile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			log.WarningLog.Printf("failed to parse agent profile %s: %v", path, err)
+			continue
+		}
+		if profile.Name == "" {
+			profile.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		add(profile, path)
+	}
+	return profiles
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PromptsDirName is the directory which holds the prompt library, one prompt per file. The prompts of the user
+// are in the config directory, and those shared by everyone working on a repository in RepoPromptsDir.
+const PromptsDirName = "prompts"
+
+// RepoPromptsDir is the directory in the root of a repository which holds the prompts of the repository.
+var RepoPromptsDir = filepath.Join(".claude-squad", PromptsDirName)
+
+// Prompt is a prompt of the library. Its text is a Go template which can refer to variables like {{.Branch}}.
+type Prompt struct {
+	// Name is the name of the file without its extension.
+	Name string
+	// Path is the file the prompt was loaded from.
+	Path string
+	// Repo is true if the prompt belongs to the repository rather than to the user.
+	Repo bool
+	Text string
+}
+
+// LoadPrompts loads the prompts of the user and of the repository at repoPath, sorted by name. A prompt of the
+// repository replaces a prompt of the user with the same name. repoPath may be empty to only load the prompts of
+// the user.
+func LoadPrompts(repoPath string) ([]Prompt, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Prompt)
+	if err := loadPromptsDir(filepath.Join(configDir, PromptsDirName), false, byName); err != nil {
+		return nil, err
+	}
+	if repoPath != "" {
+		if err := loadPromptsDir(filepath.Join(repoPath, RepoPromptsDir), true, byName); err != nil {
+			return nil, err
+		}
+	}
+
+	prompts := make([]Prompt, 0, len(byName))
+	for _, prompt := range byName {
+		prompts = append(prompts, prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool {
+		return prompts[i].Name < prompts[j].Name
+	})
+	return prompts, nil
+}
+
+// loadPromptsDir adds the prompts in dir to byName. Hidden files and directories are skipped, and a missing
+// directory has no prompts.
+func loadPromptsDir(dir string, repo bool, byName map[string]Prompt) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to list prompts: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt: %w", err)
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		byName[name] = Prompt{Name: name, Path: path, Repo: repo, Text: string(data)}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package config
+
+// ReaperConfig configures how idle instances are paused and merged ones are killed. Zero disables a policy.
+type ReaperConfig struct {
+	// PauseIdleHours pauses instances whose pane and diff didn't change for this many hours. Pausing commits the
+	// changes to the branch and removes the worktree.
+	PauseIdleHours int `json:"pause_idle_hours,omitempty"`
+	// KillMergedDays kills instances paused for this many days whose branch was merged. Killing deletes the
+	// branch.
+	KillMergedDays int `json:"kill_merged_days,omitempty"`
+}
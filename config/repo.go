@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RepoConfigFileName is the name of the config file in the root of a repository, which configures the instances
+// created for the repository.
+const RepoConfigFileName = ".claude-squad.json"
+
+// RepoConfig configures the instances of a repository.
+type RepoConfig struct {
+	// Copy are paths relative to the repository root which are copied from the main checkout into new worktrees,
+	// like .env files. Directories are copied with everything in them.
+	Copy []string `json:"copy,omitempty"`
+	// Symlink are paths relative to the repository root which are linked from new worktrees to the main checkout,
+	// like node_modules.
+	Symlink []string `json:"symlink,omitempty"`
+	// Setup are shell commands run in new worktrees before the agent starts, like "npm ci".
+	Setup []string `json:"setup,omitempty"`
+	// Env are environment variables of the setup commands and the tmux session.
+	Env map[string]string `json:"env,omitempty"`
+	// Windows are extra tmux windows started in the worktree of every instance, next to the agent, like a shell,
+	// a test watcher or a dev server.
+	Windows []WindowConfig `json:"windows,omitempty"`
+	// Verify is a shell command which checks the work of an instance in its worktree, like "go test ./...". The
+	// compare view runs it for the sessions of a best-of-N.
+	Verify string `json:"verify,omitempty"`
+}
+
+// WindowConfig is an extra window of an instance.
+type WindowConfig struct {
+	// Name is shown in the preview and the tmux status line.
+	Name string `json:"name"`
+	// Command is typed into the shell of the window when it's started. Without a command, the window is a shell.
+	Command string `json:"command,omitempty"`
+}
+
+// LoadRepoConfig loads the config of the repository at repoPath. A repository without a config gets an empty one.
+func LoadRepoConfig(repoPath string) (*RepoConfig, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, RepoConfigFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RepoConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+
+	var cfg RepoConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RepoConfigFileName, err)
+	}
+	for _, path := range append(append([]string(nil), cfg.Copy...), cfg.Symlink...) {
+		if !filepath.IsLocal(path) {
+			return nil, fmt.Errorf("invalid %s: %q is not a path inside the repository", RepoConfigFileName, path)
+		}
+	}
+	names := make(map[string]bool)
+	for _, window := range cfg.Windows {
+		if window.Name == "" {
+			return nil, fmt.Errorf("invalid %s: every window needs a name", RepoConfigFileName)
+		}
+		if names[window.Name] {
+			return nil, fmt.Errorf("invalid %s: duplicate window %q", RepoConfigFileName, window.Name)
+		}
+		names[window.Name] = true
+	}
+	return &cfg, nil
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
 	StateFileName     = "state.json"
 	InstancesFileName = "instances.json"
+	// maxPromptHistory limits how many prompts the history keeps.
+	maxPromptHistory = 50
 )
 
 // InstanceStorage handles instance-related operations
@@ -29,6 +32,10 @@ type AppState interface {
 	GetHelpScreensSeen() uint32
 	// SetHelpScreensSeen updates the bitmask of seen help screens
 	SetHelpScreensSeen(seen uint32) error
+	// GetPromptHistory returns the prompts sent to instances, the most recent first
+	GetPromptHistory() []string
+	// AddPromptHistory adds a prompt to the front of the history
+	AddPromptHistory(prompt string) error
 }
 
 // StateManager combines instance storage and app state management
@@ -41,6 +48,8 @@ type StateManager interface {
 type State struct {
 	// HelpScreensSeen is a bitmask tracking which help screens have been shown
 	HelpScreensSeen uint32 `json:"help_screens_seen"`
+	// PromptHistory holds the prompts sent to instances, the most recent first
+	PromptHistory []string `json:"prompt_history,omitempty"`
 	// Instances stores the serialized instance data as raw JSON
 	InstancesData json.RawMessage `json:"instances"`
 }
@@ -137,3 +146,24 @@ func (s *State) SetHelpScreensSeen(seen uint32) error {
 	s.HelpScreensSeen = seen
 	return SaveState(s)
 }
+
+// GetPromptHistory returns the prompts sent to instances, the most recent first
+func (s *State) GetPromptHistory() []string {
+	return s.PromptHistory
+}
+
+// AddPromptHistory adds a prompt to the front of the history. A prompt which is already in the history moves to
+// the front, and the oldest prompts are dropped once the history is full.
+func (s *State) AddPromptHistory(prompt string) error {
+	if strings.TrimSpace(prompt) == "" {
+		return nil
+	}
+	history := []string{prompt}
+	for _, previous := range s.PromptHistory {
+		if previous != prompt && len(history) < maxPromptHistory {
+			history = append(history, previous)
+		}
+	}
+	s.PromptHistory = history
+	return SaveState(s)
+}
@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Commands which clients send to the daemon over its socket. Every request is one line of JSON, answered with one
+// line of JSON.
+const (
+	// commandStatus returns the Status of the daemon.
+	commandStatus = "status"
+	// commandReload loads the instances from storage again.
+	commandReload = "reload"
+	// commandShutdown stops the daemon.
+	commandShutdown = "shutdown"
+	// commandAttach keeps the connection open while a TUI manages the instances itself. The daemon doesn't answer
+	// prompts or restart agents meanwhile, and reloads the instances once the TUI is gone.
+	commandAttach = "attach"
+)
+
+// dialTimeout limits how long clients wait for the daemon.
+const dialTimeout = 2 * time.Second
+
+// ErrNotRunning is returned by clients when no daemon is listening on the socket.
+var ErrNotRunning = errors.New("daemon is not running")
+
+type request struct {
+	Command string `json:"command"`
+}
+
+type response struct {
+	Error  string  `json:"error,omitempty"`
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status describes what the daemon is doing.
+type Status struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	// Attached is the number of TUIs connected to the daemon. While one is attached, the daemon leaves the
+	// instances to it.
+	Attached int `json:"attached"`
+	// AutoYes is true if the daemon answers the prompts of all instances, not only of those created in auto-yes
+	// mode.
+	AutoYes bool `json:"auto_yes"`
+	// Approvals is the number of prompts answered since the daemon started.
+	Approvals int              `json:"approvals"`
+	Instances []InstanceStatus `json:"instances"`
+	// Errors are the last errors of the daemon, oldest first.
+	Errors []ErrorStatus `json:"errors,omitempty"`
+}
+
+// ErrorStatus is an error of the daemon.
+type ErrorStatus struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// InstanceStatus describes an instance watched by the daemon.
+type InstanceStatus struct {
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Restarts int    `json:"restarts"`
+}
+
+// Client is a connection to the daemon which stays open, like the one of a TUI.
+type Client struct {
+	conn net.Conn
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// call sends a request to the daemon on the socket and returns the response.
+func call(socket, command string) (*response, error) {
+	conn, resp, err := open(socket, command)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.Close()
+	return resp, nil
+}
+
+// open sends a request to the daemon on the socket and returns the response, keeping the connection open.
+func open(socket, command string) (net.Conn, *response, error) {
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return nil, nil, ErrNotRunning
+	}
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	if err := json.NewEncoder(conn).Encode(request{Command: command}); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("failed to send %s to daemon: %w", command, err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("failed to read daemon response: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("invalid daemon response: %w", err)
+	}
+	if resp.Error != "" {
+		_ = conn.Close()
+		return nil, nil, errors.New(resp.Error)
+	}
+	return conn, &resp, nil
+}
+
+func status(socket string) (*Status, error) {
+	resp, err := call(socket, commandStatus)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("daemon sent no status")
+	}
+	return resp.Status, nil
+}
+
+// GetStatus returns the status of the running daemon, or ErrNotRunning.
+func GetStatus() (*Status, error) {
+	p, err := defaultPaths()
+	if err != nil {
+		return nil, err
+	}
+	return status(p.socket)
+}
+
+// Reload makes the running daemon load the instances from storage again. It's a no-op if no daemon is running.
+func Reload() error {
+	p, err := defaultPaths()
+	if err != nil {
+		return err
+	}
+	if _, err := call(p.socket, commandReload); err != nil && !errors.Is(err, ErrNotRunning) {
+		return err
+	}
+	return nil
+}
+
+// Attach connects a TUI to the running daemon, which leaves the instances to the TUI until the client is closed.
+// Returns ErrNotRunning if no daemon is running.
+func Attach() (*Client, error) {
+	p, err := defaultPaths()
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := open(p.socket, commandAttach)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
@@ -4,98 +4,102 @@ import (
 	"claude-squad/config"
 	"claude-squad/log"
 	"claude-squad/session"
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
-	"path/filepath"
-	"sync"
 	"syscall"
 	"time"
 )
 
-// RunDaemon runs the daemon process which iterates over all sessions and runs AutoYes mode on them.
-// It's expected that the main process kills the daemon when the main process starts.
+// StopTimeout is how long the daemon gets to shut down before it's killed.
+const StopTimeout = 5 * time.Second
+
+// RunDaemon runs the daemon process, which supervises the stored instances until it receives SIGINT or SIGTERM, or a
+// client asks it to shut down. Clients find it through the PID file and talk to it over a unix socket in the config
+// directory. The daemon never writes the instances to storage, that's left to the TUI.
+//
+// The daemon only supervises the instances while no TUI is open. It isn't a server which the TUI and the other
+// commands go through: a TUI attaches to take the instances over, manages them itself until it exits, and the daemon
+// takes over the stored instances again afterwards. Commands which change the stored instances ask it to reload.
 func RunDaemon(cfg *config.Config) error {
-	log.InfoLog.Printf("starting daemon")
+	p, err := defaultPaths()
+	if err != nil {
+		return err
+	}
 	state := config.LoadState()
 	storage, err := session.NewStorage(state)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	instances, err := storage.LoadInstances()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	pollInterval := time.Duration(cfg.DaemonPollInterval) * time.Millisecond
+	return run(ctx, p, newSupervisor(storage.LoadInstances, cfg.AutoYes, pollInterval))
+}
+
+// run supervises the instances until the context is done or a client asks the daemon to shut down.
+func run(ctx context.Context, p paths, s *supervisor) error {
+	log.InfoLog.Printf("starting daemon")
+	if err := removeStale(p); err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", p.socket)
 	if err != nil {
-		return fmt.Errorf("failed to load instacnes: %w", err)
+		return fmt.Errorf("failed to listen on %s: %w", p.socket, err)
 	}
-	for _, instance := range instances {
-		// Assume AutoYes is true if the daemon is running.
-		instance.AutoYes = true
+	if err := writePID(p.pidFile); err != nil {
+		_ = listener.Close()
+		return err
 	}
-
-	pollInterval := time.Duration(cfg.DaemonPollInterval) * time.Millisecond
-
-	// If we get an error for a session, it's likely that we'll keep getting the error. Log every 30 seconds.
-	everyN := log.NewEvery(60 * time.Second)
-
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	stopCh := make(chan struct{})
-	go func() {
-		defer wg.Done()
-		ticker := time.NewTimer(pollInterval)
-		for {
-			for _, instance := range instances {
-				// We only store started instances, but check anyway.
-				if instance.Started() && !instance.Paused() {
-					if _, hasPrompt := instance.HasUpdated(); hasPrompt {
-						instance.TapEnter()
-						if err := instance.UpdateDiffStats(); err != nil {
-							if everyN.ShouldLog() {
-								log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
-							}
-						}
-					}
-				}
-			}
-
-			// Handle stop before ticker.
-			select {
-			case <-stopCh:
-				return
-			default:
-			}
-
-			<-ticker.C
-			ticker.Reset(pollInterval)
-		}
+	defer func() {
+		_ = os.Remove(p.pidFile)
+		_ = os.Remove(p.socket)
 	}()
 
-	// Notify on SIGINT (Ctrl+C) and SIGTERM. Save instances before
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-sigChan
-	log.InfoLog.Printf("received signal %s", sig.String())
+	if err := s.reload(); err != nil {
+		_ = listener.Close()
+		return err
+	}
 
-	// Stop the goroutine so we don't race.
-	close(stopCh)
-	wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.serve(listener, cancel)
 
-	if err := storage.SaveInstances(instances); err != nil {
-		log.ErrorLog.Printf("failed to save instances when terminating daemon: %v", err)
-	}
+	s.run(ctx)
+	log.InfoLog.Printf("daemon shutting down")
+	_ = listener.Close()
+	s.mu.Lock()
+	s.release()
+	s.mu.Unlock()
 	return nil
 }
 
-// LaunchDaemon launches the daemon process.
-func LaunchDaemon() error {
+// LaunchDaemon launches the daemon process unless one is running already. With autoYes, the daemon answers the
+// prompts of all instances.
+func LaunchDaemon(autoYes bool) error {
+	p, err := defaultPaths()
+	if err != nil {
+		return err
+	}
+	if _, err := status(p.socket); err == nil {
+		return nil
+	}
+
 	// Find the claude squad binary.
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	cmd := exec.Command(execPath, "--daemon")
+	args := []string{"--daemon"}
+	if autoYes {
+		args = append(args, "--autoyes")
+	}
+	cmd := exec.Command(execPath, args...)
 
 	// Detach the process from the parent
 	cmd.Stdin = nil
@@ -111,57 +115,59 @@ func LaunchDaemon() error {
 
 	log.InfoLog.Printf("started daemon child process with PID: %d", cmd.Process.Pid)
 
-	// Save PID to a file for later management
-	pidDir, err := config.GetConfigDir()
-	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
-	}
-
-	pidFile := filepath.Join(pidDir, "daemon.pid")
-	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", cmd.Process.Pid)), 0644); err != nil {
-		return fmt.Errorf("failed to write PID file: %w", err)
-	}
-
-	// Don't wait for the child to exit, it's detached
-	return nil
+	// Don't wait for the child to exit, it's detached. It writes the PID file itself.
+	return cmd.Process.Release()
 }
 
-// StopDaemon attempts to stop a running daemon process if it exists. Returns no error if the daemon is not found
-// (assumes the daemon does not exist).
-func StopDaemon() error {
-	pidDir, err := config.GetConfigDir()
+// StopDaemon stops a running daemon gracefully, and kills it if it doesn't stop within the timeout. Returns no error
+// if the daemon is not found (assumes the daemon does not exist), and removes the files of a daemon which is gone.
+// A stale PID file pointing at another process doesn't get that process killed.
+func StopDaemon(timeout time.Duration) error {
+	p, err := defaultPaths()
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return err
 	}
+	return stop(p, timeout, terminate)
+}
 
-	pidFile := filepath.Join(pidDir, "daemon.pid")
-	data, err := os.ReadFile(pidFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("failed to read PID file: %w", err)
+func stop(p paths, timeout time.Duration, terminate func(p paths, pid int) error) error {
+	inspection := inspect(p)
+	if inspection.State != Running && inspection.State != Unresponsive {
+		return removeFiles(p)
 	}
+	pid := inspection.PID
 
-	var pid int
-	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil {
-		return fmt.Errorf("invalid PID file format: %w", err)
+	if err := terminate(p, pid); err != nil {
+		log.WarningLog.Printf("could not ask daemon (PID: %d) to stop: %v", pid, err)
+	} else if waitForExit(p, pid, timeout) {
+		log.InfoLog.Printf("daemon process (PID: %d) stopped successfully", pid)
+		return nil
 	}
 
 	proc, err := os.FindProcess(pid)
 	if err != nil {
 		return fmt.Errorf("failed to find daemon process: %w", err)
 	}
-
 	if err := proc.Kill(); err != nil {
-		return fmt.Errorf("failed to stop daemon process: %w", err)
+		return fmt.Errorf("failed to kill daemon process: %w", err)
 	}
+	log.WarningLog.Printf("killed daemon process (PID: %d) which did not stop within %s", pid, timeout)
+	return removeFiles(p)
+}
 
-	// Clean up PID file
-	if err := os.Remove(pidFile); err != nil {
-		return fmt.Errorf("failed to remove PID file: %w", err)
+// waitForExit waits until the daemon is gone. It removes its PID file when it's done.
+func waitForExit(p paths, pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for processAlive(pid) && fileExists(p.pidFile) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
 	}
+	return true
+}
 
-	log.InfoLog.Printf("daemon process (PID: %d) stopped successfully", pid)
-	return nil
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
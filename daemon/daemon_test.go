@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+
+	os.Exit(m.Run())
+}
+
+func testPaths(t *testing.T) paths {
+	dir := t.TempDir()
+	return paths{pidFile: filepath.Join(dir, pidFileName), socket: filepath.Join(dir, socketFileName)}
+}
+
+// shutdown asks the daemon to stop over the socket, since the daemon in the tests is the test process itself.
+func shutdown(p paths, _ int) error {
+	_, err := call(p.socket, commandShutdown)
+	return err
+}
+
+// deadPID returns the PID of a process which exited.
+func deadPID(t *testing.T) int {
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func TestDaemonServesClients(t *testing.T) {
+	p := testPaths(t)
+	var loads atomic.Int32
+	s := newSupervisor(func() ([]*session.Instance, error) {
+		loads.Add(1)
+		return nil, nil
+	}, false, 10*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- run(context.Background(), p, s) }()
+	require.Eventually(t, func() bool {
+		_, err := status(p.socket)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pid, err := readPID(p.pidFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.ErrorContains(t, removeStale(p), "already running", "a second daemon doesn't start")
+
+	// An attached TUI makes the daemon leave the instances alone, which are loaded again once the TUI is gone.
+	conn, _, err := open(p.socket, commandAttach)
+	require.NoError(t, err)
+	st, err := status(p.socket)
+	require.NoError(t, err)
+	assert.Equal(t, 1, st.Attached)
+	require.Equal(t, int32(1), loads.Load())
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool { return loads.Load() == 2 }, 2*time.Second, 10*time.Millisecond)
+
+	_, err = call(p.socket, "nonsense")
+	assert.ErrorContains(t, err, "unknown command")
+
+	inspection := inspect(p)
+	assert.Equal(t, Running, inspection.State)
+	assert.Equal(t, os.Getpid(), inspection.Status.PID)
+
+	require.NoError(t, stop(p, 2*time.Second, shutdown))
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon didn't shut down")
+	}
+	assert.NoFileExists(t, p.pidFile)
+	assert.NoFileExists(t, p.socket)
+
+	_, err = status(p.socket)
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestStaleDaemonFilesAreRemoved(t *testing.T) {
+	p := testPaths(t)
+	assert.Equal(t, NotRunning, inspect(p).State)
+
+	pid := deadPID(t)
+	require.NoError(t, os.WriteFile(p.pidFile, []byte(strconv.Itoa(pid)), 0644))
+	require.NoError(t, os.WriteFile(p.socket, nil, 0644))
+	assert.Equal(t, &Inspection{PID: pid, State: StaleDead}, inspect(p))
+
+	require.NoError(t, stop(p, time.Second, shutdown), "stopping a daemon which is gone succeeds")
+	assert.NoFileExists(t, p.pidFile)
+	assert.NoFileExists(t, p.socket)
+
+	// A process which got the PID of the daemon isn't touched.
+	other := exec.Command("sleep", "10")
+	require.NoError(t, other.Start())
+	defer func() {
+		_ = other.Process.Kill()
+		_ = other.Wait()
+	}()
+	require.NoError(t, os.WriteFile(p.pidFile, []byte(strconv.Itoa(other.Process.Pid)), 0644))
+	assert.Equal(t, StaleUnrelated, inspect(p).State)
+	terminated := false
+	require.NoError(t, stop(p, time.Second, func(paths, int) error {
+		terminated = true
+		return nil
+	}))
+	assert.False(t, terminated)
+	assert.True(t, processAlive(other.Process.Pid))
+	assert.NoFileExists(t, p.pidFile)
+}
+
+func TestLogFilterKeepsDaemonLines(t *testing.T) {
+	var filter logFilter
+	var kept []string
+	for _, line := range []string{
+		"INFO:2026/10/19 08:00:00 app.go:1: started",
+		"[DAEMON] INFO:2026/10/19 08:00:01 daemon.go:1: starting daemon",
+		"[DAEMON] ERROR:2026/10/19 08:00:02 supervisor.go:1: setup command failed",
+		"npm ERR! missing script",
+		"WARNING:2026/10/19 08:00:03 app.go:1: something",
+		"continued",
+	} {
+		if filter.keep(line) {
+			kept = append(kept, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"[DAEMON] INFO:2026/10/19 08:00:01 daemon.go:1: starting daemon",
+		"[DAEMON] ERROR:2026/10/19 08:00:02 supervisor.go:1: setup command failed",
+		"npm ERR! missing script",
+	}, kept)
+}
@@ -3,6 +3,9 @@
 package daemon
 
 import (
+	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
 )
 
@@ -12,3 +15,25 @@ func getSysProcAttr() *syscall.SysProcAttr {
 		Setsid: true, // Create a new session
 	}
 }
+
+// processAlive returns true if a process with the PID exists.
+func processAlive(pid int) bool {
+	// Signal 0 only checks whether the process exists. EPERM means it exists, but belongs to another user.
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// isDaemonProcess returns true if the process with the PID was started as a daemon, as opposed to another process
+// which got the PID of a daemon which is gone.
+func isDaemonProcess(pid int) bool {
+	output, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "--daemon")
+}
+
+// terminate asks the daemon to shut down with SIGTERM.
+func terminate(_ paths, pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
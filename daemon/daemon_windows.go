@@ -4,6 +4,8 @@ package daemon
 
 import (
 	"golang.org/x/sys/windows"
+	"os"
+	"strings"
 	"syscall"
 )
 
@@ -13,3 +15,44 @@ func getSysProcAttr() *syscall.SysProcAttr {
 		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
 	}
 }
+
+// processAlive returns true if a process with the PID exists.
+func processAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	// STILL_ACTIVE
+	return code == 259
+}
+
+// isDaemonProcess returns true if the process with the PID is a claude squad executable, as opposed to another
+// process which got the PID of a daemon which is gone. The arguments of other processes can't be read on Windows.
+func isDaemonProcess(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+	buf := make([]uint16, windows.MAX_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(handle, 0, &buf[0], &size); err != nil {
+		return false
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(windows.UTF16ToString(buf[:size]), self)
+}
+
+// terminate asks the daemon to shut down over its socket, since Windows has no SIGTERM.
+func terminate(p paths, _ int) error {
+	_, err := call(p.socket, commandShutdown)
+	return err
+}
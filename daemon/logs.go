@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"bufio"
+	"claude-squad/log"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// daemonLogPrefix starts the lines which the daemon writes to the log file shared with the other processes.
+const daemonLogPrefix = "[DAEMON]"
+
+// logFilter picks the lines of the daemon from the log file. Lines without a prefix continue the previous message.
+type logFilter struct {
+	inDaemon bool
+}
+
+func (f *logFilter) keep(line string) bool {
+	switch {
+	case strings.HasPrefix(line, daemonLogPrefix):
+		f.inDaemon = true
+	case strings.HasPrefix(line, "INFO:"), strings.HasPrefix(line, "WARNING:"), strings.HasPrefix(line, "ERROR:"):
+		f.inDaemon = false
+	}
+	return f.inDaemon
+}
+
+// Logs writes the last n lines of the daemon from the log file to w. With follow, it keeps writing new lines until
+// the context is done.
+func Logs(ctx context.Context, w io.Writer, n int, follow bool) error {
+	f, err := os.Open(log.FileName())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no log file at %s", log.FileName())
+		}
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var filter logFilter
+	reader := bufio.NewReader(f)
+	var last []string
+	var partial string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			partial = line
+			break
+		}
+		if filter.keep(strings.TrimSuffix(line, "\n")) {
+			last = append(last, line)
+			if n > 0 && len(last) > n {
+				last = last[1:]
+			}
+		}
+	}
+	for _, line := range last {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	if !follow {
+		return nil
+	}
+
+	// The file is appended to by other processes, so reading on after EOF returns their new lines.
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				partial += line
+				break
+			}
+			line, partial = partial+line, ""
+			if filter.keep(strings.TrimSuffix(line, "\n")) {
+				if _, err := io.WriteString(w, line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
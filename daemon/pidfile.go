@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	pidFileName    = "daemon.pid"
+	socketFileName = "daemon.sock"
+)
+
+// paths are the files through which clients find the daemon.
+type paths struct {
+	pidFile string
+	socket  string
+}
+
+// defaultPaths returns the files of the daemon in the config directory.
+func defaultPaths() (paths, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return paths{}, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return paths{
+		pidFile: filepath.Join(dir, pidFileName),
+		socket:  filepath.Join(dir, socketFileName),
+	}, nil
+}
+
+// readPID returns the PID in the PID file, or 0 if there is no PID file.
+func readPID(pidFile string) (int, error) {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read PID file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid PID file format: %w", err)
+	}
+	return pid, nil
+}
+
+// writePID writes the PID of this process to the PID file.
+func writePID(pidFile string) error {
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
+
+// State is what the PID file of the daemon points at.
+type State int
+
+const (
+	// NotRunning means there is no PID file.
+	NotRunning State = iota
+	// Running means the daemon answers on its socket.
+	Running
+	// Unresponsive means the process is a daemon, but it doesn't answer on the socket.
+	Unresponsive
+	// StaleDead means the process of the PID file is gone.
+	StaleDead
+	// StaleUnrelated means the PID was reused by a process which isn't a daemon.
+	StaleUnrelated
+)
+
+func (s State) String() string {
+	switch s {
+	case NotRunning:
+		return "not running"
+	case Running:
+		return "running"
+	case Unresponsive:
+		return "not responding"
+	case StaleDead:
+		return "stale PID file, the process is gone"
+	case StaleUnrelated:
+		return "stale PID file, the PID belongs to another process"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Inspection is what Inspect found out about the daemon.
+type Inspection struct {
+	// PID is the PID of the daemon, or the one in a stale PID file.
+	PID   int
+	State State
+	// Status is set if the daemon is Running.
+	Status *Status
+}
+
+// Inspect checks whether the daemon runs, and whether its PID file is stale.
+func Inspect() (*Inspection, error) {
+	p, err := defaultPaths()
+	if err != nil {
+		return nil, err
+	}
+	return inspect(p), nil
+}
+
+func inspect(p paths) *Inspection {
+	// A PID file we can't read doesn't point at a daemon, but the socket may still answer.
+	pid, _ := readPID(p.pidFile)
+	if st, err := status(p.socket); err == nil {
+		return &Inspection{PID: st.PID, State: Running, Status: st}
+	}
+	switch {
+	case pid == 0:
+		return &Inspection{State: NotRunning}
+	case !processAlive(pid):
+		return &Inspection{PID: pid, State: StaleDead}
+	case isDaemonProcess(pid):
+		return &Inspection{PID: pid, State: Unresponsive}
+	default:
+		return &Inspection{PID: pid, State: StaleUnrelated}
+	}
+}
+
+// removeFiles removes the PID file and the socket.
+func removeFiles(p paths) error {
+	if err := os.Remove(p.pidFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale PID file: %w", err)
+	}
+	if err := os.Remove(p.socket); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
+
+// removeStale removes the PID file and the socket of a daemon which is gone. Returns an error if a daemon is
+// still running.
+func removeStale(p paths) error {
+	inspection := inspect(p)
+	switch inspection.State {
+	case Running:
+		return fmt.Errorf("daemon is already running with PID %d", inspection.PID)
+	case Unresponsive:
+		return fmt.Errorf("daemon with PID %d is not responding, stop it first", inspection.PID)
+	}
+	return removeFiles(p)
+}
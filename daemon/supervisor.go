@@ -0,0 +1,283 @@
+package daemon
+
+import (
+	"bufio"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/notify"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRestarts limits how often an agent is restarted within restartWindow, so that an agent which crashes
+	// right away isn't restarted forever.
+	maxRestarts   = 3
+	restartWindow = 10 * time.Minute
+	// maxErrors is the number of errors kept for the status.
+	maxErrors = 10
+)
+
+// supervisor watches the stored instances. It answers prompts of instances in auto-yes mode, restarts agents which
+// crashed and keeps the diff stats up to date.
+type supervisor struct {
+	// load returns the stored instances.
+	load func() ([]*session.Instance, error)
+	// autoYes answers the prompts of all instances, not only of those created in auto-yes mode.
+	autoYes      bool
+	pollInterval time.Duration
+	startedAt    time.Time
+
+	mu        sync.Mutex
+	instances []*session.Instance
+	// restarts are the times at which the agents were restarted, by instance title.
+	restarts map[string][]time.Time
+	// attached is the number of TUIs connected to the daemon.
+	attached int
+	// approvals is the number of prompts answered since the daemon started.
+	approvals int
+	// errors are the last errors, oldest first.
+	errors []ErrorStatus
+
+	// everyN limits logging errors which likely repeat on every poll.
+	everyN *log.Every
+}
+
+func newSupervisor(load func() ([]*session.Instance, error), autoYes bool, pollInterval time.Duration) *supervisor {
+	return &supervisor{
+		load:         load,
+		autoYes:      autoYes,
+		pollInterval: pollInterval,
+		startedAt:    time.Now(),
+		restarts:     make(map[string][]time.Time),
+		everyN:       log.NewEvery(60 * time.Second),
+	}
+}
+
+// reload replaces the instances with the stored ones. The sessions of the old instances are released, not killed.
+func (s *supervisor) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.release()
+	instances, err := s.load()
+	if err != nil {
+		return fmt.Errorf("failed to load instances: %w", err)
+	}
+	for _, instance := range instances {
+		if s.autoYes {
+			instance.AutoYes = true
+		}
+	}
+	s.instances = instances
+	log.InfoLog.Printf("daemon watches %d instances", len(instances))
+	return nil
+}
+
+// release releases the sessions of the instances. Must be called with mu held.
+func (s *supervisor) release() {
+	for _, instance := range s.instances {
+		if err := instance.Release(); err != nil {
+			log.WarningLog.Printf("could not release %s: %v", instance.Title, err)
+		}
+	}
+	s.instances = nil
+}
+
+// run polls the instances until the context is done.
+func (s *supervisor) run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		s.poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks every instance once, unless a TUI is attached.
+func (s *supervisor) poll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attached > 0 {
+		return
+	}
+	for _, instance := range s.instances {
+		// We only store started instances, but check anyway.
+		if !instance.Started() || instance.Paused() {
+			continue
+		}
+		if !instance.TmuxAlive() {
+			s.restart(instance)
+			continue
+		}
+		updated, hasPrompt := instance.HasUpdated()
+		switch {
+		case updated:
+			instance.SetStatus(session.Running)
+		case hasPrompt:
+			if instance.AnswerPrompt() {
+				s.approvals++
+			}
+		default:
+			instance.SetStatus(session.Ready)
+		}
+		if err := instance.UpdateDiffStats(); err != nil && s.everyN.ShouldLog() {
+			s.fail("could not update diff stats for %s: %v", instance.Title, err)
+		}
+	}
+}
+
+// restart starts the agent of an instance again, unless it was restarted too often already. Must be called with mu
+// held.
+func (s *supervisor) restart(instance *session.Instance) {
+	var recent []time.Time
+	for _, at := range s.restarts[instance.Title] {
+		if time.Since(at) < restartWindow {
+			recent = append(recent, at)
+		}
+	}
+	s.restarts[instance.Title] = recent
+	if len(recent) >= maxRestarts {
+		if s.everyN.ShouldLog() {
+			s.fail("not restarting %s: it exited %d times in %s", instance.Title, len(recent), restartWindow)
+		}
+		return
+	}
+
+	if err := instance.Restart(); err != nil {
+		if s.everyN.ShouldLog() {
+			s.fail("could not restart %s: %v", instance.Title, err)
+		}
+		return
+	}
+	s.restarts[instance.Title] = append(recent, time.Now())
+	log.InfoLog.Printf("restarted %s", instance.Title)
+}
+
+// fail logs an error and keeps it for the status. Must be called with mu held.
+func (s *supervisor) fail(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	log.ErrorLog.Print(message)
+	notify.Notify(notify.EventError, "", message)
+	s.errors = append(s.errors, ErrorStatus{Time: time.Now(), Message: message})
+	if len(s.errors) > maxErrors {
+		s.errors = s.errors[len(s.errors)-maxErrors:]
+	}
+}
+
+// status returns the status of the daemon.
+func (s *supervisor) status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &Status{
+		PID:       os.Getpid(),
+		StartedAt: s.startedAt,
+		AutoYes:   s.autoYes,
+		Attached:  s.attached,
+		Approvals: s.approvals,
+		Instances: make([]InstanceStatus, 0, len(s.instances)),
+		Errors:    append([]ErrorStatus(nil), s.errors...),
+	}
+	for _, instance := range s.instances {
+		st.Instances = append(st.Instances, InstanceStatus{
+			Title:    instance.Title,
+			Status:   instance.Status.String(),
+			Restarts: len(s.restarts[instance.Title]),
+		})
+	}
+	return st
+}
+
+// serve answers the clients connecting to the listener until it's closed. shutdown is called when a client asks
+// the daemon to stop.
+func (s *supervisor) serve(listener net.Listener, shutdown func()) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.ErrorLog.Printf("daemon stopped accepting clients: %v", err)
+			}
+			return
+		}
+		go s.handle(conn, shutdown)
+	}
+}
+
+// handle answers the request of a client.
+func (s *supervisor) handle(conn net.Conn, shutdown func()) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	var req request
+	var resp response
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.Error = fmt.Sprintf("invalid request: %v", err)
+	} else {
+		switch req.Command {
+		case commandStatus:
+			resp.Status = s.status()
+		case commandReload:
+			if s.status().Attached > 0 {
+				// The instances are loaded once the TUI is gone.
+				break
+			}
+			if err := s.reload(); err != nil {
+				resp.Error = err.Error()
+			}
+		case commandShutdown:
+			defer shutdown()
+		case commandAttach:
+			s.attach()
+			defer s.detach()
+		default:
+			resp.Error = fmt.Sprintf("unknown command %q", req.Command)
+		}
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		return
+	}
+
+	if req.Command == commandAttach && resp.Error == "" {
+		// The TUI doesn't send anything else. The read returns when it closes the connection or exits.
+		_, _ = reader.ReadBytes('\n')
+	}
+}
+
+// attach is called when a TUI connects. The daemon releases the sessions, so that only the TUI watches them.
+func (s *supervisor) attach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attached++
+	s.release()
+}
+
+// detach is called when a TUI is gone. The TUI saved the instances, so the daemon takes over the stored ones.
+func (s *supervisor) detach() {
+	s.mu.Lock()
+	s.attached--
+	attached := s.attached
+	s.mu.Unlock()
+	if attached > 0 {
+		return
+	}
+	if err := s.reload(); err != nil {
+		s.mu.Lock()
+		s.fail("could not reload instances after the TUI exited: %v", err)
+		s.mu.Unlock()
+	}
+}
@@ -22,12 +22,46 @@ const (
 
 	KeyCheckout
 	KeyResume
-	KeyPrompt // New key for entering a prompt
-	KeyHelp   // Key for showing help screen
+	KeyPrompt     // New key for entering a prompt
+	KeyHelp       // Key for showing help screen
+	KeyConflicts  // Key for showing files modified by more than one session
+	KeyTranscript // Key for showing the transcript of a session
+	KeySearch     // Key for searching all sessions
+	KeyWindow     // Key for cycling through the windows of a session
+	KeyFilter     // Key for filtering the list of sessions
+	KeySort       // Key for cycling the sort order of the list
+	KeyGroup      // Key for setting the group of a session
+	KeyTags       // Key for setting the tags of a session
+	KeyRename     // Key for renaming a session
+	KeyDuplicate  // Key for starting a session again with the same prompt
+	KeyFork       // Key for forking a session from its current state
+	KeyFanOut     // Key for starting a best-of-N of sessions with the same prompt
+	KeyCompare    // Key for comparing the sessions of a group
+	KeySendPrompt // Key for sending a prompt to the selected session
+	KeyBroadcast  // Key for sending a prompt to the marked sessions
+	KeyMark       // Key for marking a session
+	KeyAutoYes    // Key for toggling whether the prompts of a session are answered automatically
 
 	// Diff keybindings
 	KeyShiftUp
 	KeyShiftDown
+	KeyDiffNextFile
+	KeyDiffPrevFile
+	KeyDiffNextHunk
+	KeyDiffPrevHunk
+	KeyDiffToggleFile
+	KeyDiffToggleAll
+	KeyDiffNextLine
+	KeyDiffPrevLine
+	KeyDiffMarkHunk
+	KeyDiffStage
+	KeyDiffRevert
+	KeyDiffCommit
+	KeyDiffComment
+	KeyDiffSendComments
+	KeyDiffSideBySide
+	KeyDiffScope
+	KeyDiffRef
 )
 
 // GlobalKeyStringsMap is a global, immutable map string to keybinding.
@@ -49,6 +83,45 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"r":          KeyResume,
 	"p":          KeySubmit,
 	"?":          KeyHelp,
+	"X":          KeyConflicts,
+	"T":          KeyTranscript,
+	"/":          KeySearch,
+	"w":          KeyWindow,
+	"f":          KeyFilter,
+	"s":          KeySort,
+	"g":          KeyGroup,
+	"t":          KeyTags,
+	"R":          KeyRename,
+	"y":          KeyDuplicate,
+	"F":          KeyFork,
+	"B":          KeyFanOut,
+	"=":          KeyCompare,
+	"i":          KeySendPrompt,
+	"I":          KeyBroadcast,
+	" ":          KeyMark,
+	"A":          KeyAutoYes,
+}
+
+// DiffKeyStringsMap is a global, immutable map string to keybinding for keys that only apply while the diff tab
+// is active. These take precedence over GlobalKeyStringsMap in the diff tab.
+var DiffKeyStringsMap = map[string]KeyName{
+	"]": KeyDiffNextFile,
+	"[": KeyDiffPrevFile,
+	"}": KeyDiffNextHunk,
+	"{": KeyDiffPrevHunk,
+	"z": KeyDiffToggleFile,
+	"Z": KeyDiffToggleAll,
+	"J": KeyDiffNextLine,
+	"K": KeyDiffPrevLine,
+	" ": KeyDiffMarkHunk,
+	"a": KeyDiffStage,
+	"u": KeyDiffRevert,
+	"C": KeyDiffCommit,
+	"m": KeyDiffComment,
+	"M": KeyDiffSendComments,
+	"|": KeyDiffSideBySide,
+	"v": KeyDiffScope,
+	"V": KeyDiffRef,
 }
 
 // GlobalkeyBindings is a global, immutable map of KeyName tot keybinding.
@@ -109,6 +182,145 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("r"),
 		key.WithHelp("r", "resume"),
 	),
+	KeyConflicts: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "conflicts"),
+	),
+	KeyTranscript: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "transcript"),
+	),
+	KeySearch: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	KeyWindow: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "next window"),
+	),
+	KeyFilter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "filter"),
+	),
+	KeySort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort"),
+	),
+	KeyGroup: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group"),
+	),
+	KeyTags: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "tags"),
+	),
+	KeyRename: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "rename"),
+	),
+	KeyDuplicate: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "duplicate"),
+	),
+	KeyFork: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "fork"),
+	),
+	KeyFanOut: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "best-of-N"),
+	),
+	KeyCompare: key.NewBinding(
+		key.WithKeys("="),
+		key.WithHelp("=", "compare"),
+	),
+	KeySendPrompt: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "send prompt"),
+	),
+	KeyBroadcast: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "send to marked"),
+	),
+	KeyMark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	KeyAutoYes: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "auto-yes"),
+	),
+
+	// -- Diff tab keybindings --
+
+	KeyDiffNextFile: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next file"),
+	),
+	KeyDiffPrevFile: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "prev file"),
+	),
+	KeyDiffNextHunk: key.NewBinding(
+		key.WithKeys("}"),
+		key.WithHelp("}", "next hunk"),
+	),
+	KeyDiffPrevHunk: key.NewBinding(
+		key.WithKeys("{"),
+		key.WithHelp("{", "prev hunk"),
+	),
+	KeyDiffToggleFile: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "fold file"),
+	),
+	KeyDiffToggleAll: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "fold all"),
+	),
+	KeyDiffNextLine: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "next line"),
+	),
+	KeyDiffPrevLine: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "prev line"),
+	),
+	KeyDiffMarkHunk: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark hunk"),
+	),
+	KeyDiffStage: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "stage hunks"),
+	),
+	KeyDiffRevert: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "revert hunks"),
+	),
+	KeyDiffCommit: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "commit staged"),
+	),
+	KeyDiffComment: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "comment"),
+	),
+	KeyDiffSendComments: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "send comments"),
+	),
+	KeyDiffSideBySide: key.NewBinding(
+		key.WithKeys("|"),
+		key.WithHelp("|", "side by side"),
+	),
+	KeyDiffScope: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "diff scope"),
+	),
+	KeyDiffRef: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "diff vs ref"),
+	),
 
 	// -- Special keybindings --
 
@@ -43,11 +43,17 @@ func Initialize(daemon bool) {
 }
 
 func Close() {
-	_ = globalLogFile.Close()
+	CloseQuietly()
 	// TODO: maybe only print if verbose flag is set?
 	fmt.Println("wrote logs to " + logFileName)
 }
 
+// CloseQuietly closes the log file like Close, without printing where the logs were written. For commands whose
+// output is read by other programs.
+func CloseQuietly() {
+	_ = globalLogFile.Close()
+}
+
 // Every is used to log at most once every timeout duration.
 type Every struct {
 	timeout time.Duration
@@ -74,3 +80,8 @@ func (e *Every) ShouldLog() bool {
 		return false
 	}
 }
+
+// FileName returns the path of the log file, which is shared by all processes.
+func FileName() string {
+	return logFileName
+}
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"bufio"
 	"claude-squad/app"
 	cmd2 "claude-squad/cmd"
 	"claude-squad/config"
 	"claude-squad/daemon"
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/audit"
 	"claude-squad/session/git"
+	"claude-squad/session/notify"
+	"claude-squad/session/policy"
 	"claude-squad/session/tmux"
+	"claude-squad/session/transcript"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -31,10 +42,19 @@ var (
 			defer log.Close()
 
 			if daemonFlag {
+				audit.SetActor(audit.ActorDaemon)
 				cfg := config.LoadConfig()
-				err := daemon.RunDaemon(cfg)
-				log.ErrorLog.Printf("failed to start daemon %v", err)
-				return err
+				if err := loadAgentConfig(cfg); err != nil {
+					return err
+				}
+				if autoYesFlag {
+					cfg.AutoYes = true
+				}
+				if err := daemon.RunDaemon(cfg); err != nil {
+					log.ErrorLog.Printf("failed to run daemon: %v", err)
+					return err
+				}
+				return nil
 			}
 
 			// Check if we're in a git repository
@@ -48,6 +68,9 @@ var (
 			}
 
 			cfg := config.LoadConfig()
+			if err := loadAgentConfig(cfg); err != nil {
+				return err
+			}
 
 			// Program flag overrides config
 			program := cfg.DefaultProgram
@@ -61,14 +84,16 @@ var (
 			}
 			if autoYes {
 				defer func() {
-					if err := daemon.LaunchDaemon(); err != nil {
+					if err := daemon.LaunchDaemon(true); err != nil {
 						log.ErrorLog.Printf("failed to launch daemon: %v", err)
 					}
 				}()
 			}
-			// Kill any daemon that's running.
-			if err := daemon.StopDaemon(); err != nil {
-				log.ErrorLog.Printf("failed to stop daemon: %v", err)
+			// A running daemon leaves the instances to the TUI until it exits.
+			if client, err := daemon.Attach(); err == nil {
+				defer client.Close()
+			} else if !errors.Is(err, daemon.ErrNotRunning) {
+				log.ErrorLog.Printf("failed to attach to daemon: %v", err)
 			}
 
 			return app.Run(ctx, program, autoYes)
@@ -82,11 +107,21 @@ var (
 			log.Initialize(false)
 			defer log.Close()
 
+			// Stop the daemon first, so that it doesn't restart the agents whose sessions are cleaned up.
+			if err := daemon.StopDaemon(daemon.StopTimeout); err != nil {
+				return err
+			}
+			fmt.Println("daemon has been stopped")
+
 			state := config.LoadState()
 			storage, err := session.NewStorage(state)
 			if err != nil {
 				return fmt.Errorf("failed to initialize storage: %w", err)
 			}
+			forgotten, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
 			if err := storage.DeleteAllInstances(); err != nil {
 				return fmt.Errorf("failed to reset storage: %w", err)
 			}
@@ -97,17 +132,15 @@ var (
 			}
 			fmt.Println("Tmux sessions have been cleaned up")
 
-			if err := git.CleanupWorktrees(); err != nil {
+			orphans, err := session.FindOrphans(nil, forgotten, nil)
+			if err != nil {
+				return fmt.Errorf("failed to find worktrees: %w", err)
+			}
+			if err := cleanOrphans(orphans, storage, true, false); err != nil {
 				return fmt.Errorf("failed to cleanup worktrees: %w", err)
 			}
 			fmt.Println("Worktrees have been cleaned up")
 
-			// Kill any daemon that's running.
-			if err := daemon.StopDaemon(); err != nil {
-				return err
-			}
-			fmt.Println("daemon has been stopped")
-
 			return nil
 		},
 	}
@@ -130,6 +163,302 @@ var (
 		},
 	}
 
+	auditCmd = &cobra.Command{
+		Use:   "audit <title>",
+		Short: "Print the audit log of the prompts, keys and approvals sent to an instance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.CloseQuietly()
+
+			entries, skipped, err := audit.Read(args[0])
+			if err != nil {
+				return err
+			}
+			if skipped > 0 {
+				fmt.Fprintf(os.Stderr, "skipped %d invalid lines of the audit log, see %s\n", skipped, log.FileName())
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("no audit log for instance %s", args[0])
+			}
+			if auditLimitFlag > 0 && len(entries) > auditLimitFlag {
+				entries = entries[len(entries)-auditLimitFlag:]
+			}
+
+			for _, entry := range entries {
+				if auditJSONFlag {
+					data, err := json.Marshal(entry)
+					if err != nil {
+						return err
+					}
+					fmt.Println(string(data))
+					continue
+				}
+				payload := entry.Payload
+				if payload == "" {
+					payload = "sha256:" + entry.PayloadHash
+				}
+				fmt.Printf("%s  %-6s  %-7s  %q\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Actor,
+					entry.Action, payload)
+				if auditPaneFlag && entry.Pane != "" {
+					fmt.Printf("    | %s\n", strings.ReplaceAll(entry.Pane, "\n", "\n    | "))
+				}
+			}
+			return nil
+		},
+	}
+
+	transcriptCmd = &cobra.Command{
+		Use:   "transcript <title>",
+		Short: "Export the transcript of an instance as plain text or as an asciicast for replay",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := transcript.Load(args[0])
+			if err != nil {
+				return err
+			}
+			switch transcriptFormatFlag {
+			case "text":
+				fmt.Println(t.Text())
+				return nil
+			case "cast":
+				return t.WriteCast(os.Stdout)
+			default:
+				return fmt.Errorf("unknown transcript format %q, expected text or cast", transcriptFormatFlag)
+			}
+		},
+	}
+
+	watchCmd = &cobra.Command{
+		Use:   "watch <title>...",
+		Short: "Watch the sessions of instances read-only, tiled if there are several",
+		Long: "Watch attaches to the tmux sessions of the instances without forwarding keys, so an agent can be " +
+			"observed from another terminal without interfering. Press the tmux prefix (ctrl-b) and d to stop.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if watchAllFlag {
+				if len(args) > 0 {
+					return fmt.Errorf("--all does not take titles")
+				}
+				return tmux.WatchAll()
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("expected the titles of the instances to watch, or --all")
+			}
+			return tmux.Watch(args)
+		},
+	}
+
+	reconcileCmd = &cobra.Command{
+		Use:   "reconcile",
+		Short: "List the worktrees, branches and tmux sessions which no instance uses, and clean them up",
+		Long: "Reconcile cross-references the stored instances with the worktree directory, the worktrees of their " +
+			"repositories and the tmux sessions. It lists the orphans, and with --clean removes them after asking " +
+			"for each one. Branches with unpushed commits are only deleted with --force. Quit Claude Squad first, " +
+			"since the instances it is creating aren't stored yet.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			stored, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
+			sessions, err := tmux.ListSessions(cmd2.MakeExecutor())
+			if err != nil {
+				return err
+			}
+			orphans, err := session.FindOrphans(stored, nil, sessions)
+			if err != nil {
+				return err
+			}
+			printOrphans(orphans)
+			if !reconcileCleanFlag || orphans.Empty() {
+				return nil
+			}
+			fmt.Println()
+			cleanErr := cleanOrphans(orphans, storage, reconcileYesFlag, reconcileForceFlag)
+			// The daemon shouldn't keep supervising the instances which were forgotten.
+			if err := daemon.Reload(); err != nil {
+				log.ErrorLog.Printf("failed to reload the daemon: %v", err)
+			}
+			return cleanErr
+		},
+	}
+
+	reapCmd = &cobra.Command{
+		Use:   "reap",
+		Short: "List the instances which the reaper would pause or kill, without changing anything",
+		Long: "The reaper runs in the background of the TUI. It pauses instances whose pane and diff didn't change for " +
+			"reaper.pause_idle_hours, and kills instances paused for reaper.kill_merged_days whose branch was " +
+			"merged. The flags override the config to see what other limits would do.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			cfg := config.LoadConfig().Reaper
+			if cmd.Flags().Changed("idle-hours") {
+				cfg.PauseIdleHours = reapIdleHoursFlag
+			}
+			if cmd.Flags().Changed("merged-days") {
+				cfg.KillMergedDays = reapMergedDaysFlag
+			}
+			if cfg.PauseIdleHours <= 0 && cfg.KillMergedDays <= 0 {
+				fmt.Println("The reaper is disabled, set reaper in the config or pass --idle-hours or --merged-days")
+				return nil
+			}
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			instances, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
+			plan := session.PlanReaping(cfg, instances, time.Now())
+			if len(plan) == 0 {
+				fmt.Println("Nothing to reap")
+				return nil
+			}
+			for _, reaping := range plan {
+				fmt.Printf("%-6s %-32s %s\n", reaping.Action, reaping.Title, reaping.Reason)
+			}
+			return nil
+		},
+	}
+
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Start, stop and inspect the daemon which supervises the instances in the background",
+	}
+
+	daemonStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon unless it's running",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			inspection, err := daemon.Inspect()
+			if err != nil {
+				return err
+			}
+			switch inspection.State {
+			case daemon.Running:
+				fmt.Printf("Daemon is already running (PID %d)\n", inspection.PID)
+				return nil
+			case daemon.Unresponsive:
+				return fmt.Errorf("daemon (PID %d) is not responding, stop it with 'cs daemon stop'", inspection.PID)
+			}
+
+			if err := daemon.LaunchDaemon(daemonAutoYesFlag); err != nil {
+				return err
+			}
+			deadline := time.Now().Add(daemon.StopTimeout)
+			for time.Now().Before(deadline) {
+				if status, err := daemon.GetStatus(); err == nil {
+					fmt.Printf("Daemon started (PID %d)\n", status.PID)
+					return nil
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			return fmt.Errorf("daemon did not start, see 'cs daemon logs'")
+		},
+	}
+
+	daemonStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon, and kill it if it doesn't stop within the timeout",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			inspection, err := daemon.Inspect()
+			if err != nil {
+				return err
+			}
+			if err := daemon.StopDaemon(daemonStopTimeoutFlag); err != nil {
+				return err
+			}
+			switch inspection.State {
+			case daemon.NotRunning:
+				fmt.Println("Daemon is not running")
+			case daemon.StaleDead, daemon.StaleUnrelated:
+				fmt.Printf("Daemon is not running, removed %s (PID %d)\n", inspection.State, inspection.PID)
+			default:
+				fmt.Printf("Daemon (PID %d) stopped\n", inspection.PID)
+			}
+			return nil
+		},
+	}
+
+	daemonStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Print whether the daemon runs and what it's doing",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inspection, err := daemon.Inspect()
+			if err != nil {
+				return err
+			}
+			if inspection.State != daemon.Running {
+				if inspection.PID != 0 {
+					fmt.Printf("Daemon: %s (PID %d)\n", inspection.State, inspection.PID)
+				} else {
+					fmt.Printf("Daemon: %s\n", inspection.State)
+				}
+				return nil
+			}
+
+			status := inspection.Status
+			fmt.Printf("Daemon: running (PID %d, up %s)\n", status.PID,
+				time.Since(status.StartedAt).Round(time.Second))
+			if status.AutoYes {
+				fmt.Println("Auto-yes: all instances")
+			} else {
+				fmt.Println("Auto-yes: instances created in auto-yes mode")
+			}
+			if status.Attached > 0 {
+				fmt.Printf("Attached: %d TUI, which manages the instances meanwhile\n", status.Attached)
+			}
+			fmt.Printf("Approvals: %d\n", status.Approvals)
+			fmt.Printf("Instances: %d\n", len(status.Instances))
+			for _, instance := range status.Instances {
+				fmt.Printf("  %-32s %-8s %d restarts\n", instance.Title, instance.Status, instance.Restarts)
+			}
+			if len(status.Errors) > 0 {
+				fmt.Println("Last errors:")
+				for _, e := range status.Errors {
+					fmt.Printf("  %s  %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Message)
+				}
+			}
+			return nil
+		},
+	}
+
+	daemonLogsCmd = &cobra.Command{
+		Use:   "logs",
+		Short: "Print the log of the daemon",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return daemon.Logs(ctx, os.Stdout, daemonLogLinesFlag, daemonLogFollowFlag)
+		},
+	}
+
+	// transcriptRecordCmd is run by tmux pipe-pane with the output of a pane on stdin.
+	transcriptRecordCmd = &cobra.Command{
+		Use:    "transcript-record <file>",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return transcript.Record(os.Stdin, args[0], transcriptPaneFlag)
+		},
+	}
+
 	versionCmd = &cobra.Command{
 		Use:   "version",
 		Short: "Print the version number of claude-squad",
@@ -140,6 +469,139 @@ var (
 	}
 )
 
+// loadAgentConfig sets up the agent profiles, the approval policy, the audit log and the notifications from the
+// config. Every command which runs agents calls it: the TUI, the daemon and wtask.
+func loadAgentConfig(cfg *config.Config) error {
+	config.SetAgentProfiles(config.LoadAgentProfiles(cfg))
+	audit.Configure(cfg.Audit)
+	if err := tmux.SetAttachKeys(cfg.Attach); err != nil {
+		return fmt.Errorf("invalid attach keys: %w", err)
+	}
+	if cfg.ApprovalPolicy != nil {
+		engine, err := policy.NewEngine(*cfg.ApprovalPolicy)
+		if err != nil {
+			return fmt.Errorf("invalid approval policy: %w", err)
+		}
+		policy.SetDefault(engine)
+	}
+	if err := notify.Configure(cfg.Notifications); err != nil {
+		return fmt.Errorf("invalid notifications: %w", err)
+	}
+	return nil
+}
+
+var (
+	auditJSONFlag  bool
+	auditPaneFlag  bool
+	auditLimitFlag int
+
+	transcriptFormatFlag string
+	transcriptPaneFlag   string
+
+	watchAllFlag bool
+
+	reconcileCleanFlag bool
+	reconcileYesFlag   bool
+	reconcileForceFlag bool
+
+	reapIdleHoursFlag  int
+	reapMergedDaysFlag int
+
+	daemonAutoYesFlag     bool
+	daemonStopTimeoutFlag time.Duration
+	daemonLogLinesFlag    int
+	daemonLogFollowFlag   bool
+)
+
+// printOrphans lists the orphans found by reconcile.
+func printOrphans(orphans *session.Orphans) {
+	if orphans.Empty() {
+		fmt.Println("Nothing to clean up")
+		return
+	}
+	section := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Printf("%s:\n", title)
+		for _, item := range items {
+			fmt.Printf("  %s\n", item)
+		}
+	}
+	var worktrees, branches []string
+	for _, worktree := range orphans.Worktrees {
+		worktrees = append(worktrees, worktree.String())
+	}
+	for _, branch := range orphans.Branches {
+		branches = append(branches, branch.String())
+	}
+	section("Worktrees without an instance", worktrees)
+	section("Branches without an instance", branches)
+	section("Tmux sessions without an instance", orphans.Sessions)
+	section("Instances whose worktree is gone", orphans.Instances)
+}
+
+// cleanOrphans removes the orphans, asking for each one unless yes is set. Branches with unpushed commits are kept
+// unless force is set.
+func cleanOrphans(orphans *session.Orphans, storage *session.Storage, yes, force bool) error {
+	reader := bufio.NewReader(os.Stdin)
+	all, quit := yes, false
+	confirm := func(question string) bool {
+		if all || quit {
+			return all
+		}
+		fmt.Printf("%s? [y/N/a/q] ", question)
+		answer, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "a", "all":
+			all = true
+			return true
+		case "q", "quit":
+			quit = true
+		}
+		return false
+	}
+	failed := 0
+	check := func(err error) {
+		if err != nil {
+			fmt.Printf("  failed: %v\n", err)
+			failed++
+		}
+	}
+
+	// Sessions and worktrees go first, since git doesn't delete branches which are checked out.
+	for _, name := range orphans.Sessions {
+		if confirm("Kill tmux session " + name) {
+			check(tmux.KillSession(cmd2.MakeExecutor(), name))
+		}
+	}
+	for _, worktree := range orphans.Worktrees {
+		if confirm("Remove worktree " + worktree.String()) {
+			check(worktree.Remove())
+		}
+	}
+	for _, branch := range orphans.Branches {
+		if branch.Unpushed > 0 && !force {
+			fmt.Printf("Keeping branch %s, delete it with 'cs reconcile --clean --force'\n", branch)
+			continue
+		}
+		if confirm("Delete branch " + branch.String()) {
+			check(branch.Delete(force))
+		}
+	}
+	for _, title := range orphans.Instances {
+		if confirm("Forget instance " + title) {
+			check(storage.DeleteInstance(title))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d orphans could not be cleaned up", failed)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.Flags().StringVarP(&programFlag, "program", "p", "",
 		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
@@ -154,10 +616,42 @@ func init() {
 		panic(err)
 	}
 
+	auditCmd.Flags().BoolVar(&auditJSONFlag, "json", false, "Print the entries as JSON lines")
+	auditCmd.Flags().BoolVar(&auditPaneFlag, "pane", false, "Print the pane excerpt recorded with each entry")
+	auditCmd.Flags().IntVarP(&auditLimitFlag, "limit", "n", 0, "Only print the last n entries")
+
+	watchCmd.Flags().BoolVarP(&watchAllFlag, "all", "a", false, "Watch the sessions of all instances")
+	reconcileCmd.Flags().BoolVar(&reconcileCleanFlag, "clean", false, "Remove the orphans, asking for each one")
+	reconcileCmd.Flags().BoolVarP(&reconcileYesFlag, "yes", "y", false, "Don't ask before removing orphans")
+	reconcileCmd.Flags().BoolVar(&reconcileForceFlag, "force", false, "Also delete branches with unpushed commits")
+	reapCmd.Flags().IntVar(&reapIdleHoursFlag, "idle-hours", 0, "Pause instances idle for this many hours")
+	reapCmd.Flags().IntVar(&reapMergedDaysFlag, "merged-days", 0,
+		"Kill instances paused for this many days whose branch was merged")
+
+	daemonStartCmd.Flags().BoolVarP(&daemonAutoYesFlag, "autoyes", "y", false,
+		"[experimental] Answer the prompts of all instances, not only of those created in auto-yes mode")
+	daemonStopCmd.Flags().DurationVar(&daemonStopTimeoutFlag, "timeout", daemon.StopTimeout,
+		"How long the daemon gets to shut down before it's killed")
+	daemonLogsCmd.Flags().IntVarP(&daemonLogLinesFlag, "lines", "n", 50, "Print the last n lines, or all with 0")
+	daemonLogsCmd.Flags().BoolVarP(&daemonLogFollowFlag, "follow", "f", false, "Keep printing new lines")
+	daemonCmd.AddCommand(daemonStartCmd, daemonStopCmd, daemonStatusCmd, daemonLogsCmd)
+
+	transcriptCmd.Flags().StringVarP(&transcriptFormatFlag, "format", "f", "text", "Output format: text or cast")
+	transcriptRecordCmd.Flags().StringVar(&transcriptPaneFlag, "pane", "", "The tmux pane which is recorded")
+
 	rootCmd.AddCommand(debugCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(resetCmd)
-	rootCmd.AddCommand(cmd2.GetWTaskCmd())
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(transcriptCmd)
+	rootCmd.AddCommand(transcriptRecordCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(reapCmd)
+	rootCmd.AddCommand(reconcileCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(cmd2.GetWTaskCmd(func() error {
+		return loadAgentConfig(config.LoadConfig())
+	}))
 }
 
 func main() {
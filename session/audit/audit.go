@@ -0,0 +1,350 @@
+package audit
+
+import (
+	"bufio"
+	"claude-squad/config"
+	"claude-squad/log"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirName is the directory in the config directory which holds the audit log of every instance.
+const DirName = "audit"
+
+// Actor is who sent something to an agent.
+type Actor string
+
+const (
+	// ActorUser is a person using the TUI.
+	ActorUser Actor = "user"
+	// ActorDaemon is the auto-yes mode, whether it runs in the daemon or in the TUI.
+	ActorDaemon Actor = "daemon"
+	// ActorWTask is the worktree task runner.
+	ActorWTask Actor = "wtask"
+	// ActorAPI is a program talking to claude squad.
+	ActorAPI Actor = "api"
+)
+
+// Actions recorded in the audit log.
+const (
+	// ActionPrompt is a prompt sent to the agent, followed by enter.
+	ActionPrompt = "prompt"
+	// ActionKeys are keystrokes sent to the agent.
+	ActionKeys = "keys"
+	// ActionApprove is an approval prompt answered with the approval keys.
+	ActionApprove = "approve"
+	// ActionReject is an approval prompt answered with the reject keys.
+	ActionReject = "reject"
+)
+
+// Entry is a line in the audit log of an instance.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Instance string    `json:"instance"`
+	Actor    Actor     `json:"actor"`
+	Action   string    `json:"action"`
+	// Payload is what was sent. It is empty if the config only allows hashes to be recorded.
+	Payload     string `json:"payload,omitempty"`
+	PayloadHash string `json:"payload_sha256"`
+	// Pane is the end of the pane before the payload was sent.
+	Pane string `json:"pane,omitempty"`
+}
+
+var (
+	mu       sync.Mutex
+	actor    = ActorUser
+	settings = config.AuditConfig{}.WithDefaults()
+)
+
+// SetActor sets the actor of everything this process sends to agents. Defaults to ActorUser.
+func SetActor(a Actor) {
+	mu.Lock()
+	defer mu.Unlock()
+	actor = a
+}
+
+// CurrentActor returns the actor set with SetActor.
+func CurrentActor() Actor {
+	mu.Lock()
+	defer mu.Unlock()
+	return actor
+}
+
+// Configure sets how entries are recorded and rotated.
+func Configure(cfg config.AuditConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = cfg.WithDefaults()
+}
+
+// PaneLines returns how many lines of the pane should be passed to Record.
+func PaneLines() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return settings.PaneLines
+}
+
+// Record appends an entry to the audit log of the instance. Errors are logged, since an agent should not stop
+// receiving input because the log could not be written.
+func Record(instance string, actor Actor, action, payload, pane string) {
+	if err := record(instance, actor, action, payload, pane); err != nil {
+		log.WarningLog.Printf("could not write audit log of %s: %v", instance, err)
+	}
+}
+
+func record(instance string, actor Actor, action, payload, pane string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if settings.Disabled {
+		return nil
+	}
+
+	hash := sha256.Sum256([]byte(payload))
+	entry := Entry{
+		Time:        time.Now(),
+		Instance:    instance,
+		Actor:       actor,
+		Action:      action,
+		PayloadHash: hex.EncodeToString(hash[:]),
+		Pane:        pane,
+	}
+	if !settings.HashPayloads {
+		entry.Payload = payload
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	path, err := logPath(instance, 0)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(data)) > int64(settings.MaxSizeKB)*1024 {
+		if err := rotate(instance, settings.MaxFiles); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts the log of the instance to the first rotated file, and each rotated file to the next one. The
+// oldest file is removed once there are maxFiles rotated files.
+func rotate(instance string, maxFiles int) error {
+	oldest, err := logPath(instance, maxFiles)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove oldest audit log: %w", err)
+	}
+	for n := maxFiles - 1; n >= 0; n-- {
+		from, _ := logPath(instance, n)
+		to, _ := logPath(instance, n+1)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Read returns the entries of the instance, oldest first, including the rotated ones, and the number of invalid lines
+// which were skipped.
+func Read(instance string) ([]Entry, int, error) {
+	paths, err := logPaths(instance)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []Entry
+	skipped := 0
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			// A line which is not valid JSON was cut off by a crash while it was written.
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				log.WarningLog.Printf("skipping invalid line in %s: %v", path, err)
+				skipped++
+				continue
+			}
+			// Titles which only differ in special characters share a file.
+			if entry.Instance == instance {
+				entries = append(entries, entry)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+	// Entries moved by Rename may be older than those already in the log of the new title.
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].Time.Before(entries[b].Time) })
+	return entries, skipped, nil
+}
+
+// Rename moves the entries of an instance, including the rotated ones, to the log of its new title. Entries of
+// other instances whose title shares a file with it stay where they are.
+func Rename(oldInstance, newInstance string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	paths, err := logPaths(oldInstance)
+	if err != nil {
+		return err
+	}
+	oldCurrent, err := logPath(oldInstance, 0)
+	if err != nil {
+		return err
+	}
+	newCurrent, err := logPath(newInstance, 0)
+	if err != nil {
+		return err
+	}
+	// A rotated log keeps its number.
+	oldBase, newBase := strings.TrimSuffix(oldCurrent, ".jsonl"), strings.TrimSuffix(newCurrent, ".jsonl")
+	for _, oldPath := range paths {
+		newPath := newBase + strings.TrimPrefix(oldPath, oldBase)
+		if err := moveEntries(oldPath, newPath, oldInstance, newInstance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveEntries moves the entries of an instance from one log to another one, renaming the instance. The other lines
+// stay in the old log, which is removed if none are left.
+func moveEntries(oldPath, newPath, oldInstance, newInstance string) error {
+	data, err := os.ReadFile(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	// If both titles share the file, the renamed entries stay where they are.
+	shared := oldPath == newPath
+	var kept, moved []byte
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		var entry Entry
+		if line == "" || json.Unmarshal([]byte(line), &entry) != nil || entry.Instance != oldInstance {
+			kept = append(kept, line...)
+			continue
+		}
+		entry.Instance = newInstance
+		renamed, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit entry: %w", err)
+		}
+		renamed = append(renamed, '\n')
+		if shared {
+			kept = append(kept, renamed...)
+		} else {
+			moved = append(moved, renamed...)
+		}
+	}
+
+	if len(kept) == 0 {
+		if err := os.Remove(oldPath); err != nil {
+			return fmt.Errorf("failed to remove audit log: %w", err)
+		}
+	} else if err := os.WriteFile(oldPath, kept, 0600); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(newPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(moved); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// logPaths returns the existing logs of the instance, oldest first. Rotated logs may be missing in between, like
+// after Rename moved all the entries out of one.
+func logPaths(instance string) ([]string, error) {
+	current, err := logPath(instance, 0)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(current, ".jsonl")
+	matches, err := filepath.Glob(base + ".*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	rotated := make(map[int]string)
+	var numbers []int
+	for _, path := range matches {
+		number := strings.TrimSuffix(strings.TrimPrefix(path, base+"."), ".jsonl")
+		if n, err := strconv.Atoi(number); err == nil && n > 0 {
+			rotated[n] = path
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+	var paths []string
+	for _, n := range numbers {
+		paths = append(paths, rotated[n])
+	}
+	if _, err := os.Stat(current); err == nil {
+		paths = append(paths, current)
+	}
+	return paths, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// logPath returns the path of the log of the instance, or of its n-th rotated log if n is positive.
+func logPath(instance string, n int) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	name := unsafeFileChars.ReplaceAllString(instance, "_")
+	if n > 0 {
+		name = fmt.Sprintf("%s.%d", name, n)
+	}
+	return filepath.Join(configDir, DirName, name+".jsonl"), nil
+}
+
+// Excerpt returns the last lines of the pane content, without trailing blank lines.
+func Excerpt(content string, lines int) string {
+	all := strings.Split(strings.TrimRight(content, " \t\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	for i := range all {
+		all[i] = strings.TrimRight(all[i], " \t")
+	}
+	return strings.Join(all, "\n")
+}
@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+
+	os.Exit(m.Run())
+}
+
+func TestRecordAndRead(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	Configure(config.AuditConfig{})
+
+	Record("my task", ActorUser, ActionPrompt, "fix the tests", "> ")
+	Record("my task", ActorDaemon, ActionApprove, "Bash command: go test ./...", "Do you want to proceed?")
+	Record("other", ActorUser, ActionKeys, "q", "")
+
+	entries, skipped, err := Read("my task")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Zero(t, skipped)
+	assert.Equal(t, ActorUser, entries[0].Actor)
+	assert.Equal(t, ActionPrompt, entries[0].Action)
+	assert.Equal(t, "fix the tests", entries[0].Payload)
+	assert.Equal(t, "> ", entries[0].Pane)
+	assert.Len(t, entries[0].PayloadHash, 64)
+	assert.Equal(t, ActionApprove, entries[1].Action)
+
+	t.Run("hash only", func(t *testing.T) {
+		Configure(config.AuditConfig{HashPayloads: true})
+		defer Configure(config.AuditConfig{})
+
+		Record("secret", ActorAPI, ActionPrompt, "the password is hunter2", "")
+		entries, _, err := Read("secret")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Empty(t, entries[0].Payload)
+		hash := sha256.Sum256([]byte("the password is hunter2"))
+		assert.Equal(t, hex.EncodeToString(hash[:]), entries[0].PayloadHash)
+	})
+
+	t.Run("cut off line", func(t *testing.T) {
+		path, err := logPath("my task", 0)
+		require.NoError(t, err)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"instance":"my task","act` + "\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		Record("my task", ActorUser, ActionKeys, "y", "")
+
+		entries, skipped, err := Read("my task")
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+		assert.Equal(t, 1, skipped)
+	})
+}
+
+func TestRotation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	Configure(config.AuditConfig{MaxSizeKB: 1, MaxFiles: 2})
+	defer Configure(config.AuditConfig{})
+
+	payload := string(make([]byte, 400))
+	for i := 0; i < 10; i++ {
+		Record("rotated", ActorUser, ActionKeys, payload, "")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(home, ".claude-squad", DirName, "rotated*.jsonl"))
+	require.NoError(t, err)
+	assert.Len(t, paths, 3, "the current log and two rotated logs are kept")
+
+	entries, _, err := Read("rotated")
+	require.NoError(t, err)
+	assert.Less(t, len(entries), 10, "the oldest entries are dropped")
+	for i := 1; i < len(entries); i++ {
+		assert.False(t, entries[i].Time.Before(entries[i-1].Time), "entries are read oldest first")
+	}
+}
+
+func TestRename(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	Configure(config.AuditConfig{MaxSizeKB: 1, MaxFiles: 10})
+	defer Configure(config.AuditConfig{})
+
+	// "old task" and "old_task" share a file.
+	Record("old_task", ActorUser, ActionPrompt, "other", "")
+	for i := 0; i < 6; i++ {
+		Record("old task", ActorUser, ActionPrompt, strings.Repeat("x", 300), "")
+	}
+	before, _, err := Read("old task")
+	require.NoError(t, err)
+	require.Len(t, before, 6)
+	paths, err := logPaths("old task")
+	require.NoError(t, err)
+	require.Greater(t, len(paths), 1, "the log was rotated")
+
+	require.NoError(t, Rename("old task", "new task"))
+	entries, _, err := Read("new task")
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	for i, entry := range entries {
+		assert.Equal(t, "new task", entry.Instance)
+		assert.Equal(t, before[i].Time, entry.Time)
+	}
+	gone, _, err := Read("old task")
+	require.NoError(t, err)
+	assert.Empty(t, gone)
+	other, _, err := Read("old_task")
+	require.NoError(t, err)
+	require.Len(t, other, 1, "entries of other instances sharing the file stay")
+	assert.Equal(t, "other", other[0].Payload)
+
+	// Titles which share a file are renamed in place.
+	require.NoError(t, Rename("new task", "new_task!"))
+	entries, _, err = Read("new_task!")
+	require.NoError(t, err)
+	assert.Len(t, entries, 6)
+}
+
+func TestExcerpt(t *testing.T) {
+	assert.Equal(t, "c\nd", Excerpt("a\nb\nc  \nd\n\n\n", 2))
+	assert.Equal(t, "a", Excerpt("a", 10))
+}
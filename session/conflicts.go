@@ -0,0 +1,100 @@
+package session
+
+import (
+	"sort"
+)
+
+// FileConflict is a file that has been modified by more than one session working on the same repository.
+type FileConflict struct {
+	// RepoPath is the path to the repository that the sessions share.
+	RepoPath string
+	// Path is the path of the file relative to the repository root.
+	Path string
+	// Sessions are the titles of the sessions that touched the file, sorted alphabetically.
+	Sessions []string
+}
+
+// ConflictReport holds all files that are being edited by more than one session at the same time.
+type ConflictReport struct {
+	// Conflicts is sorted by repo path and then by file path.
+	Conflicts []FileConflict
+
+	// byTitle maps a session title to the conflicting paths it touched and the other sessions touching them.
+	byTitle map[string]map[string][]string
+}
+
+// DetectConflicts computes the touched-file sets from the diff stats of every started instance and returns
+// the files that overlap between instances on the same repository. Paused instances are included since their
+// changes still have to be merged eventually.
+func DetectConflicts(instances []*Instance) *ConflictReport {
+	// repo path -> file path -> session titles
+	touched := make(map[string]map[string][]string)
+	for _, instance := range instances {
+		if !instance.Started() || instance.gitWorktree == nil {
+			continue
+		}
+		stats := instance.GetDiffStats()
+		if stats == nil || stats.Error != nil || stats.IsEmpty() {
+			continue
+		}
+		repoPath := instance.gitWorktree.GetRepoPath()
+		if _, ok := touched[repoPath]; !ok {
+			touched[repoPath] = make(map[string][]string)
+		}
+		for _, path := range stats.TouchedFiles() {
+			touched[repoPath][path] = append(touched[repoPath][path], instance.Title)
+		}
+	}
+
+	report := &ConflictReport{byTitle: make(map[string]map[string][]string)}
+	for repoPath, files := range touched {
+		for path, titles := range files {
+			if len(titles) < 2 {
+				continue
+			}
+			sort.Strings(titles)
+			report.Conflicts = append(report.Conflicts, FileConflict{
+				RepoPath: repoPath,
+				Path:     path,
+				Sessions: titles,
+			})
+			for _, title := range titles {
+				if _, ok := report.byTitle[title]; !ok {
+					report.byTitle[title] = make(map[string][]string)
+				}
+				for _, other := range titles {
+					if other != title {
+						report.byTitle[title][path] = append(report.byTitle[title][path], other)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(report.Conflicts, func(a, b int) bool {
+		if report.Conflicts[a].RepoPath != report.Conflicts[b].RepoPath {
+			return report.Conflicts[a].RepoPath < report.Conflicts[b].RepoPath
+		}
+		return report.Conflicts[a].Path < report.Conflicts[b].Path
+	})
+	return report
+}
+
+// ForInstance returns the conflicting paths of the given session mapped to the other sessions touching them.
+// It returns nil if the session has no conflicts. The report may be nil.
+func (r *ConflictReport) ForInstance(title string) map[string][]string {
+	if r == nil {
+		return nil
+	}
+	return r.byTitle[title]
+}
+
+// HasConflicts returns true if the given session shares at least one modified file with another session.
+func (r *ConflictReport) HasConflicts(title string) bool {
+	return len(r.ForInstance(title)) > 0
+}
+
+// IsEmpty returns true if no files are shared between sessions. The report may be nil.
+func (r *ConflictReport) IsEmpty() bool {
+	return r == nil || len(r.Conflicts) == 0
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diffMainAndReadme = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+-package old
++package main
+diff --git a/README.md b/README.md
+new file mode 100644
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1 @@
++hello
+`
+
+const diffMainOnly = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+--- a/not-a-header.go
++package main
+`
+
+// newConflictTestInstance creates a started instance with the given diff without touching tmux or git.
+func newConflictTestInstance(title, repoPath, diff string) *Instance {
+	return &Instance{
+		Title:       title,
+		started:     true,
+		gitWorktree: git.NewGitWorktreeFromStorage(repoPath, "/tmp/"+title, title, title, "abc"),
+		diffStats:   &git.DiffStats{Content: diff, Added: 1, Removed: 1},
+	}
+}
+
+func TestDetectConflicts(t *testing.T) {
+	t.Run("reports files shared by sessions on the same repo", func(t *testing.T) {
+		report := DetectConflicts([]*Instance{
+			newConflictTestInstance("b", "/repo", diffMainAndReadme),
+			newConflictTestInstance("a", "/repo", diffMainOnly),
+		})
+
+		require.Len(t, report.Conflicts, 1)
+		assert.Equal(t, "main.go", report.Conflicts[0].Path)
+		assert.Equal(t, []string{"a", "b"}, report.Conflicts[0].Sessions)
+		assert.Equal(t, map[string][]string{"main.go": {"b"}}, report.ForInstance("a"))
+		assert.True(t, report.HasConflicts("b"))
+	})
+
+	t.Run("ignores sessions on different repos", func(t *testing.T) {
+		report := DetectConflicts([]*Instance{
+			newConflictTestInstance("a", "/repo-one", diffMainOnly),
+			newConflictTestInstance("b", "/repo-two", diffMainOnly),
+		})
+
+		assert.True(t, report.IsEmpty())
+		assert.False(t, report.HasConflicts("a"))
+	})
+
+	t.Run("nil report has no conflicts", func(t *testing.T) {
+		var report *ConflictReport
+		assert.True(t, report.IsEmpty())
+		assert.Nil(t, report.ForInstance("a"))
+	})
+}
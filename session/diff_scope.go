@@ -0,0 +1,106 @@
+package session
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"fmt"
+)
+
+// DiffScope selects what the changes of an instance are compared against in the diff tab.
+type DiffScope int
+
+const (
+	// DiffScopeSessionStart shows all changes since the instance was created.
+	DiffScopeSessionStart DiffScope = iota
+	// DiffScopeUncommitted shows the changes which have not been committed yet.
+	DiffScopeUncommitted
+	// DiffScopeLastTurn shows the changes since the agent last started working.
+	DiffScopeLastTurn
+	// DiffScopeRef shows the changes compared to an arbitrary ref.
+	DiffScopeRef
+)
+
+// DiffScope returns the current diff scope of the instance, and the ref it compares against for DiffScopeRef.
+func (i *Instance) DiffScope() (DiffScope, string) {
+	return i.diffScope, i.diffRef
+}
+
+// DiffScopeLabel returns a short description of the current diff scope.
+func (i *Instance) DiffScopeLabel() string {
+	switch i.diffScope {
+	case DiffScopeUncommitted:
+		return "uncommitted"
+	case DiffScopeLastTurn:
+		return "since last agent turn"
+	case DiffScopeRef:
+		return "vs " + i.diffRef
+	default:
+		return "since session start"
+	}
+}
+
+// SetDiffScope changes what the diff of the instance is compared against and recomputes it. The ref is only used
+// for DiffScopeRef and must exist in the worktree.
+func (i *Instance) SetDiffScope(scope DiffScope, ref string) error {
+	if scope == DiffScopeRef {
+		if !i.started || i.gitWorktree == nil {
+			return fmt.Errorf("instance not started")
+		}
+		if _, err := i.gitWorktree.ResolveRef(ref); err != nil {
+			return err
+		}
+		i.diffRef = ref
+	}
+	i.diffScope = scope
+	return i.UpdateDiffStats()
+}
+
+// CycleDiffScope switches to the next diff scope. The ref scope is only part of the cycle once a ref was set.
+func (i *Instance) CycleDiffScope() error {
+	next := i.diffScope + 1
+	if next > DiffScopeRef || (next == DiffScopeRef && i.diffRef == "") {
+		next = DiffScopeSessionStart
+	}
+	return i.SetDiffScope(next, i.diffRef)
+}
+
+// GetScopedDiffStats returns the diff of the instance in its current scope. Unlike GetDiffStats, which always
+// covers all changes since the session started, this is what the diff tab displays.
+func (i *Instance) GetScopedDiffStats() *git.DiffStats {
+	if i.diffScope == DiffScopeSessionStart || i.scopedDiffStats == nil {
+		return i.diffStats
+	}
+	return i.scopedDiffStats
+}
+
+// updateScopedDiffStats recomputes the diff for the current scope if it differs from the session start.
+func (i *Instance) updateScopedDiffStats() {
+	switch i.diffScope {
+	case DiffScopeUncommitted:
+		i.scopedDiffStats = i.gitWorktree.DiffAgainst("HEAD")
+	case DiffScopeLastTurn:
+		if i.turnSnapshot == "" {
+			// The agent has not finished a turn since we started tracking, so its only turn is the whole session.
+			i.scopedDiffStats = i.diffStats
+			return
+		}
+		i.scopedDiffStats = i.gitWorktree.DiffAgainst(i.turnSnapshot)
+	case DiffScopeRef:
+		i.scopedDiffStats = i.gitWorktree.DiffAgainst(i.diffRef)
+	default:
+		i.scopedDiffStats = nil
+	}
+}
+
+// snapshotTurn records the current state of the worktree as the start of a new agent turn.
+func (i *Instance) snapshotTurn() {
+	if !i.started || i.gitWorktree == nil {
+		return
+	}
+	tree, err := i.gitWorktree.SnapshotTree()
+	if err != nil {
+		log.WarningLog.Printf("could not snapshot agent turn: %v", err)
+		return
+	}
+	i.turnSnapshot = tree
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxFanOut limits how many instances a single fan-out creates.
+const maxFanOut = 10
+
+// ParsePrograms parses the programs of a fan-out, a comma separated list like "claude, aider x2". A program
+// followed by xN is repeated N times.
+func ParsePrograms(text string) ([]string, error) {
+	var programs []string
+	for _, item := range strings.Split(text, ",") {
+		program := strings.TrimSpace(item)
+		if program == "" {
+			continue
+		}
+		count := 1
+		if fields := strings.Fields(program); len(fields) > 1 {
+			last := fields[len(fields)-1]
+			if n, err := strconv.Atoi(strings.TrimPrefix(last, "x")); strings.HasPrefix(last, "x") && err == nil {
+				if n < 1 {
+					return nil, fmt.Errorf("invalid count %s of %s", last, program)
+				}
+				count = n
+				program = strings.TrimSpace(strings.TrimSuffix(program, last))
+			}
+		}
+		for j := 0; j < count; j++ {
+			programs = append(programs, program)
+		}
+	}
+	if len(programs) == 0 {
+		return nil, fmt.Errorf("no programs given")
+	}
+	if len(programs) > maxFanOut {
+		return nil, fmt.Errorf("can't fan out to more than %d instances", maxFanOut)
+	}
+	return programs, nil
+}
+
+// FanOut returns new instances, which aren't started yet, one for each program, in a group named after the title.
+// Their worktrees start at the current HEAD of the repository at path, so that their results can be compared. The
+// titles are the title followed by the name of the program, and a number if the program repeats.
+func FanOut(path, title string, programs []string) ([]*Instance, error) {
+	base, err := git.HeadCommit(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]int)
+	for _, program := range programs {
+		names[programName(program)]++
+	}
+	seen := make(map[string]int)
+	var instances []*Instance
+	for _, program := range programs {
+		name := programName(program)
+		suffix := "-" + name
+		if names[name] > 1 {
+			seen[name]++
+			suffix += "-" + strconv.Itoa(seen[name])
+		}
+		instance, err := NewInstance(InstanceOptions{
+			Title:   title[:max(min(len(title), MaxTitleLength-len(suffix)), 0)] + suffix,
+			Path:    path,
+			Program: program,
+		})
+		if err != nil {
+			return nil, err
+		}
+		instance.Group = title
+		instance.startPoint = &git.StartPoint{Base: base}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// programName returns the name of the executable of the program, like aider for "aider --model x".
+func programName(program string) string {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return "agent"
+	}
+	return filepath.Base(fields[0])
+}
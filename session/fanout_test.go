@@ -0,0 +1,56 @@
+package session
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrograms(t *testing.T) {
+	programs, err := ParsePrograms("claude x2, aider --model gpt-4o, codex x1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"claude", "claude", "aider --model gpt-4o", "codex"}, programs)
+
+	// A lone word starting with x is a program, not a count.
+	programs, err = ParsePrograms("x2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x2"}, programs)
+
+	for _, text := range []string{"", " , ", "claude x0", "claude x11"} {
+		_, err := ParsePrograms(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	repo := t.TempDir()
+	runGit := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	runGit("init", "-b", "main")
+	runGit("-c", "user.email=test@example.com", "-c", "user.name=Test User",
+		"commit", "--allow-empty", "-m", "initial commit")
+
+	instances, err := FanOut(repo, "a-rather-long-best-of-n-title", []string{"claude", "claude", "/usr/bin/aider -v"})
+	require.NoError(t, err)
+	var titles []string
+	for _, instance := range instances {
+		titles = append(titles, instance.Title)
+		assert.LessOrEqual(t, len(instance.Title), MaxTitleLength)
+		assert.Equal(t, "a-rather-long-best-of-n-title", instance.Group)
+		require.NotNil(t, instance.startPoint)
+		assert.Equal(t, runGit("rev-parse", "HEAD"), instance.startPoint.Base)
+		assert.False(t, instance.Started())
+	}
+	assert.Equal(t, []string{
+		"a-rather-long-best-of-n-claude-1",
+		"a-rather-long-best-of-n-claude-2",
+		"a-rather-long-best-of-n-ti-aider",
+	}, titles)
+	assert.Equal(t, "/usr/bin/aider -v", instances[2].Program)
+}
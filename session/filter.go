@@ -0,0 +1,96 @@
+package session
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fields which a filter term can be limited to, like "tag:api".
+var filterFields = []string{"tag", "group", "repo", "status", "program"}
+
+// Filter selects instances. See ParseFilter.
+type Filter struct {
+	terms []filterTerm
+}
+
+type filterTerm struct {
+	// field is one of filterFields, or empty to match any of them and the title.
+	field string
+	value string
+}
+
+// ParseFilter parses a filter like "tag:api status:ready login". A term with a field (tag, group, repo, status or
+// program) matches that field, other terms match the title, the tags, the group, the repository or the program.
+// Instances match if they match every term. Terms match case-insensitively by substring, except for the status
+// which has to be one of running, ready, loading or paused.
+func ParseFilter(text string) Filter {
+	var filter Filter
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		term := filterTerm{value: word}
+		if field, value, ok := strings.Cut(word, ":"); ok {
+			for _, known := range filterFields {
+				if field == known {
+					term = filterTerm{field: field, value: value}
+					break
+				}
+			}
+		}
+		// A field without a value is still being typed, so it matches everything.
+		if term.value != "" {
+			filter.terms = append(filter.terms, term)
+		}
+	}
+	return filter
+}
+
+// Empty returns true if the filter matches every instance.
+func (f Filter) Empty() bool {
+	return len(f.terms) == 0
+}
+
+// Match returns true if the instance matches every term of the filter.
+func (f Filter) Match(i *Instance) bool {
+	repo := ""
+	if i.Started() {
+		repo, _ = i.RepoName()
+	}
+	for _, term := range f.terms {
+		contains := func(s string) bool { return strings.Contains(strings.ToLower(s), term.value) }
+		tagged := false
+		for _, tag := range i.Tags {
+			tagged = tagged || contains(tag)
+		}
+		var ok bool
+		switch term.field {
+		case "tag":
+			ok = tagged
+		case "group":
+			ok = contains(i.Group)
+		case "repo":
+			ok = contains(repo)
+		case "status":
+			ok = i.Status.String() == term.value
+		case "program":
+			ok = contains(i.Program)
+		default:
+			ok = contains(i.Title) || tagged || contains(i.Group) || contains(repo) || contains(i.Program)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTags splits text at whitespace and commas into tags, dropping duplicates.
+func ParseTags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
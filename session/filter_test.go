@@ -0,0 +1,51 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	instance, err := FromInstanceData(InstanceData{
+		Title:    "login-form",
+		Status:   Paused,
+		Program:  "claude",
+		Group:    "Frontend",
+		Tags:     []string{"ui", "urgent"},
+		Worktree: GitWorktreeData{RepoPath: "/src/webapp"},
+	})
+	require.NoError(t, err)
+
+	for filter, match := range map[string]bool{
+		"":                           true,
+		"login":                      true,
+		"LOGIN":                      true,
+		"urgent":                     true,
+		"webapp":                     true,
+		"api":                        false,
+		"tag:ui":                     true,
+		"tag:login":                  false,
+		"group:front":                true,
+		"repo:web":                   true,
+		"repo:api":                   false,
+		"program:claude":             true,
+		"program:aider":              false,
+		"status:paused":              true,
+		"status:pause":               false,
+		"status:ready":               false,
+		"tag: login":                 true,
+		"tag:ui group:frontend form": true,
+		"tag:ui status:ready":        false,
+		"unknown:field":              false,
+	} {
+		assert.Equal(t, match, ParseFilter(filter).Match(instance), filter)
+	}
+	assert.True(t, ParseFilter("  tag: ").Empty())
+}
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"api", "urgent", "v2"}, ParseTags(" api, urgent,,v2 api\nurgent"))
+	assert.Nil(t, ParseTags(" , "))
+}
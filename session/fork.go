@@ -0,0 +1,56 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"slices"
+)
+
+// Duplicate returns a new instance, which isn't started yet, with the program, prompt, group and tags of this one.
+// Its worktree starts at the same base commit, so the prompt can be tried again from scratch. The prompt isn't sent
+// by starting the instance.
+func (i *Instance) Duplicate(title string) (*Instance, error) {
+	if !i.started {
+		return nil, fmt.Errorf("cannot duplicate instance that has not been started")
+	}
+	duplicate, err := i.copy(title)
+	if err != nil {
+		return nil, err
+	}
+	// Instances stored before the base commit was tracked start at the HEAD of the repository.
+	if base := i.gitWorktree.GetBaseCommitSHA(); base != "" {
+		duplicate.startPoint = &git.StartPoint{Base: base}
+	}
+	return duplicate, nil
+}
+
+// Fork returns a new instance, which isn't started yet, whose worktree branches from the HEAD of this one and
+// starts with its uncommitted changes, so that two agents can try different approaches from the same point.
+func (i *Instance) Fork(title string) (*Instance, error) {
+	if !i.started {
+		return nil, fmt.Errorf("cannot fork instance that has not been started")
+	}
+	start, err := i.gitWorktree.ForkPoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork %s: %w", i.Title, err)
+	}
+	fork, err := i.copy(title)
+	if err != nil {
+		return nil, err
+	}
+	fork.startPoint = &start
+	return fork, nil
+}
+
+// copy returns a new instance with the settings of this one.
+func (i *Instance) copy(title string) (*Instance, error) {
+	instance, err := NewInstance(InstanceOptions{Title: title, Path: i.Path, Program: i.Program})
+	if err != nil {
+		return nil, err
+	}
+	instance.AutoYes = i.AutoYes
+	instance.Prompt = i.Prompt
+	instance.Group = i.Group
+	instance.Tags = slices.Clone(i.Tags)
+	return instance, nil
+}
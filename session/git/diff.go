@@ -1,6 +1,12 @@
 package git
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +18,8 @@ type DiffStats struct {
 	Added int
 	// Removed is the number of removed lines
 	Removed int
+	// Files is the diff content parsed into files and hunks
+	Files []FileDiff
 	// Error holds any error that occurred during diff computation
 	// This allows propagating setup errors (like missing base commit) without breaking the flow
 	Error error
@@ -21,31 +29,335 @@ func (d *DiffStats) IsEmpty() bool {
 	return d.Added == 0 && d.Removed == 0 && d.Content == ""
 }
 
+// ParsedFiles returns the structured per-file diff. If the stats were created without parsing the content
+// (e.g. when restored from storage), the content is parsed on demand.
+func (d *DiffStats) ParsedFiles() []FileDiff {
+	if d.Files == nil && d.Content != "" {
+		return ParseDiff(d.Content)
+	}
+	return d.Files
+}
+
+// TouchedFiles returns the paths of all files that appear in the diff. Renamed and deleted files
+// are reported under their old path as well, since another session may still be editing it.
+func (d *DiffStats) TouchedFiles() []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path == "" || seen[path] {
+			return
+		}
+		seen[path] = true
+		files = append(files, path)
+	}
+
+	for _, file := range d.ParsedFiles() {
+		if !file.IsNew {
+			add(file.OldPath)
+		}
+		if !file.IsDeleted {
+			add(file.NewPath)
+		}
+	}
+	return files
+}
+
+// DiffLineKind is the kind of a line inside a hunk.
+type DiffLineKind int
+
+const (
+	// DiffLineContext is an unchanged line shown for context.
+	DiffLineContext DiffLineKind = iota
+	// DiffLineAdded is a line that was added.
+	DiffLineAdded
+	// DiffLineRemoved is a line that was removed.
+	DiffLineRemoved
+	// DiffLineNoNewline is the "\ No newline at end of file" marker.
+	DiffLineNoNewline
+)
+
+// DiffLine is a single line inside a hunk.
+type DiffLine struct {
+	Kind DiffLineKind
+	// Content is the line without its leading +, - or space.
+	Content string
+	// OldLine is the line number in the old file. It is 0 for added lines.
+	OldLine int
+	// NewLine is the line number in the new file. It is 0 for removed lines.
+	NewLine int
+}
+
+// String returns the line as it appears in a unified diff.
+func (l DiffLine) String() string {
+	switch l.Kind {
+	case DiffLineAdded:
+		return "+" + l.Content
+	case DiffLineRemoved:
+		return "-" + l.Content
+	case DiffLineNoNewline:
+		return l.Content
+	default:
+		return " " + l.Content
+	}
+}
+
+// Hunk is a contiguous block of changes within a file.
+type Hunk struct {
+	// Header is the full "@@ -a,b +c,d @@ section" line.
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []DiffLine
+	Added    int
+	Removed  int
+}
+
+// FileDiff holds the changes to a single file.
+type FileDiff struct {
+	// OldPath is the path before the change, relative to the repository root.
+	OldPath string
+	// NewPath is the path after the change, relative to the repository root.
+	NewPath string
+	// Header holds the raw header lines from "diff --git" up to the first hunk.
+	Header    []string
+	Hunks     []Hunk
+	Added     int
+	Removed   int
+	IsNew     bool
+	IsDeleted bool
+	IsBinary  bool
+}
+
+// Path returns the path that best identifies the file: the new path, or the old path if the file was deleted.
+func (f *FileDiff) Path() string {
+	if f.IsDeleted || f.NewPath == "" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// IsRename returns true if the file was moved.
+func (f *FileDiff) IsRename() bool {
+	return !f.IsNew && !f.IsDeleted && f.OldPath != "" && f.NewPath != "" && f.OldPath != f.NewPath
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseDiff parses the output of git diff into files and hunks.
+func ParseDiff(content string) []FileDiff {
+	files := []FileDiff{}
+	var file *FileDiff
+	var hunk *Hunk
+	oldLine, newLine := 0, 0
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			files = append(files, FileDiff{})
+			file = &files[len(files)-1]
+			hunk = nil
+			file.Header = append(file.Header, line)
+			file.OldPath, file.NewPath = parseDiffGitPaths(line)
+			continue
+		}
+		if file == nil {
+			continue
+		}
+
+		if matches := hunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+			file.Hunks = append(file.Hunks, Hunk{
+				Header:   line,
+				OldStart: atoiDefault(matches[1], 0),
+				OldLines: atoiDefault(matches[2], 1),
+				NewStart: atoiDefault(matches[3], 0),
+				NewLines: atoiDefault(matches[4], 1),
+			})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+
+		if hunk == nil {
+			parseFileHeaderLine(file, line)
+			continue
+		}
+
+		if len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case '+':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineAdded, Content: line[1:], NewLine: newLine})
+			newLine++
+			hunk.Added++
+			file.Added++
+		case '-':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineRemoved, Content: line[1:], OldLine: oldLine})
+			oldLine++
+			hunk.Removed++
+			file.Removed++
+		case ' ':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineContext, Content: line[1:], OldLine: oldLine, NewLine: newLine})
+			oldLine++
+			newLine++
+		case '\\':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineNoNewline, Content: line})
+		}
+	}
+	return files
+}
+
+// parseFileHeaderLine fills in the file metadata from a header line between "diff --git" and the first hunk.
+func parseFileHeaderLine(file *FileDiff, line string) {
+	file.Header = append(file.Header, line)
+	switch {
+	case strings.HasPrefix(line, "--- "):
+		path := unquotePath(strings.TrimPrefix(line, "--- "))
+		if path == "/dev/null" {
+			file.IsNew = true
+		} else {
+			file.OldPath = strings.TrimPrefix(path, "a/")
+		}
+	case strings.HasPrefix(line, "+++ "):
+		path := unquotePath(strings.TrimPrefix(line, "+++ "))
+		if path == "/dev/null" {
+			file.IsDeleted = true
+		} else {
+			file.NewPath = strings.TrimPrefix(path, "b/")
+		}
+	case strings.HasPrefix(line, "new file mode"):
+		file.IsNew = true
+	case strings.HasPrefix(line, "deleted file mode"):
+		file.IsDeleted = true
+	case strings.HasPrefix(line, "rename from "):
+		file.OldPath = strings.TrimPrefix(line, "rename from ")
+	case strings.HasPrefix(line, "rename to "):
+		file.NewPath = strings.TrimPrefix(line, "rename to ")
+	case strings.HasPrefix(line, "Binary files "):
+		file.IsBinary = true
+	}
+}
+
+// parseDiffGitPaths extracts the paths from a "diff --git a/x b/y" line. They are only used as a fallback
+// for files without ---/+++ lines, like binary files or pure mode changes.
+func parseDiffGitPaths(line string) (oldPath, newPath string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	idx := strings.LastIndex(rest, " b/")
+	if idx < 0 {
+		return "", ""
+	}
+	return strings.TrimPrefix(unquotePath(rest[:idx]), "a/"), unquotePath(rest[idx+1:])[2:]
+}
+
+func unquotePath(path string) string {
+	path = strings.TrimSuffix(path, "\t")
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
 // Diff returns the git diff between the worktree and the base branch along with statistics
 func (g *GitWorktree) Diff() *DiffStats {
+	if g.GetBaseCommitSHA() == "" {
+		return &DiffStats{Error: fmt.Errorf("base commit SHA not set")}
+	}
+	return g.DiffAgainst(g.GetBaseCommitSHA())
+}
+
+// DiffAgainst returns the git diff between the files in the worktree, including untracked files, and the given
+// tree-ish along with statistics. The changes are staged in a temporary index, so the index of the worktree is
+// never modified.
+func (g *GitWorktree) DiffAgainst(treeish string) *DiffStats {
 	stats := &DiffStats{}
 
-	// -N stages untracked files (intent to add), including them in the diff
-	_, err := g.runGitCommand(g.worktreePath, "add", "-N", ".")
+	content, err := g.withTemporaryIndex(func(env []string) (string, error) {
+		return g.runGitCommandWithEnv(g.worktreePath, env, "--no-pager", "diff", "--cached", treeish)
+	})
 	if err != nil {
 		stats.Error = err
 		return stats
 	}
+	stats.Content = content
+	stats.Files = ParseDiff(content)
+	for _, file := range stats.Files {
+		stats.Added += file.Added
+		stats.Removed += file.Removed
+	}
+
+	return stats
+}
+
+// SnapshotTree writes the current files in the worktree, including untracked files, to a tree object and returns
+// its hash. Like DiffAgainst, it does not modify the index of the worktree.
+func (g *GitWorktree) SnapshotTree() (string, error) {
+	tree, err := g.withTemporaryIndex(func(env []string) (string, error) {
+		return g.runGitCommandWithEnv(g.worktreePath, env, "write-tree")
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot worktree: %w", err)
+	}
+	return strings.TrimSpace(tree), nil
+}
 
-	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", g.GetBaseCommitSHA())
+// ResolveRef returns the commit hash of the given ref in the worktree, or an error if it does not exist.
+func (g *GitWorktree) ResolveRef(ref string) (string, error) {
+	sha, err := g.runGitCommand(g.worktreePath, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
 	if err != nil {
-		stats.Error = err
-		return stats
+		return "", fmt.Errorf("unknown ref %q", ref)
 	}
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-			stats.Added++
-		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-			stats.Removed++
-		}
+	return strings.TrimSpace(sha), nil
+}
+
+// withTemporaryIndex copies the index of the worktree to a temporary file, stages all changes in it and calls fn
+// with the environment that points git at it. Copying the real index keeps its stat cache, so unchanged files
+// don't have to be hashed again.
+func (g *GitWorktree) withTemporaryIndex(fn func(env []string) (string, error)) (string, error) {
+	indexPath, err := g.runGitCommand(g.worktreePath, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	indexPath = strings.TrimSpace(indexPath)
+	if !filepath.IsAbs(indexPath) {
+		indexPath = filepath.Join(g.worktreePath, indexPath)
 	}
-	stats.Content = content
 
-	return stats
+	tmp, err := os.CreateTemp("", "claude-squad-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	index, err := os.Open(indexPath)
+	if err == nil {
+		_, err = io.Copy(tmp, index)
+		index.Close()
+	} else if os.IsNotExist(err) {
+		// A worktree without an index is valid, git starts from an empty one.
+		err = nil
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to copy index: %w", err)
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + tmp.Name()}
+	if _, err := g.runGitCommandWithEnv(g.worktreePath, env, "add", "-A"); err != nil {
+		return "", err
+	}
+	return fn(env)
 }
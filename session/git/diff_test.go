@@ -0,0 +1,128 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@ package main
+ package main
+-import "fmt"
++import "os"
+ 
+ func main() {}
+@@ -10,2 +10,3 @@ func helper() {
+ 	a := 1
++	b := 2
+ }
+\ No newline at end of file
+diff --git a/old.txt b/new.txt
+similarity index 90%
+rename from old.txt
+rename to new.txt
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/image.png b/image.png
+new file mode 100644
+Binary files /dev/null and b/image.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(testDiff)
+	require.Len(t, files, 4)
+
+	main := files[0]
+	assert.Equal(t, "main.go", main.Path())
+	assert.Equal(t, 2, main.Added)
+	assert.Equal(t, 1, main.Removed)
+	require.Len(t, main.Hunks, 2)
+	assert.Equal(t, 10, main.Hunks[1].NewStart)
+	assert.Equal(t, 3, main.Hunks[1].NewLines)
+	assert.Equal(t, DiffLine{Kind: DiffLineAdded, Content: "\tb := 2", NewLine: 11}, main.Hunks[1].Lines[1])
+	assert.Equal(t, DiffLineNoNewline, main.Hunks[1].Lines[3].Kind)
+	assert.Equal(t, DiffLine{Kind: DiffLineRemoved, Content: `import "fmt"`, OldLine: 2}, main.Hunks[0].Lines[1])
+
+	rename := files[1]
+	assert.True(t, rename.IsRename())
+	assert.Equal(t, "old.txt", rename.OldPath)
+	assert.Equal(t, "new.txt", rename.Path())
+
+	deleted := files[2]
+	assert.True(t, deleted.IsDeleted)
+	assert.Equal(t, "gone.txt", deleted.Path())
+
+	binary := files[3]
+	assert.True(t, binary.IsBinary)
+	assert.True(t, binary.IsNew)
+	assert.Equal(t, "image.png", binary.Path())
+}
+
+func TestTouchedFiles(t *testing.T) {
+	stats := &DiffStats{Content: testDiff}
+	assert.Equal(t, []string{"main.go", "old.txt", "new.txt", "gone.txt", "image.png"}, stats.TouchedFiles())
+}
+
+func TestFileDiffPatch(t *testing.T) {
+	files := ParseDiff(testDiff)
+	require.NotEmpty(t, files)
+
+	assert.Equal(t, `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,2 +10,3 @@ func helper() {
+ 	a := 1
++	b := 2
+ }
+\ No newline at end of file
+`, files[0].Patch(1))
+
+	assert.Equal(t, testDiff[:len(files[0].Patch())], files[0].Patch(), "all hunks reproduce the original diff")
+}
+
+func TestDiffAgainstKeepsIndex(t *testing.T) {
+	dir := t.TempDir()
+	runGit := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	runGit("init")
+	runGit("config", "--local", "user.email", "test@example.com")
+	runGit("config", "--local", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0644))
+	runGit("add", "a.txt")
+	runGit("commit", "-m", "initial commit")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0644))
+	status := runGit("status", "--porcelain")
+
+	worktree := NewGitWorktreeFromStorage(dir, dir, "test", "test", "HEAD")
+	stats := worktree.DiffAgainst("HEAD")
+	require.NoError(t, stats.Error)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, stats.TouchedFiles())
+	assert.Equal(t, 2, stats.Added)
+	assert.Equal(t, 1, stats.Removed)
+	assert.Equal(t, status, runGit("status", "--porcelain"), "the index is not modified")
+
+	tree, err := worktree.SnapshotTree()
+	require.NoError(t, err)
+	assert.True(t, worktree.DiffAgainst(tree).IsEmpty())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("newer\n"), 0644))
+	assert.Equal(t, []string{"b.txt"}, worktree.DiffAgainst(tree).TouchedFiles())
+}
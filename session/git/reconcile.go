@@ -0,0 +1,113 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Worktree is a worktree registered in a repository.
+type Worktree struct {
+	Path string
+	// Branch is empty for a detached HEAD.
+	Branch string
+	// Prunable is true if the directory of the worktree is gone.
+	Prunable bool
+}
+
+// WorktreeDirectory returns the directory in which the worktrees of the instances are created.
+func WorktreeDirectory() (string, error) {
+	return getWorktreeDirectory()
+}
+
+// runGit runs git in the directory and returns its output.
+func runGit(dir string, args ...string) (string, error) {
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s (%w)", args[0], strings.TrimSpace(string(output)), err)
+	}
+	return string(output), nil
+}
+
+// ListWorktrees returns the worktrees registered in the repository, except for its main worktree.
+func ListWorktrees(repoPath string) ([]Worktree, error) {
+	output, err := runGit(repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var worktrees []Worktree
+	// Every worktree is a block of lines, the first one is the main worktree.
+	for i, block := range strings.Split(strings.TrimSpace(output), "\n\n") {
+		if i == 0 {
+			continue
+		}
+		var worktree Worktree
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "worktree "):
+				worktree.Path = strings.TrimPrefix(line, "worktree ")
+			case strings.HasPrefix(line, "branch "):
+				worktree.Branch = strings.TrimPrefix(line, "branch refs/heads/")
+			case line == "prunable" || strings.HasPrefix(line, "prunable "):
+				worktree.Prunable = true
+			}
+		}
+		worktrees = append(worktrees, worktree)
+	}
+	return worktrees, nil
+}
+
+// RepoOfWorktree returns the repository to which the worktree in the directory belongs.
+func RepoOfWorktree(path string) (string, error) {
+	output, err := runGit(path, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	commonDir := strings.TrimSpace(output)
+	if filepath.Base(commonDir) == ".git" {
+		return filepath.Dir(commonDir), nil
+	}
+	// A bare repository.
+	return commonDir, nil
+}
+
+// BranchExists returns true if the branch exists in the repository.
+func BranchExists(repoPath, branch string) bool {
+	_, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// UnpushedCommits returns the number of commits of the branch which are neither on a remote nor on another local
+// branch, and would be lost by deleting it. The branches in deleted don't count as other branches, since they are
+// about to be deleted too: two branches sharing their commits would otherwise both seem safe to delete.
+func UnpushedCommits(repoPath, branch string, deleted []string) (int, error) {
+	args := []string{"rev-list", "--count", "refs/heads/" + branch, "--not", "--exclude=" + branch}
+	for _, name := range deleted {
+		args = append(args, "--exclude="+name)
+	}
+	output, err := runGit(repoPath, append(args, "--branches", "--remotes")...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(output))
+}
+
+// RemoveWorktree removes a worktree from the repository, including changes which weren't committed.
+func RemoveWorktree(repoPath, path string) error {
+	_, err := runGit(repoPath, "worktree", "remove", "--force", path)
+	return err
+}
+
+// PruneWorktrees removes the worktrees of the repository whose directory is gone.
+func PruneWorktrees(repoPath string) error {
+	_, err := runGit(repoPath, "worktree", "prune")
+	return err
+}
+
+// DeleteBranch deletes a branch of the repository, even if it wasn't merged.
+func DeleteBranch(repoPath, branch string) error {
+	_, err := runGit(repoPath, "branch", "-D", branch)
+	return err
+}
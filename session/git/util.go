@@ -67,7 +67,8 @@ func IsGitRepo(path string) bool {
 	}
 }
 
-func findGitRepoRoot(path string) (string, error) {
+// FindRepoRoot returns the root of the repository which contains path.
+func FindRepoRoot(path string) (string, error) {
 	currentPath := path
 	for {
 		_, err := git.PlainOpen(currentPath)
@@ -29,6 +29,8 @@ type GitWorktree struct {
 	branchName string
 	// Base commit hash for the worktree
 	baseCommitSHA string
+	// startPoint is where a new worktree starts instead of the HEAD of the repository. May be nil.
+	startPoint *StartPoint
 }
 
 func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string) *GitWorktree {
@@ -43,9 +45,8 @@ func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName
 
 // NewGitWorktree creates a new GitWorktree instance
 func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, branchname string, err error) {
-	cfg := config.LoadConfig()
 	sanitizedName := sanitizeBranchName(sessionName)
-	branchName := fmt.Sprintf("%s%s", cfg.BranchPrefix, sanitizedName)
+	branchName := BranchName(sessionName)
 
 	// Convert repoPath to absolute path
 	absPath, err := filepath.Abs(repoPath)
@@ -55,7 +56,7 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 		absPath = repoPath
 	}
 
-	repoPath, err = findGitRepoRoot(absPath)
+	repoPath, err = FindRepoRoot(absPath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -76,6 +77,12 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 	}, branchName, nil
 }
 
+// BranchName returns the name of the branch of a new worktree for the session.
+func BranchName(sessionName string) string {
+	cfg := config.LoadConfig()
+	return fmt.Sprintf("%s%s", cfg.BranchPrefix, sanitizeBranchName(sessionName))
+}
+
 // GetWorktreePath returns the path to the worktree
 func (g *GitWorktree) GetWorktreePath() string {
 	return g.worktreePath
@@ -2,7 +2,9 @@ package git
 
 import (
 	"claude-squad/log"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 )
@@ -20,6 +22,20 @@ func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error)
 	return string(output), nil
 }
 
+// runGitCommandWithEnv executes a git command with additional environment variables and returns any error
+func (g *GitWorktree) runGitCommandWithEnv(path string, env []string, args ...string) (string, error) {
+	baseArgs := []string{"-C", path}
+	cmd := exec.Command("git", append(baseArgs, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %s (%w)", output, err)
+	}
+
+	return string(output), nil
+}
+
 // PushChanges commits and pushes changes in the worktree to the remote branch
 func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 	if err := checkGHCLI(); err != nil {
@@ -121,6 +137,31 @@ func (g *GitWorktree) IsBranchCheckedOut() (bool, error) {
 	return strings.TrimSpace(string(output)) == g.branchName, nil
 }
 
+// IsMerged checks if the instance branch was merged into the HEAD of the repository or into the default branch of
+// origin. Squash and rebase merges aren't detected, since they don't keep the commits of the branch.
+func (g *GitWorktree) IsMerged() (bool, error) {
+	if _, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+g.branchName); err != nil {
+		return false, fmt.Errorf("branch %s does not exist", g.branchName)
+	}
+	targets := []string{"HEAD"}
+	if _, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/HEAD"); err == nil {
+		targets = append(targets, "refs/remotes/origin/HEAD")
+	}
+	for _, target := range targets {
+		cmd := exec.Command("git", "-C", g.repoPath, "merge-base", "--is-ancestor", "refs/heads/"+g.branchName, target)
+		err := cmd.Run()
+		if err == nil {
+			return true, nil
+		}
+		// Exit code 1 means the branch isn't an ancestor, anything else is an error.
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return false, fmt.Errorf("failed to check if branch %s was merged into %s: %w", g.branchName, target, err)
+		}
+	}
+	return false, nil
+}
+
 // OpenBranchURL opens the branch URL in the default browser
 func (g *GitWorktree) OpenBranchURL() error {
 	// Check if GitHub CLI is available
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runGitCommandWithInput executes a git command with the given input on stdin and returns its output
+func (g *GitWorktree) runGitCommandWithInput(path string, input string, args ...string) (string, error) {
+	baseArgs := []string{"-C", path}
+	cmd := exec.Command("git", append(baseArgs, args...)...)
+	cmd.Stdin = strings.NewReader(input)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %s (%w)", output, err)
+	}
+
+	return string(output), nil
+}
+
+// Patch returns a patch containing the file header and only the given hunks, suitable for git apply.
+// If no hunks are given, all hunks are included.
+func (f *FileDiff) Patch(hunks ...int) string {
+	if len(hunks) == 0 {
+		for i := range f.Hunks {
+			hunks = append(hunks, i)
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range f.Header {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for _, idx := range hunks {
+		if idx < 0 || idx >= len(f.Hunks) {
+			continue
+		}
+		hunk := f.Hunks[idx]
+		b.WriteString(hunk.Header)
+		b.WriteString("\n")
+		for _, line := range hunk.Lines {
+			b.WriteString(line.String())
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// RevertPatch reverse-applies the patch to the files in the worktree, discarding those changes.
+func (g *GitWorktree) RevertPatch(patch string) error {
+	if _, err := g.runGitCommandWithInput(g.worktreePath, patch, "apply", "-R", "--whitespace=nowarn", "-"); err != nil {
+		return fmt.Errorf("failed to revert changes: %w", err)
+	}
+	return nil
+}
+
+// StagePatch applies the patch to the index of the worktree without touching the files.
+func (g *GitWorktree) StagePatch(patch string) error {
+	if _, err := g.runGitCommandWithInput(g.worktreePath, patch, "apply", "--cached", "--whitespace=nowarn", "-"); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	return nil
+}
+
+// CommitStaged commits only the changes which are currently staged in the worktree.
+func (g *GitWorktree) CommitStaged(commitMessage string) error {
+	output, err := g.runGitCommand(g.worktreePath, "diff", "--cached", "--name-only")
+	if err != nil {
+		return fmt.Errorf("failed to check staged changes: %w", err)
+	}
+	if strings.TrimSpace(output) == "" {
+		return fmt.Errorf("no staged changes to commit")
+	}
+
+	if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
+		return fmt.Errorf("failed to commit staged changes: %w", err)
+	}
+	return nil
+}
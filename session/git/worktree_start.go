@@ -0,0 +1,89 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StartPoint is where a new worktree starts instead of the HEAD of the repository. See SetStartPoint.
+type StartPoint struct {
+	// Base is the commit which the changes in the worktree are compared against.
+	Base string
+	// Head is the commit at which the branch of the worktree starts. Empty means Base.
+	Head string
+	// Tree is a snapshot of the files, see SnapshotTree, which the worktree starts with as uncommitted changes.
+	// Empty means the files of Head.
+	Tree string
+}
+
+func (p *StartPoint) head() string {
+	if p.Head == "" {
+		return p.Base
+	}
+	return p.Head
+}
+
+// HeadCommit returns the commit checked out in the repository at path.
+func HeadCommit(path string) (string, error) {
+	head, err := runGit(path, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD commit hash: %w", err)
+	}
+	return strings.TrimSpace(head), nil
+}
+
+// SetStartPoint makes Setup create a new branch at the start point instead of the HEAD of the repository. It has
+// no effect if the branch already exists.
+func (g *GitWorktree) SetStartPoint(start StartPoint) {
+	g.startPoint = &start
+}
+
+// ForkPoint returns the start point of a worktree which continues from the current state of this one. Its branch
+// starts at the HEAD of this worktree with the same uncommitted changes, and its changes are compared against the
+// same base. Without the worktree, like for a paused session, it starts at the branch.
+func (g *GitWorktree) ForkPoint() (StartPoint, error) {
+	var start StartPoint
+	if _, err := os.Stat(g.worktreePath); err == nil {
+		head, err := g.runGitCommand(g.worktreePath, "rev-parse", "HEAD")
+		if err != nil {
+			return start, fmt.Errorf("failed to get HEAD commit hash: %w", err)
+		}
+		start.Head = strings.TrimSpace(head)
+		if start.Tree, err = g.SnapshotTree(); err != nil {
+			return start, err
+		}
+	} else {
+		head, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "refs/heads/"+g.branchName)
+		if err != nil {
+			return start, fmt.Errorf("failed to get the commit of branch %s: %w", g.branchName, err)
+		}
+		start.Head = strings.TrimSpace(head)
+	}
+	start.Base = g.baseCommitSHA
+	if start.Base == "" {
+		start.Base = start.Head
+	}
+	return start, nil
+}
+
+// restoreTree makes the files of the worktree match the tree, leaving the differences to HEAD as uncommitted
+// changes. New files become untracked ones.
+func (g *GitWorktree) restoreTree(tree string) error {
+	if _, err := g.runGitCommand(g.worktreePath, "read-tree", "-u", "--reset", tree); err != nil {
+		return fmt.Errorf("failed to restore files of tree %s: %w", tree, err)
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "reset", "-q"); err != nil {
+		return fmt.Errorf("failed to reset index: %w", err)
+	}
+	return nil
+}
+
+// RenameBranch renames the branch of the worktree. The worktree, if it exists, stays on the branch.
+func (g *GitWorktree) RenameBranch(name string) error {
+	if _, err := g.runGitCommand(g.repoPath, "branch", "-m", g.branchName, name); err != nil {
+		return fmt.Errorf("failed to rename branch %s to %s: %w", g.branchName, name, err)
+	}
+	g.branchName = name
+	return nil
+}
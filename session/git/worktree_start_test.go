@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForkAndDuplicateStartPoints(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	runGit := func(dir string, args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	runGit(repo, "init", "-b", "main")
+	runGit(repo, "config", "--local", "user.email", "test@example.com")
+	runGit(repo, "config", "--local", "user.name", "Test User")
+	for _, name := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repo, name), []byte(name+"\n"), 0644))
+	}
+	runGit(repo, "add", ".")
+	runGit(repo, "commit", "-m", "initial commit")
+	base := runGit(repo, "rev-parse", "HEAD")
+
+	source, _, err := NewGitWorktree(repo, "source")
+	require.NoError(t, err)
+	require.NoError(t, source.Setup())
+	path := source.GetWorktreePath()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "a.txt"), []byte("committed\n"), 0644))
+	runGit(path, "commit", "-am", "agent commit")
+	require.NoError(t, os.WriteFile(filepath.Join(path, "a.txt"), []byte("uncommitted\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(path, "b.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "c.txt"), []byte("new\n"), 0644))
+	// The main checkout moves on, which neither the fork nor the duplicate follow.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "d.txt"), []byte("d\n"), 0644))
+	runGit(repo, "add", "d.txt")
+	runGit(repo, "commit", "-m", "later commit")
+
+	start, err := source.ForkPoint()
+	require.NoError(t, err)
+	assert.Equal(t, base, start.Base)
+	assert.Equal(t, runGit(path, "rev-parse", "HEAD"), start.Head)
+
+	fork, _, err := NewGitWorktree(repo, "fork")
+	require.NoError(t, err)
+	fork.SetStartPoint(start)
+	require.NoError(t, fork.Setup())
+	assert.Equal(t, base, fork.GetBaseCommitSHA())
+	assert.Equal(t, start.Head, runGit(fork.GetWorktreePath(), "rev-parse", "HEAD"))
+	assert.Equal(t, runGit(path, "status", "--porcelain"), runGit(fork.GetWorktreePath(), "status", "--porcelain"))
+	content, err := os.ReadFile(filepath.Join(fork.GetWorktreePath(), "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "uncommitted\n", string(content))
+
+	duplicate, _, err := NewGitWorktree(repo, "duplicate")
+	require.NoError(t, err)
+	duplicate.SetStartPoint(StartPoint{Base: source.GetBaseCommitSHA()})
+	require.NoError(t, duplicate.Setup())
+	assert.Equal(t, base, runGit(duplicate.GetWorktreePath(), "rev-parse", "HEAD"))
+	assert.Empty(t, runGit(duplicate.GetWorktreePath(), "status", "--porcelain"))
+
+	// A paused session has no worktree, so its fork starts at the branch.
+	require.NoError(t, fork.Remove())
+	start, err = fork.ForkPoint()
+	require.NoError(t, err)
+	assert.Equal(t, StartPoint{Base: base, Head: runGit(path, "rev-parse", "HEAD")}, start)
+
+	oldBranch := source.GetBranchName()
+	require.NoError(t, source.RenameBranch("renamed"))
+	assert.Equal(t, "renamed", source.GetBranchName())
+	assert.Equal(t, "renamed", runGit(path, "branch", "--show-current"))
+	assert.False(t, BranchExists(repo, oldBranch))
+}
@@ -1,9 +1,14 @@
 package session
 
 import (
+	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/session/audit"
 	"claude-squad/session/git"
+	"claude-squad/session/notify"
+	"claude-squad/session/policy"
 	"claude-squad/session/tmux"
+	"claude-squad/session/transcript"
 	"path/filepath"
 
 	"fmt"
@@ -27,6 +32,23 @@ const (
 	Paused
 )
 
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Ready:
+		return "ready"
+	case Loading:
+		return "loading"
+	case Paused:
+		return "paused"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// MaxTitleLength is the maximum length of the title of an instance.
+const MaxTitleLength = 32
+
 // Instance is a running instance of claude code.
 type Instance struct {
 	// Title is the title of the instance.
@@ -49,11 +71,46 @@ type Instance struct {
 	UpdatedAt time.Time
 	// AutoYes is true if the instance should automatically press enter when prompted.
 	AutoYes bool
-	// Prompt is the initial prompt to pass to the instance on startup
+	// Prompt is the first prompt sent to the instance, which a duplicate is started with.
 	Prompt string
+	// Group is the group under which the list shows the instance. Empty means no group.
+	Group string
+	// Tags are labels by which the list can be filtered.
+	Tags []string
 
 	// DiffStats stores the current git diff statistics
 	diffStats *git.DiffStats
+	// reviewComments are the comments on the diff which have not been sent to the agent yet.
+	reviewComments []ReviewComment
+
+	// diffScope and diffRef select what scopedDiffStats is compared against. See DiffScope.
+	diffScope DiffScope
+	diffRef   string
+	// scopedDiffStats is the diff in the current scope. It is nil for DiffScopeSessionStart.
+	scopedDiffStats *git.DiffStats
+	// turnSnapshot is the hash of the tree the worktree had when the agent last started working.
+	turnSnapshot string
+
+	// lastApproval and lastApprovalAt identify the last request answered by the approval policy. See Approve.
+	lastApproval   string
+	lastApprovalAt time.Time
+	// auditActor is recorded in the audit log for prompts and keys sent to the agent. Empty means the actor
+	// of the process.
+	auditActor audit.Actor
+	// setupLog is the output of preparing the worktree, shown while the instance is loading.
+	setupLog setupLog
+	// turnStarted is true while the agent works on a prompt, so that the user is notified when it's done.
+	turnStarted bool
+	// promptNotified is true if the user was notified about the prompt which waits for them.
+	promptNotified bool
+	// lastActivity is when the pane or the diff last changed, and pausedAt is when the instance was paused. See
+	// PlanReaping.
+	lastActivity time.Time
+	pausedAt     time.Time
+	// observed is true once the pane was checked, since the first check always finds a change.
+	observed bool
+	// startPoint is where the worktree of a duplicate or fork starts. See Duplicate and Fork.
+	startPoint *git.StartPoint
 
 	// The below fields are initialized upon calling Start().
 
@@ -77,6 +134,13 @@ func (i *Instance) ToInstanceData() InstanceData {
 		UpdatedAt: time.Now(),
 		Program:   i.Program,
 		AutoYes:   i.AutoYes,
+
+		Prompt:         i.Prompt,
+		Group:          i.Group,
+		Tags:           i.Tags,
+		LastActivity:   i.lastActivity,
+		PausedAt:       i.pausedAt,
+		ReviewComments: i.reviewComments,
 	}
 
 	// Only include worktree data if gitWorktree is initialized
@@ -114,6 +178,10 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		CreatedAt: data.CreatedAt,
 		UpdatedAt: data.UpdatedAt,
 		Program:   data.Program,
+		AutoYes:   data.AutoYes,
+		Prompt:    data.Prompt,
+		Group:     data.Group,
+		Tags:      data.Tags,
 		gitWorktree: git.NewGitWorktreeFromStorage(
 			data.Worktree.RepoPath,
 			data.Worktree.WorktreePath,
@@ -125,7 +193,18 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 			Added:   data.DiffStats.Added,
 			Removed: data.DiffStats.Removed,
 			Content: data.DiffStats.Content,
+			Files:   git.ParseDiff(data.DiffStats.Content),
 		},
+		reviewComments: data.ReviewComments,
+		lastActivity:   data.LastActivity,
+		pausedAt:       data.PausedAt,
+	}
+	// Instances stored before the times were tracked start their clocks now.
+	if instance.lastActivity.IsZero() {
+		instance.lastActivity = time.Now()
+	}
+	if instance.Paused() && instance.pausedAt.IsZero() {
+		instance.pausedAt = time.Now()
 	}
 
 	if instance.Paused() {
@@ -171,6 +250,8 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 		CreatedAt: t,
 		UpdatedAt: t,
 		AutoYes:   false,
+
+		lastActivity: t,
 	}, nil
 }
 
@@ -182,75 +263,182 @@ func (i *Instance) RepoName() (string, error) {
 }
 
 func (i *Instance) SetStatus(status Status) {
+	// The agent starts a new turn when it goes from waiting for input to working. Since the status is polled, the
+	// agent may already have made its first edits by the time we snapshot.
+	if i.Status == Ready && status == Running {
+		i.snapshotTurn()
+		i.turnStarted = true
+	}
+	// Only a turn which the agent finished is notified, not the agent starting up.
+	if i.Status == Running && status == Ready && i.turnStarted {
+		i.turnStarted = false
+		notify.Notify(notify.EventReady, i.Title, fmt.Sprintf("%s is ready", i.Title))
+	}
+	if status == Running {
+		i.promptNotified = false
+	}
 	i.Status = status
 }
 
+// Launch is a new instance which was started in the background. See Instance.Launch.
+type Launch struct {
+	tmuxSession *tmux.TmuxSession
+	gitWorktree *git.GitWorktree
+	branch      string
+}
+
 // firstTimeSetup is true if this is a new instance. Otherwise, it's one loaded from storage.
 func (i *Instance) Start(firstTimeSetup bool) error {
+	if firstTimeSetup {
+		launch, err := i.Launch()
+		if err != nil {
+			return err
+		}
+		i.CompleteLaunch(launch)
+		return nil
+	}
 	if i.Title == "" {
 		return fmt.Errorf("instance title cannot be empty")
 	}
 
-	var tmuxSession *tmux.TmuxSession
-	if i.tmuxSession != nil {
-		// Use existing tmux session (useful for testing)
-		tmuxSession = i.tmuxSession
-	} else {
-		// Create new tmux session
-		tmuxSession = tmux.NewTmuxSession(i.Title, i.Program)
+	if i.tmuxSession == nil {
+		i.tmuxSession = tmux.NewTmuxSession(i.Title, i.Program)
 	}
-	i.tmuxSession = tmuxSession
+	i.setupTranscript(i.tmuxSession, false)
+	// Reuse existing session
+	if err := i.tmuxSession.Restore(); err != nil {
+		return fmt.Errorf("failed to restore existing session: %w", err)
+	}
+	i.started = true
+	i.SetStatus(Running)
+	return nil
+}
 
-	if firstTimeSetup {
-		gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title)
-		if err != nil {
-			return fmt.Errorf("failed to create git worktree: %w", err)
-		}
-		i.gitWorktree = gitWorktree
-		i.Branch = branchName
+// Launch creates the worktree and the tmux session of a new instance and starts the agent, without changing the
+// instance. It's the slow part of Start, so it can run in the background while the instance is shown as loading,
+// and CompleteLaunch then finishes the start.
+func (i *Instance) Launch() (*Launch, error) {
+	if i.Title == "" {
+		return nil, fmt.Errorf("instance title cannot be empty")
 	}
 
-	// Setup error handler to cleanup resources on any error
-	var setupErr error
-	defer func() {
-		if setupErr != nil {
-			if cleanupErr := i.Kill(); cleanupErr != nil {
-				setupErr = fmt.Errorf("%v (cleanup error: %v)", setupErr, cleanupErr)
-			}
-		} else {
-			i.started = true
-		}
-	}()
+	tmuxSession := i.tmuxSession
+	if tmuxSession == nil {
+		tmuxSession = tmux.NewTmuxSession(i.Title, i.Program)
+	}
+	i.setupTranscript(tmuxSession, true)
 
-	if !firstTimeSetup {
-		// Reuse existing session
-		if err := tmuxSession.Restore(); err != nil {
-			setupErr = fmt.Errorf("failed to restore existing session: %w", err)
-			return setupErr
+	gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create git worktree: %w", err)
+	}
+	if i.startPoint != nil {
+		gitWorktree.SetStartPoint(*i.startPoint)
+	}
+
+	if err := gitWorktree.Setup(); err != nil {
+		return nil, fmt.Errorf("failed to setup git worktree: %w", err)
+	}
+	if err := i.prepareWorktree(tmuxSession, gitWorktree); err != nil {
+		if cleanupErr := gitWorktree.Cleanup(); cleanupErr != nil {
+			err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
 		}
-	} else {
-		// Setup git worktree first
-		if err := i.gitWorktree.Setup(); err != nil {
-			setupErr = fmt.Errorf("failed to setup git worktree: %w", err)
-			return setupErr
+		return nil, err
+	}
+	if err := tmuxSession.Start(gitWorktree.GetWorktreePath()); err != nil {
+		// Cleanup git worktree if tmux session creation fails
+		if cleanupErr := gitWorktree.Cleanup(); cleanupErr != nil {
+			err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
 		}
+		return nil, fmt.Errorf("failed to start new session: %w", err)
+	}
+	return &Launch{tmuxSession: tmuxSession, gitWorktree: gitWorktree, branch: branchName}, nil
+}
 
-		// Create new session
-		if err := i.tmuxSession.Start(i.gitWorktree.GetWorktreePath()); err != nil {
-			// Cleanup git worktree if tmux session creation fails
-			if cleanupErr := i.gitWorktree.Cleanup(); cleanupErr != nil {
-				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
-			}
-			setupErr = fmt.Errorf("failed to start new session: %w", err)
-			return setupErr
+// CompleteLaunch makes the instance use the worktree and the tmux session of a successful Launch.
+func (i *Instance) CompleteLaunch(launch *Launch) {
+	i.tmuxSession = launch.tmuxSession
+	i.gitWorktree = launch.gitWorktree
+	i.Branch = launch.branch
+	i.started = true
+	i.SetStatus(Running)
+}
+
+// setupTranscript makes the tmux session record its output to the transcript of the instance. A new instance
+// archives the transcript of an earlier instance with the same title.
+func (i *Instance) setupTranscript(tmuxSession *tmux.TmuxSession, firstTimeSetup bool) {
+	if firstTimeSetup {
+		if err := transcript.Archive(i.Title); err != nil {
+			log.WarningLog.Printf("could not archive old transcript of %s: %v", i.Title, err)
 		}
 	}
+	command, err := transcript.RecordCommand(i.Title)
+	if err != nil {
+		log.WarningLog.Printf("could not record transcript of %s: %v", i.Title, err)
+		return
+	}
+	tmuxSession.SetRecorder(command)
+}
 
-	i.SetStatus(Running)
+// prepareWorktree sets up a new worktree as configured for the repository, and makes the tmux session start with
+// the environment and the extra windows of the repository.
+func (i *Instance) prepareWorktree(tmuxSession *tmux.TmuxSession, gitWorktree *git.GitWorktree) error {
+	repoConfig, err := config.LoadRepoConfig(gitWorktree.GetRepoPath())
+	if err != nil {
+		return err
+	}
+	if err := setupWorktree(repoConfig, gitWorktree.GetRepoPath(), gitWorktree.GetWorktreePath(),
+		i.setupLog.add); err != nil {
+		return fmt.Errorf("failed to set up worktree: %w", err)
+	}
+	tmuxSession.SetEnv(repoConfig.Env)
+	tmuxSession.SetWindows(repoConfig.Windows)
+	return nil
+}
+
+// SetupOutput returns the last lines printed while setting up the worktree of the instance.
+func (i *Instance) SetupOutput() string {
+	return i.setupLog.String()
+}
+
+// Restart starts the agent again in the worktree after its tmux session ended, like when the agent crashed.
+func (i *Instance) Restart() error {
+	if !i.started || i.Status == Paused {
+		return fmt.Errorf("can only restart running instances")
+	}
+	if i.tmuxSession.DoesSessionExist() {
+		return fmt.Errorf("instance %s is still running", i.Title)
+	}
+	worktreePath := i.gitWorktree.GetWorktreePath()
+	if _, err := os.Stat(worktreePath); err != nil {
+		return fmt.Errorf("cannot restart instance %s without its worktree: %w", i.Title, err)
+	}
 
+	if err := i.tmuxSession.Release(); err != nil {
+		log.WarningLog.Printf("could not release the old session of %s: %v", i.Title, err)
+	}
+	if repoConfig, err := config.LoadRepoConfig(i.gitWorktree.GetRepoPath()); err != nil {
+		log.WarningLog.Printf("could not load the repository config of %s: %v", i.Title, err)
+	} else {
+		i.tmuxSession.SetEnv(repoConfig.Env)
+		i.tmuxSession.SetWindows(repoConfig.Windows)
+	}
+	if err := i.tmuxSession.Start(worktreePath); err != nil {
+		return fmt.Errorf("failed to restart session: %w", err)
+	}
+	i.SetStatus(Running)
 	return nil
 }
 
+// Release stops watching the tmux session of the instance without terminating it, so that another process can take
+// the instance over.
+func (i *Instance) Release() error {
+	if !i.started || i.Status == Paused {
+		return nil
+	}
+	return i.tmuxSession.Release()
+}
+
 // Kill terminates the instance and cleans up all resources
 func (i *Instance) Kill() error {
 	if !i.started {
@@ -294,28 +482,152 @@ func (i *Instance) combineErrors(errs []error) error {
 	return fmt.Errorf("%s", errMsg)
 }
 
+// Preview captures the window selected for the preview, which is the agent unless NextWindow changed it.
 func (i *Instance) Preview() (string, error) {
 	if !i.started || i.Status == Paused {
 		return "", nil
 	}
-	return i.tmuxSession.CapturePaneContent()
+	return i.tmuxSession.CaptureSelectedWindow()
+}
+
+// Windows returns the names of the tmux windows of the instance, starting with the agent. Instances without extra
+// windows have only the agent.
+func (i *Instance) Windows() []string {
+	if !i.started || i.Status == Paused {
+		return nil
+	}
+	return i.tmuxSession.WindowNames()
+}
+
+// SelectedWindow returns the index of the window shown by the preview and when attaching.
+func (i *Instance) SelectedWindow() int {
+	if !i.started || i.Status == Paused {
+		return 0
+	}
+	return i.tmuxSession.SelectedWindow()
+}
+
+// NextWindow selects the next window of the instance, wrapping around to the agent.
+func (i *Instance) NextWindow() error {
+	windows := i.Windows()
+	if len(windows) < 2 {
+		return nil
+	}
+	return i.tmuxSession.SelectWindow((i.tmuxSession.SelectedWindow() + 1) % len(windows))
 }
 
 func (i *Instance) HasUpdated() (updated bool, hasPrompt bool) {
 	if !i.started {
 		return false, false
 	}
-	return i.tmuxSession.HasUpdated()
+	updated, hasPrompt = i.tmuxSession.HasUpdated()
+	if updated && i.observed {
+		i.lastActivity = time.Now()
+	}
+	i.observed = true
+	return updated, hasPrompt
 }
 
-// TapEnter sends an enter key press to the tmux session if AutoYes is enabled.
-func (i *Instance) TapEnter() {
-	if !i.started || !i.AutoYes {
-		return
+// approvalCooldown is how long the same request is not answered again. The prompt stays on the screen until the
+// agent has processed the keys, so it is usually detected again on the next tick.
+const approvalCooldown = 2 * time.Second
+
+// Approve answers the pending prompt if AutoYes is enabled. Without an approval policy, every prompt is approved
+// with the approval keys of the agent profile. Otherwise, the request on the screen is approved or rejected as
+// the policy decides, or left for the user to answer. Every decision of the policy is recorded. Returns true if the
+// prompt was answered.
+func (i *Instance) Approve() bool {
+	answered, _ := i.approve()
+	return answered
+}
+
+// AnswerPrompt answers the pending prompt like Approve. If the prompt is left for the user, they are notified once
+// until the agent works again. Returns true if the prompt was answered.
+func (i *Instance) AnswerPrompt() bool {
+	answered, waiting := i.approve()
+	if waiting && !i.promptNotified {
+		i.promptNotified = true
+		notify.Notify(notify.EventPrompt, i.Title, fmt.Sprintf("%s is waiting for approval", i.Title))
 	}
-	if err := i.tmuxSession.TapEnter(); err != nil {
-		log.ErrorLog.Printf("error tapping enter: %v", err)
+	return answered
+}
+
+// approve answers the pending prompt. Returns whether it was answered, and whether it waits for the user instead.
+func (i *Instance) approve() (answered, waiting bool) {
+	if !i.started {
+		return false, false
+	}
+	if !i.AutoYes {
+		return false, true
+	}
+
+	engine := policy.Default()
+	if engine == nil {
+		pane := i.auditPane()
+		if err := i.tmuxSession.Approve(); err != nil {
+			log.ErrorLog.Printf("error approving prompt: %v", err)
+			return false, false
+		}
+		audit.Record(i.Title, audit.ActorDaemon, audit.ActionApprove, "", pane)
+		return true, false
+	}
+
+	request, err := i.tmuxSession.PendingRequest()
+	if err != nil {
+		log.ErrorLog.Printf("error reading approval prompt: %v", err)
+		return false, false
+	}
+	repoPath := ""
+	if i.gitWorktree != nil {
+		repoPath = i.gitWorktree.GetRepoPath()
+	}
+	decision, rule := engine.Evaluate(repoPath, request)
+
+	// Requests left for the user are only recorded once, answered ones again after the cooldown.
+	key := decision + "\x00" + request.String()
+	if key == i.lastApproval && (decision == config.ApprovalAsk || time.Since(i.lastApprovalAt) < approvalCooldown) {
+		return false, decision == config.ApprovalAsk
+	}
+	i.lastApproval, i.lastApprovalAt = key, time.Now()
+
+	if err := policy.RecordDecision(policy.DecisionRecord{
+		Time:     time.Now(),
+		Instance: i.Title,
+		Repo:     repoPath,
+		Program:  i.Program,
+		Tool:     request.Tool,
+		Command:  request.Command,
+		Decision: decision,
+		Rule:     rule + 1,
+	}); err != nil {
+		log.WarningLog.Printf("could not record approval decision: %v", err)
+	}
+
+	action := ""
+	pane := i.auditPane()
+	switch decision {
+	case config.ApprovalAllow:
+		action, err = audit.ActionApprove, i.tmuxSession.Approve()
+	case config.ApprovalDeny:
+		action, err = audit.ActionReject, i.tmuxSession.Reject()
+	}
+	if err != nil {
+		log.ErrorLog.Printf("error answering prompt: %v", err)
+		return false, false
+	}
+	if action == "" {
+		return false, true
+	}
+	audit.Record(i.Title, audit.ActorDaemon, action, request.String(), pane)
+	return true, false
+}
+
+// AgentExited returns true if the agent profile detected that the agent exited on the last call to HasUpdated.
+func (i *Instance) AgentExited() bool {
+	if !i.started || i.tmuxSession == nil {
+		return false
 	}
+	return i.tmuxSession.AgentExited()
 }
 
 func (i *Instance) Attach() (chan struct{}, error) {
@@ -325,6 +637,14 @@ func (i *Instance) Attach() (chan struct{}, error) {
 	return i.tmuxSession.Attach()
 }
 
+// AttachResult returns how the instance was left the last time it was attached.
+func (i *Instance) AttachResult() tmux.AttachResult {
+	if i.tmuxSession == nil {
+		return tmux.AttachDetached
+	}
+	return i.tmuxSession.AttachResult()
+}
+
 func (i *Instance) SetPreviewSize(width, height int) error {
 	if !i.started || i.Status == Paused {
 		return fmt.Errorf("cannot set preview size for instance that has not been started or " +
@@ -364,15 +684,38 @@ func (i *Instance) TmuxAlive() bool {
 	return i.tmuxSession.DoesSessionExist()
 }
 
-// Pause stops the tmux session and removes the worktree, preserving the branch
+// Pause stops the tmux session and removes the worktree, preserving the branch. The branch name is copied to the
+// clipboard to check it out.
 func (i *Instance) Pause() error {
+	if err := i.pause(); err != nil {
+		return err
+	}
+	_ = clipboard.WriteAll(i.gitWorktree.GetBranchName())
+	return nil
+}
+
+// pause stops the tmux session and removes the worktree, preserving the branch.
+func (i *Instance) pause() error {
 	if !i.started {
 		return fmt.Errorf("cannot pause instance that has not been started")
 	}
 	if i.Status == Paused {
 		return fmt.Errorf("instance is already paused")
 	}
+	if i.Status == Loading {
+		return fmt.Errorf("instance is busy")
+	}
+	if err := i.unload(); err != nil {
+		return err
+	}
+	i.SetStatus(Paused)
+	i.pausedAt = time.Now()
+	return nil
+}
 
+// unload does the work of pausing the instance: it commits the changes, detaches from the tmux session and removes
+// the worktree. It doesn't change the status, so that it can run in the background.
+func (i *Instance) unload() error {
 	var errs []error
 
 	// Check if there are any changes to commit
@@ -418,9 +761,6 @@ func (i *Instance) Pause() error {
 		log.ErrorLog.Print(err)
 		return err
 	}
-
-	i.SetStatus(Paused)
-	_ = clipboard.WriteAll(i.gitWorktree.GetBranchName())
 	return nil
 }
 
@@ -447,6 +787,17 @@ func (i *Instance) Resume() error {
 		return fmt.Errorf("failed to setup git worktree: %w", err)
 	}
 
+	if err := i.prepareWorktree(i.tmuxSession, i.gitWorktree); err != nil {
+		log.ErrorLog.Print(err)
+		// Keep the branch, which has the work of the paused instance.
+		if removeErr := i.gitWorktree.Remove(); removeErr != nil {
+			err = fmt.Errorf("%v (cleanup error: %v)", err, removeErr)
+		} else if pruneErr := i.gitWorktree.Prune(); pruneErr != nil {
+			err = fmt.Errorf("%v (cleanup error: %v)", err, pruneErr)
+		}
+		return err
+	}
+
 	// Check if tmux session still exists from pause, otherwise create new one
 	if i.tmuxSession.DoesSessionExist() {
 		// Session exists, just restore PTY connection to it
@@ -477,6 +828,7 @@ func (i *Instance) Resume() error {
 	}
 
 	i.SetStatus(Running)
+	i.lastActivity, i.pausedAt, i.observed = time.Now(), time.Time{}, false
 	return nil
 }
 
@@ -502,7 +854,11 @@ func (i *Instance) UpdateDiffStats() error {
 		return fmt.Errorf("failed to get diff stats: %w", stats.Error)
 	}
 
+	if i.diffStats == nil || i.diffStats.Content != stats.Content {
+		i.lastActivity = time.Now()
+	}
 	i.diffStats = stats
+	i.updateScopedDiffStats()
 	return nil
 }
 
@@ -511,6 +867,11 @@ func (i *Instance) GetDiffStats() *git.DiffStats {
 	return i.diffStats
 }
 
+// LastActivity returns when the pane or the diff of the instance last changed.
+func (i *Instance) LastActivity() time.Time {
+	return i.lastActivity
+}
+
 // SendPrompt sends a prompt to the tmux session
 func (i *Instance) SendPrompt(prompt string) error {
 	if !i.started {
@@ -519,6 +880,7 @@ func (i *Instance) SendPrompt(prompt string) error {
 	if i.tmuxSession == nil {
 		return fmt.Errorf("tmux session not initialized")
 	}
+	pane := i.auditPane()
 	if err := i.tmuxSession.SendKeys(prompt); err != nil {
 		return fmt.Errorf("error sending keys to tmux session: %w", err)
 	}
@@ -528,6 +890,11 @@ func (i *Instance) SendPrompt(prompt string) error {
 	if err := i.tmuxSession.TapEnter(); err != nil {
 		return fmt.Errorf("error tapping enter: %w", err)
 	}
+	audit.Record(i.Title, i.actor(), audit.ActionPrompt, prompt, pane)
+	i.turnStarted = true
+	if i.Prompt == "" {
+		i.Prompt = prompt
+	}
 
 	return nil
 }
@@ -550,5 +917,33 @@ func (i *Instance) SendKeys(keys string) error {
 	if !i.started || i.Status == Paused {
 		return fmt.Errorf("cannot send keys to instance that has not been started or is paused")
 	}
-	return i.tmuxSession.SendKeys(keys)
+	pane := i.auditPane()
+	if err := i.tmuxSession.SendKeys(keys); err != nil {
+		return err
+	}
+	audit.Record(i.Title, i.actor(), audit.ActionKeys, keys, pane)
+	return nil
+}
+
+// SetAuditActor sets who is recorded in the audit log as sending prompts and keys to this instance, instead of
+// the actor of the process.
+func (i *Instance) SetAuditActor(actor audit.Actor) {
+	i.auditActor = actor
+}
+
+func (i *Instance) actor() audit.Actor {
+	if i.auditActor != "" {
+		return i.auditActor
+	}
+	return audit.CurrentActor()
+}
+
+// auditPane returns the end of the pane for the audit log, or an empty string if it can't be captured.
+func (i *Instance) auditPane() string {
+	content, err := i.tmuxSession.CapturePaneContent()
+	if err != nil {
+		log.WarningLog.Printf("could not capture pane of %s for the audit log: %v", i.Title, err)
+		return ""
+	}
+	return audit.Excerpt(tmux.StripANSI(content), audit.PaneLines())
 }
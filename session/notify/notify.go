@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is something about which the user can be notified.
+type Event string
+
+const (
+	// EventReady is sent when an agent finished its turn and waits for input.
+	EventReady Event = "ready"
+	// EventPrompt is sent when an agent waits for a prompt to be approved which isn't approved automatically.
+	EventPrompt Event = "prompt"
+	// EventError is sent when an instance failed, like when it couldn't be started.
+	EventError Event = "error"
+	// EventWTaskFinished is sent when a worktree task completed or failed.
+	EventWTaskFinished Event = "wtask_finished"
+)
+
+// Backends which deliver notifications.
+const (
+	// BackendBell rings the terminal bell.
+	BackendBell = "bell"
+	// BackendOSC9 shows the message with the OSC 9 escape sequence, supported by iTerm2, kitty, WezTerm and others.
+	BackendOSC9 = "osc9"
+	// BackendOSC777 shows the message with the OSC 777 escape sequence, supported by urxvt, foot, Ghostty and others.
+	BackendOSC777 = "osc777"
+	// BackendDesktop shows the message with notify-send on Linux or osascript on macOS.
+	BackendDesktop = "desktop"
+	// BackendCommand runs the command of the config.
+	BackendCommand = "command"
+)
+
+// title is shown by desktop notifications.
+const title = "Claude Squad"
+
+// defaultRateLimit is the minimum time between two notifications of the same event for the same instance.
+const defaultRateLimit = 30 * time.Second
+
+var events = []Event{EventReady, EventPrompt, EventError, EventWTaskFinished}
+
+var backends = []string{BackendBell, BackendOSC9, BackendOSC777, BackendDesktop, BackendCommand}
+
+// notifier sends the notifications of this process.
+type notifier struct {
+	mu       sync.Mutex
+	events   map[Event][]string
+	command  string
+	interval time.Duration
+	// terminal receives the escape sequences. Without a terminal, like in the daemon, the terminal backends are
+	// skipped.
+	terminal io.Writer
+	// tmux is true if the terminal is a tmux pane, which only passes escape sequences on when they're wrapped.
+	tmux bool
+	// last is when a notification was last sent, by event and instance.
+	last map[string]time.Time
+	now  func() time.Time
+	// run runs a command of the desktop or the command backend.
+	run func(cmd *exec.Cmd)
+}
+
+func newNotifier() *notifier {
+	n := &notifier{
+		last: make(map[string]time.Time),
+		now:  time.Now,
+		run:  runCommand,
+	}
+	_ = n.configure(config.NotificationConfig{})
+	return n
+}
+
+var defaultNotifier = newNotifier()
+
+// Configure sets which backends notify about which events. Events which aren't configured aren't notified, so
+// nothing is by default. Returns an error for unknown events or backends.
+func Configure(cfg config.NotificationConfig) error {
+	return defaultNotifier.configure(cfg)
+}
+
+// SetTerminal sets the terminal which the bell and the OSC backends write to. Writes have to be safe while the TUI
+// renders to the same terminal. nil stops writing to the terminal.
+func SetTerminal(w io.Writer) {
+	defaultNotifier.mu.Lock()
+	defer defaultNotifier.mu.Unlock()
+	defaultNotifier.terminal = w
+	defaultNotifier.tmux = os.Getenv("TMUX") != ""
+}
+
+// Notify notifies the user about an event of an instance, unless the same was notified within the rate limit.
+// instance may be empty for events which don't belong to an instance.
+func Notify(event Event, instance, message string) {
+	defaultNotifier.notify(event, instance, message)
+}
+
+func (n *notifier) configure(cfg config.NotificationConfig) error {
+	configured := make(map[Event][]string)
+	for name, names := range cfg.Events {
+		event := Event(name)
+		if !contains(events, event) {
+			return fmt.Errorf("unknown event %q", name)
+		}
+		for _, backend := range names {
+			if !contains(backends, backend) {
+				return fmt.Errorf("unknown backend %q for event %s", backend, name)
+			}
+			if backend == BackendCommand && cfg.Command == "" {
+				return fmt.Errorf("backend %q for event %s needs a command", backend, name)
+			}
+		}
+		configured[event] = names
+	}
+	interval := defaultRateLimit
+	if cfg.RateLimitSeconds > 0 {
+		interval = time.Duration(cfg.RateLimitSeconds) * time.Second
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events, n.command, n.interval = configured, cfg.Command, interval
+	return nil
+}
+
+func (n *notifier) notify(event Event, instance, message string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	names := n.events[event]
+	if len(names) == 0 {
+		return
+	}
+	key := string(event) + "\x00" + instance
+	if last, ok := n.last[key]; ok && n.now().Sub(last) < n.interval {
+		return
+	}
+	n.last[key] = n.now()
+
+	message = sanitize(message)
+	for _, backend := range names {
+		switch backend {
+		case BackendBell:
+			n.write("\a")
+		case BackendOSC9:
+			n.write(n.wrap("\x1b]9;" + message + "\a"))
+		case BackendOSC777:
+			n.write(n.wrap("\x1b]777;notify;" + title + ";" + strings.ReplaceAll(message, ";", ",") + "\a"))
+		case BackendDesktop:
+			if cmd := desktopCommand(message); cmd != nil {
+				n.run(cmd)
+			}
+		case BackendCommand:
+			shell := os.Getenv("SHELL")
+			if shell == "" {
+				shell = "/bin/sh"
+			}
+			cmd := exec.Command(shell, "-c", n.command)
+			cmd.Env = append(os.Environ(),
+				"CS_EVENT="+string(event), "CS_INSTANCE="+instance, "CS_MESSAGE="+message)
+			n.run(cmd)
+		}
+	}
+}
+
+// write writes an escape sequence to the terminal. Must be called with mu held.
+func (n *notifier) write(seq string) {
+	if n.terminal == nil {
+		return
+	}
+	if _, err := io.WriteString(n.terminal, seq); err != nil {
+		log.WarningLog.Printf("could not write notification to the terminal: %v", err)
+	}
+}
+
+// wrap wraps an escape sequence for tmux to pass it on to the terminal, which requires allow-passthrough. Must be
+// called with mu held.
+func (n *notifier) wrap(seq string) string {
+	if !n.tmux {
+		return seq
+	}
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// desktopCommand returns the command which shows a desktop notification on this platform, or nil if there is none.
+func desktopCommand(message string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", title, message)
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		return exec.Command("osascript", "-e", script)
+	}
+	return nil
+}
+
+// runCommand runs a notification command in the background, so that a slow command doesn't hold up the caller.
+func runCommand(cmd *exec.Cmd) {
+	go func() {
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.WarningLog.Printf("notification command %s failed: %v: %s", cmd.Path, err, output)
+		}
+	}()
+}
+
+// sanitize removes control characters, which would end the escape sequences early.
+func sanitize(message string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, message)
+}
+
+func contains[T comparable](list []T, value T) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
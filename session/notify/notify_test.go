@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/log"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+
+	os.Exit(m.Run())
+}
+
+// testNotifier returns a notifier which writes to a buffer and collects the commands instead of running them.
+func testNotifier(t *testing.T, cfg config.NotificationConfig) (*notifier, *bytes.Buffer, *[]*exec.Cmd) {
+	n := newNotifier()
+	require.NoError(t, n.configure(cfg))
+	var terminal bytes.Buffer
+	var commands []*exec.Cmd
+	n.terminal = &terminal
+	n.run = func(cmd *exec.Cmd) { commands = append(commands, cmd) }
+	return n, &terminal, &commands
+}
+
+func TestNothingIsNotifiedByDefault(t *testing.T) {
+	n, terminal, commands := testNotifier(t, config.NotificationConfig{})
+	n.notify(EventReady, "a", "a is ready")
+	n.notify(EventError, "a", "failed")
+	n.notify(EventWTaskFinished, "task", "Task done completed")
+	assert.Empty(t, terminal.String())
+	assert.Empty(t, *commands)
+}
+
+func TestWTaskFinished(t *testing.T) {
+	n, terminal, _ := testNotifier(t, config.NotificationConfig{Events: map[string][]string{
+		"wtask_finished": {BackendBell},
+	}})
+	n.notify(EventWTaskFinished, "task", "Task done completed")
+	n.notify(EventReady, "a", "a is ready")
+	assert.Equal(t, "\a", terminal.String())
+}
+
+func TestEscapeSequences(t *testing.T) {
+	n, terminal, _ := testNotifier(t, config.NotificationConfig{Events: map[string][]string{
+		"ready":  {BackendOSC9},
+		"prompt": {BackendOSC777},
+	}})
+	n.notify(EventReady, "a", "a is\x1b ready")
+	assert.Equal(t, "\x1b]9;a is  ready\a", terminal.String(), "control characters are removed")
+
+	terminal.Reset()
+	n.notify(EventPrompt, "a", "a; waiting")
+	assert.Equal(t, "\x1b]777;notify;Claude Squad;a, waiting\a", terminal.String())
+
+	terminal.Reset()
+	n.notify(EventError, "a", "failed")
+	assert.Empty(t, terminal.String(), "events without backends aren't notified")
+
+	terminal.Reset()
+	n.tmux = true
+	n.notify(EventReady, "b", "b is ready")
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]9;b is ready\a\x1b\\", terminal.String())
+}
+
+func TestRateLimit(t *testing.T) {
+	n, terminal, _ := testNotifier(t, config.NotificationConfig{RateLimitSeconds: 10, Events: map[string][]string{
+		"ready":  {BackendBell},
+		"prompt": {BackendBell},
+	}})
+	now := time.Now()
+	n.now = func() time.Time { return now }
+
+	n.notify(EventReady, "a", "a is ready")
+	n.notify(EventReady, "a", "a is ready")
+	n.notify(EventReady, "b", "b is ready")
+	n.notify(EventPrompt, "a", "a is waiting")
+	assert.Equal(t, 3, terminal.Len(), "the same event of the same instance is only notified once")
+
+	now = now.Add(11 * time.Second)
+	n.notify(EventReady, "a", "a is ready")
+	assert.Equal(t, 4, terminal.Len())
+}
+
+func TestCommandBackend(t *testing.T) {
+	n, terminal, commands := testNotifier(t, config.NotificationConfig{
+		Events:  map[string][]string{"error": {BackendCommand}},
+		Command: "echo $CS_MESSAGE",
+	})
+	n.notify(EventError, "a", "a failed to start")
+	assert.Empty(t, terminal.String())
+	require.Len(t, *commands, 1)
+	cmd := (*commands)[0]
+	assert.Equal(t, []string{"-c", "echo $CS_MESSAGE"}, cmd.Args[1:])
+	assert.Contains(t, cmd.Env, "CS_EVENT=error")
+	assert.Contains(t, cmd.Env, "CS_INSTANCE=a")
+	assert.Contains(t, cmd.Env, "CS_MESSAGE=a failed to start")
+
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "a failed to start\n", string(output))
+}
+
+func TestConfigureRejectsUnknownNames(t *testing.T) {
+	n := newNotifier()
+	assert.ErrorContains(t, n.configure(config.NotificationConfig{
+		Events: map[string][]string{"finished": {BackendBell}},
+	}), `unknown event "finished"`)
+	assert.ErrorContains(t, n.configure(config.NotificationConfig{
+		Events: map[string][]string{"ready": {"popup"}},
+	}), `unknown backend "popup"`)
+	assert.ErrorContains(t, n.configure(config.NotificationConfig{
+		Events: map[string][]string{"ready": {BackendCommand}},
+	}), "needs a command")
+}
@@ -0,0 +1,262 @@
+package policy
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DecisionsFileName is the file in the config directory which records every decision of the approval policy.
+const DecisionsFileName = "approvals.jsonl"
+
+// Request is what an agent asks to be approved.
+type Request struct {
+	// Tool is the kind of action, like "Bash command" or "Edit file".
+	Tool string
+	// Command is the command to run or the file to change. The lines of a command which spans several lines are
+	// kept apart by newlines.
+	Command string
+}
+
+// String returns a short description of the request.
+func (r Request) String() string {
+	if r.Tool == "" && r.Command == "" {
+		return "unknown request"
+	}
+	return fmt.Sprintf("%s: %s", r.Tool, r.Command)
+}
+
+// ambiguous returns true if the command may hide more than an allow rule was written for, since it spans several
+// lines, like a wrapped command or one joined by a newline, or since the agent cut it off.
+func (r Request) ambiguous() bool {
+	return strings.Contains(r.Command, "\n") || strings.HasSuffix(r.Command, "…")
+}
+
+// boxDrawingReplacer removes the borders agents draw around their prompts.
+var boxDrawingReplacer = strings.NewReplacer("│", " ", "╭", " ", "╮", " ", "╰", " ", "╯", " ", "─", " ", "┃", " ")
+
+// ExtractRequest finds the last request on the screen using the request pattern of an agent profile. The
+// content should not contain color codes. It returns an empty request if the pattern is nil or doesn't match.
+func ExtractRequest(content string, pattern *regexp.Regexp) Request {
+	if pattern == nil {
+		return Request{}
+	}
+	lines := strings.Split(boxDrawingReplacer.Replace(content), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	matches := pattern.FindAllStringSubmatch(strings.Join(lines, "\n"), -1)
+	if len(matches) == 0 {
+		return Request{}
+	}
+
+	match := matches[len(matches)-1]
+	var request Request
+	for i, name := range pattern.SubexpNames() {
+		switch name {
+		case "tool":
+			request.Tool = strings.TrimSpace(match[i])
+		case "command":
+			var lines []string
+			for _, line := range strings.Split(match[i], "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			request.Command = strings.Join(lines, "\n")
+		}
+	}
+	return request
+}
+
+// rule is an ApprovalRule with its globs and regex compiled.
+type rule struct {
+	config.ApprovalRule
+	tool    *regexp.Regexp
+	command *regexp.Regexp
+	regex   *regexp.Regexp
+	repos   []*regexp.Regexp
+}
+
+// Engine decides which requests are approved according to an approval policy.
+type Engine struct {
+	rules         []rule
+	defaultAction string
+}
+
+// NewEngine compiles the policy. It returns an error if a rule has an unknown action or an invalid regex.
+func NewEngine(policy config.ApprovalPolicy) (*Engine, error) {
+	engine := &Engine{defaultAction: policy.Default}
+	if engine.defaultAction == "" {
+		engine.defaultAction = config.ApprovalAsk
+	}
+	if !isAction(engine.defaultAction) {
+		return nil, fmt.Errorf("unknown default approval action %q", policy.Default)
+	}
+
+	for i, approvalRule := range policy.Rules {
+		if !isAction(approvalRule.Action) {
+			return nil, fmt.Errorf("approval rule %d has unknown action %q", i+1, approvalRule.Action)
+		}
+		compiled := rule{ApprovalRule: approvalRule}
+		if approvalRule.Tool != "" {
+			compiled.tool = globToRegexp(approvalRule.Tool, true)
+		}
+		if approvalRule.Command != "" {
+			compiled.command = globToRegexp(approvalRule.Command, false)
+		}
+		if approvalRule.Regex != "" {
+			re, err := regexp.Compile(approvalRule.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("approval rule %d has an invalid regex: %w", i+1, err)
+			}
+			compiled.regex = re
+		}
+		for _, repo := range approvalRule.Repos {
+			compiled.repos = append(compiled.repos, globToRegexp(repo, false))
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine, nil
+}
+
+func isAction(action string) bool {
+	return action == config.ApprovalAllow || action == config.ApprovalDeny || action == config.ApprovalAsk
+}
+
+// globToRegexp converts a glob to an anchored regular expression in which * matches any text, including slashes.
+func globToRegexp(glob string, ignoreCase bool) *regexp.Regexp {
+	var b strings.Builder
+	if ignoreCase {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// Evaluate returns the decision for the request of an agent working on the given repository, along with the
+// index of the rule that matched, or -1 if the default decided. A request which couldn't be read from the screen is
+// left to the user, and neither allow rules nor an allowing default apply to an ambiguous one.
+func (e *Engine) Evaluate(repoPath string, request Request) (string, int) {
+	if request.Tool == "" || request.Command == "" {
+		return config.ApprovalAsk, -1
+	}
+	ambiguous := request.ambiguous()
+	for i := range e.rules {
+		if ambiguous && e.rules[i].Action == config.ApprovalAllow {
+			continue
+		}
+		if e.rules[i].matches(repoPath, request) {
+			return e.rules[i].Action, i
+		}
+	}
+	if ambiguous && e.defaultAction == config.ApprovalAllow {
+		return config.ApprovalAsk, -1
+	}
+	return e.defaultAction, -1
+}
+
+func (r *rule) matches(repoPath string, request Request) bool {
+	if len(r.repos) > 0 {
+		matched := false
+		for _, repo := range r.repos {
+			if repo.MatchString(repoPath) || repo.MatchString(filepath.Base(repoPath)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if r.tool != nil && !r.tool.MatchString(request.Tool) {
+		return false
+	}
+	// The lines of a command are matched as one, so that deny rules see all of it.
+	command := strings.ReplaceAll(request.Command, "\n", " ")
+	if r.command != nil && !r.command.MatchString(command) {
+		return false
+	}
+	if r.regex != nil && !r.regex.MatchString(command) {
+		return false
+	}
+	return true
+}
+
+var (
+	defaultEngineMu sync.RWMutex
+	defaultEngine   *Engine
+)
+
+// SetDefault sets the engine used for instances in auto-yes mode. A nil engine approves every request.
+func SetDefault(engine *Engine) {
+	defaultEngineMu.Lock()
+	defer defaultEngineMu.Unlock()
+	defaultEngine = engine
+}
+
+// Default returns the engine used for instances in auto-yes mode, or nil if every request is approved.
+func Default() *Engine {
+	defaultEngineMu.RLock()
+	defer defaultEngineMu.RUnlock()
+	return defaultEngine
+}
+
+// DecisionRecord is a line in the decisions file.
+type DecisionRecord struct {
+	Time     time.Time `json:"time"`
+	Instance string    `json:"instance"`
+	Repo     string    `json:"repo"`
+	Program  string    `json:"program"`
+	Tool     string    `json:"tool"`
+	Command  string    `json:"command"`
+	Decision string    `json:"decision"`
+	// Rule is the 1-based index of the rule which decided, or 0 for the default decision.
+	Rule int `json:"rule"`
+}
+
+var decisionsMu sync.Mutex
+
+// RecordDecision appends the record to the decisions file in the config directory.
+func RecordDecision(record DecisionRecord) error {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config directory: %w", err)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval decision: %w", err)
+	}
+
+	decisionsMu.Lock()
+	defer decisionsMu.Unlock()
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(configDir, DecisionsFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open approval decisions file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write approval decision: %w", err)
+	}
+	return nil
+}
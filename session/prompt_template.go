@@ -0,0 +1,258 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/atotto/clipboard"
+)
+
+// builtinPromptVariables are the variables of prompt templates which are filled in from the instance. Any other
+// variable has to be filled in by the user.
+var builtinPromptVariables = map[string]bool{
+	"Title": true, "Branch": true, "Repo": true, "Diff": true, "Clipboard": true,
+}
+
+// PromptContext holds the values of the built-in variables of prompt templates.
+type PromptContext struct {
+	Title  string
+	Branch string
+	Repo   string
+	// Diff summarizes the changes of the instance, a line per file.
+	Diff string
+	// Dir is the directory the file function reads from.
+	Dir string
+}
+
+// PromptTemplate is a prompt of the library parsed as a Go template. Besides the built-in variables {{.Title}},
+// {{.Branch}}, {{.Repo}}, {{.Diff}} and {{.Clipboard}}, it can refer to variables which the user fills in, like
+// {{.Issue}}, and include files of the repository with {{file "path"}}.
+type PromptTemplate struct {
+	tmpl *template.Template
+	// fields are the variables the template refers to, in order of appearance.
+	fields []string
+	// files is true if the template includes files.
+	files bool
+}
+
+// ParsePromptTemplate parses the text of a prompt.
+func ParsePromptTemplate(name, text string) (*PromptTemplate, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"file": func(string) (string, error) { return "", nil },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt %s: %w", name, err)
+	}
+	t := &PromptTemplate{tmpl: tmpl}
+	seen := make(map[string]bool)
+	addField := func(field string) {
+		if !seen[field] {
+			seen[field] = true
+			t.fields = append(t.fields, field)
+		}
+	}
+	walkTemplate(tmpl.Root, func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.FieldNode:
+			addField(n.Ident[0])
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				addField(n.Ident[1])
+			}
+		case *parse.IdentifierNode:
+			if n.Ident == "file" {
+				t.files = true
+			}
+		}
+	})
+	return t, nil
+}
+
+// PerInstance returns true if the template refers to a built-in variable or includes a file, so that it renders
+// differently for every instance.
+func (t *PromptTemplate) PerInstance() bool {
+	for _, field := range t.fields {
+		if builtinPromptVariables[field] {
+			return true
+		}
+	}
+	return t.files
+}
+
+// Variables returns the variables of the template which aren't built in, in order of appearance.
+func (t *PromptTemplate) Variables() []string {
+	var variables []string
+	for _, field := range t.fields {
+		if !builtinPromptVariables[field] {
+			variables = append(variables, field)
+		}
+	}
+	return variables
+}
+
+// Render renders the template with the built-in variables from ctx and the others from values. The clipboard is
+// only read if the template refers to it. It's an error if a built-in variable the template refers to is empty, like
+// {{.Diff}} without changes.
+func (t *PromptTemplate) Render(ctx PromptContext, values map[string]string) (string, error) {
+	data := map[string]any{
+		"Title":  ctx.Title,
+		"Branch": ctx.Branch,
+		"Repo":   ctx.Repo,
+		"Diff":   ctx.Diff,
+	}
+	for _, field := range t.fields {
+		if field == "Clipboard" {
+			text, err := clipboard.ReadAll()
+			if err != nil {
+				return "", fmt.Errorf("failed to read the clipboard: %w", err)
+			}
+			data["Clipboard"] = text
+		}
+		// A built-in variable without a value would silently leave a gap in the prompt.
+		if value, ok := data[field]; ok && builtinPromptVariables[field] && value == "" {
+			return "", fmt.Errorf("prompt %s uses {{.%s}}, which is empty for this instance", t.tmpl.Name(), field)
+		}
+	}
+	for name, value := range values {
+		if !builtinPromptVariables[name] {
+			data[name] = value
+		}
+	}
+	return t.execute(data, func(path string) (string, error) {
+		if !filepath.IsLocal(path) {
+			return "", fmt.Errorf("%q is not a path inside the repository", path)
+		}
+		content, err := os.ReadFile(filepath.Join(ctx.Dir, path))
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	})
+}
+
+// RenderDeferred renders the variables of the template which the user filled in, and leaves the built-in variables
+// and the files in place, like {{.Branch}}. It's for a prompt sent to several instances, which is then parsed and
+// rendered for each of them. Conditions on built-in variables are evaluated as if they were set.
+func (t *PromptTemplate) RenderDeferred(values map[string]string) (string, error) {
+	data := make(map[string]any, len(builtinPromptVariables)+len(values))
+	for name := range builtinPromptVariables {
+		data[name] = "{{." + name + "}}"
+	}
+	for name, value := range values {
+		if builtinPromptVariables[name] {
+			continue
+		}
+		// The value must come out as it is when the result is rendered again.
+		if strings.Contains(value, "{{") {
+			value = fmt.Sprintf("{{%q}}", value)
+		}
+		data[name] = value
+	}
+	return t.execute(data, func(path string) (string, error) {
+		return fmt.Sprintf("{{file %q}}", path), nil
+	})
+}
+
+// execute renders the template with the data, including files with the file function.
+func (t *PromptTemplate) execute(data map[string]any, file func(string) (string, error)) (string, error) {
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(template.FuncMap{"file": file})
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", t.tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// walkTemplate calls fn with the node and every node below it, except for lists and pipelines.
+func walkTemplate(node parse.Node, fn func(parse.Node)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(child, fn)
+		}
+		return
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplate(cmd, fn)
+		}
+		return
+	}
+
+	fn(node)
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, fn)
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplate(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkTemplate(n.Node, fn)
+	case *parse.IfNode:
+		walkTemplate(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkTemplate(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkTemplate(&n.BranchNode, fn)
+	case *parse.BranchNode:
+		walkTemplate(n.Pipe, fn)
+		walkTemplate(n.List, fn)
+		walkTemplate(n.ElseList, fn)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, fn)
+	}
+}
+
+// PromptContext returns the values of the built-in variables of prompt templates for the instance. An instance
+// which is still starting gets the branch and the repository it will have.
+func (i *Instance) PromptContext() PromptContext {
+	ctx := PromptContext{
+		Title:  i.Title,
+		Branch: i.Branch,
+		Repo:   filepath.Base(i.Path),
+		Dir:    i.Path,
+	}
+	switch {
+	case i.started && !i.Paused():
+		ctx.Repo = i.gitWorktree.GetRepoName()
+		ctx.Dir = i.gitWorktree.GetWorktreePath()
+	case !i.started:
+		ctx.Branch = git.BranchName(i.Title)
+		if root, err := git.FindRepoRoot(i.Path); err == nil {
+			ctx.Repo = filepath.Base(root)
+			ctx.Dir = root
+		}
+	}
+	if i.diffStats != nil && !i.diffStats.IsEmpty() {
+		ctx.Diff = diffSummary(i.diffStats)
+	}
+	return ctx
+}
+
+// diffSummary describes the changes of a diff with a line per file, like git diff --stat.
+func diffSummary(stats *git.DiffStats) string {
+	files := stats.ParsedFiles()
+	lines := make([]string, 0, len(files)+1)
+	for f := range files {
+		file := &files[f]
+		lines = append(lines, fmt.Sprintf("%s +%d -%d", file.Path(), file.Added, file.Removed))
+	}
+	lines = append(lines, fmt.Sprintf("%d files changed, %d insertions(+), %d deletions(-)",
+		len(files), stats.Added, stats.Removed))
+	return strings.Join(lines, "\n")
+}
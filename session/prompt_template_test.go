@@ -0,0 +1,78 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptTemplate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "NOTES.md"), []byte("be careful"), 0644))
+	ctx := PromptContext{Title: "fix-login", Branch: "cs/fix-login", Repo: "web", Diff: "a.go +1 -0", Dir: dir}
+
+	tmpl, err := ParsePromptTemplate("fix", `Fix issue {{.Issue}} on {{.Branch}} of {{.Repo}}.
+{{if .Details}}Details: {{.Details}}{{end}}
+{{with $.Issue}}{{.}}{{end}} {{file "NOTES.md"}}
+{{.Diff}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Issue", "Details"}, tmpl.Variables())
+
+	text, err := tmpl.Render(ctx, map[string]string{"Issue": "#42", "Details": "", "Branch": "ignored"})
+	require.NoError(t, err)
+	assert.Equal(t, "Fix issue #42 on cs/fix-login of web.\n\n#42 be careful\na.go +1 -0", text)
+
+	_, err = tmpl.Render(ctx, map[string]string{"Issue": "#42"})
+	assert.Error(t, err, "variables without a value are an error")
+
+	tmpl, err = ParsePromptTemplate("secrets", `{{file "../secret"}}`)
+	require.NoError(t, err)
+	_, err = tmpl.Render(ctx, nil)
+	assert.ErrorContains(t, err, "not a path inside the repository")
+
+	// For several instances, only the variables of the user are rendered and the result is rendered per instance.
+	tmpl, err = ParsePromptTemplate("fix", `Fix {{.Issue}} on {{.Branch}}: {{file "NOTES.md"}}`)
+	require.NoError(t, err)
+	assert.True(t, tmpl.PerInstance())
+	deferred, err := tmpl.RenderDeferred(map[string]string{"Issue": "{{odd}}"})
+	require.NoError(t, err)
+	assert.Equal(t, `Fix {{"{{odd}}"}} on {{.Branch}}: {{file "NOTES.md"}}`, deferred)
+	tmpl, err = ParsePromptTemplate("fix", deferred)
+	require.NoError(t, err)
+	assert.Empty(t, tmpl.Variables())
+	text, err = tmpl.Render(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix {{odd}} on cs/fix-login: be careful", text)
+
+	tmpl, err = ParsePromptTemplate("plain", "Fix {{.Issue}}")
+	require.NoError(t, err)
+	assert.False(t, tmpl.PerInstance())
+
+	tmpl, err = ParsePromptTemplate("review", "Review {{.Branch}}:\n{{.Diff}}")
+	require.NoError(t, err)
+	_, err = tmpl.Render(PromptContext{Branch: "cs/fix-login"}, nil)
+	assert.ErrorContains(t, err, "{{.Diff}}, which is empty")
+
+	_, err = ParsePromptTemplate("broken", "{{.Issue")
+	assert.Error(t, err)
+}
+
+func TestPromptContextOfStartingInstance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := filepath.Join(t.TempDir(), "web")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "src"), 0755))
+	out, err := exec.Command("git", "-C", repo, "init").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	instance, err := NewInstance(InstanceOptions{Title: "fix login", Path: filepath.Join(repo, "src"), Program: "claude"})
+	require.NoError(t, err)
+	ctx := instance.PromptContext()
+	assert.Equal(t, git.BranchName("fix login"), ctx.Branch)
+	assert.NotEmpty(t, ctx.Branch)
+	assert.Equal(t, "web", ctx.Repo)
+}
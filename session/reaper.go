@@ -0,0 +1,111 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"fmt"
+	"time"
+)
+
+// ReapAction is what the reaper does with an instance.
+type ReapAction string
+
+const (
+	// ReapPause pauses an idle instance.
+	ReapPause ReapAction = "pause"
+	// ReapKill kills a paused instance whose branch was merged.
+	ReapKill ReapAction = "kill"
+)
+
+// Reaping is an instance which the reaper pauses or kills.
+type Reaping struct {
+	Title  string
+	Action ReapAction
+	// Reason tells why, like "idle for 26h".
+	Reason string
+}
+
+// PlanReaping returns the instances which the policies of cfg pause or kill at now. Instances whose branch can't be
+// checked are left alone.
+func PlanReaping(cfg config.ReaperConfig, instances []InstanceData, now time.Time) []Reaping {
+	var plan []Reaping
+	for _, data := range instances {
+		switch data.Status {
+		case Loading:
+		case Paused:
+			paused := now.Sub(data.PausedAt)
+			if cfg.KillMergedDays <= 0 || data.PausedAt.IsZero() || paused < time.Duration(cfg.KillMergedDays)*24*time.Hour {
+				continue
+			}
+			if merged, err := branchMerged(data.Worktree); err != nil {
+				log.WarningLog.Printf("could not check if %s can be killed: %v", data.Title, err)
+				continue
+			} else if !merged {
+				continue
+			}
+			plan = append(plan, Reaping{
+				Title:  data.Title,
+				Action: ReapKill,
+				Reason: fmt.Sprintf("paused for %s, branch %s merged", formatAge(paused), data.Worktree.BranchName),
+			})
+		default:
+			idle := now.Sub(data.LastActivity)
+			if cfg.PauseIdleHours <= 0 || data.LastActivity.IsZero() || idle < time.Duration(cfg.PauseIdleHours)*time.Hour {
+				continue
+			}
+			plan = append(plan, Reaping{Title: data.Title, Action: ReapPause, Reason: "idle for " + formatAge(idle)})
+		}
+	}
+	return plan
+}
+
+// branchMerged returns true if the branch of the worktree was merged and isn't checked out in the repository,
+// since killing the instance deletes the branch.
+func branchMerged(data GitWorktreeData) (bool, error) {
+	worktree := git.NewGitWorktreeFromStorage(data.RepoPath, data.WorktreePath, data.SessionName, data.BranchName,
+		data.BaseCommitSHA)
+	merged, err := worktree.IsMerged()
+	if err != nil || !merged {
+		return false, err
+	}
+	checkedOut, err := worktree.IsBranchCheckedOut()
+	if err != nil {
+		return false, err
+	}
+	return !checkedOut, nil
+}
+
+// formatAge formats a duration in hours, or in days from two days on.
+func formatAge(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%dh", int(d/time.Hour))
+}
+
+// Reap carries out an action planned by PlanReaping. It doesn't change the instance, so that it can run in the
+// background while the instance is Loading, and CompleteReap applies the action to it afterwards. Unlike Pause,
+// pausing doesn't copy the branch name to the clipboard, since nobody asked for it.
+func (i *Instance) Reap(action ReapAction) error {
+	switch action {
+	case ReapPause:
+		return i.unload()
+	case ReapKill:
+		// The session of a paused instance may be gone already, like after a reboot.
+		if !i.TmuxAlive() {
+			return i.gitWorktree.Cleanup()
+		}
+		return i.Kill()
+	}
+	return fmt.Errorf("unknown reap action %q", action)
+}
+
+// CompleteReap applies an action which Reap carried out to the instance. A killed instance is left for the caller
+// to remove.
+func (i *Instance) CompleteReap(action ReapAction) {
+	if action == ReapPause {
+		i.SetStatus(Paused)
+		i.pausedAt = time.Now()
+	}
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"claude-squad/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanReaping(t *testing.T) {
+	dir := t.TempDir()
+	runGit := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	runGit("init", "-b", "main")
+	runGit("config", "--local", "user.email", "test@example.com")
+	runGit("config", "--local", "user.name", "Test User")
+	commit := func(name string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+		runGit("add", name)
+		runGit("commit", "-m", name)
+	}
+	commit("a.txt")
+	runGit("checkout", "-b", "feature")
+	commit("b.txt")
+	runGit("checkout", "main")
+	runGit("merge", "--no-ff", "-m", "merge", "feature")
+	runGit("checkout", "-b", "unmerged")
+	commit("c.txt")
+	runGit("checkout", "main")
+
+	now := time.Now()
+	paused := func(title, branch string, days int) InstanceData {
+		return InstanceData{
+			Title:    title,
+			Status:   Paused,
+			PausedAt: now.Add(-time.Duration(days) * 24 * time.Hour),
+			Worktree: GitWorktreeData{RepoPath: dir, BranchName: branch},
+		}
+	}
+	instances := []InstanceData{
+		{Title: "idle", Status: Ready, LastActivity: now.Add(-5 * time.Hour)},
+		{Title: "busy", Status: Running, LastActivity: now.Add(-time.Minute)},
+		{Title: "loading", Status: Loading, LastActivity: now.Add(-5 * time.Hour)},
+		paused("merged", "feature", 8),
+		paused("unmerged", "unmerged", 8),
+		paused("recent", "feature", 1),
+		paused("checked-out", "main", 8),
+	}
+
+	plan := PlanReaping(config.ReaperConfig{PauseIdleHours: 4, KillMergedDays: 7}, instances, now)
+	assert.Equal(t, []Reaping{
+		{Title: "idle", Action: ReapPause, Reason: "idle for 5h"},
+		{Title: "merged", Action: ReapKill, Reason: "paused for 8d, branch feature merged"},
+	}, plan)
+
+	assert.Empty(t, PlanReaping(config.ReaperConfig{}, instances, now), "the policies are off by default")
+}
@@ -0,0 +1,234 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Orphans are worktrees, branches and tmux sessions which no stored instance uses, and stored instances which lost
+// their worktree. See FindOrphans.
+type Orphans struct {
+	// Worktrees are worktrees in the worktree directory, or registered there by a repository, which no stored
+	// instance uses.
+	Worktrees []OrphanWorktree
+	// Branches are branches of orphaned worktrees and of forgotten instances.
+	Branches []OrphanBranch
+	// Sessions are the names of tmux sessions which no stored instance uses.
+	Sessions []string
+	// Instances are the titles of stored instances which aren't paused, but whose worktree is gone.
+	Instances []string
+}
+
+// Empty returns true if there are no orphans.
+func (o *Orphans) Empty() bool {
+	return len(o.Worktrees) == 0 && len(o.Branches) == 0 && len(o.Sessions) == 0 && len(o.Instances) == 0
+}
+
+// OrphanWorktree is a worktree which no stored instance uses.
+type OrphanWorktree struct {
+	Path string
+	// Repo is the repository of the worktree. It's empty if the directory doesn't belong to a repository anymore.
+	Repo   string
+	Branch string
+	// Missing is true if the repository still registers the worktree, but its directory is gone.
+	Missing bool
+}
+
+func (w OrphanWorktree) String() string {
+	var details []string
+	if w.Missing {
+		details = append(details, "directory gone")
+	}
+	if w.Repo != "" {
+		details = append(details, "repo "+w.Repo)
+	} else {
+		details = append(details, "no repo")
+	}
+	if w.Branch != "" {
+		details = append(details, "branch "+w.Branch)
+	}
+	return fmt.Sprintf("%s (%s)", w.Path, strings.Join(details, ", "))
+}
+
+// Remove removes the worktree from its repository and the disk. The branch is kept.
+func (w OrphanWorktree) Remove() error {
+	switch {
+	case w.Missing:
+		return git.PruneWorktrees(w.Repo)
+	case w.Repo != "":
+		return git.RemoveWorktree(w.Repo, w.Path)
+	default:
+		// The directory is ours, but git doesn't know it anymore.
+		return os.RemoveAll(w.Path)
+	}
+}
+
+// OrphanBranch is a branch which no stored instance uses.
+type OrphanBranch struct {
+	Repo string
+	Name string
+	// Unpushed is the number of commits which would be lost by deleting the branch.
+	Unpushed int
+}
+
+func (b OrphanBranch) String() string {
+	if b.Unpushed > 0 {
+		return fmt.Sprintf("%s in %s (%d unpushed commits)", b.Name, b.Repo, b.Unpushed)
+	}
+	return fmt.Sprintf("%s in %s", b.Name, b.Repo)
+}
+
+// Delete deletes the branch. A branch with unpushed commits is only deleted with force.
+func (b OrphanBranch) Delete(force bool) error {
+	if b.Unpushed > 0 && !force {
+		return fmt.Errorf("branch %s has %d unpushed commits", b.Name, b.Unpushed)
+	}
+	return git.DeleteBranch(b.Repo, b.Name)
+}
+
+// FindOrphans cross-references the stored instances with the worktree directory, the worktrees registered by their
+// repositories and the tmux sessions. The branches of forgotten instances, like those removed from storage by a
+// reset, are orphans unless a stored instance uses them.
+func FindOrphans(stored, forgotten []InstanceData, sessions []string) (*Orphans, error) {
+	worktreeDir, err := git.WorktreeDirectory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree directory: %w", err)
+	}
+	worktreeDir = resolvePath(worktreeDir)
+	orphans := &Orphans{}
+
+	usedWorktrees := make(map[string]bool)
+	usedBranches := make(map[string]bool)
+	usedSessions := make(map[string]bool)
+	repos := make(map[string]bool)
+	for _, data := range stored {
+		usedWorktrees[resolvePath(data.Worktree.WorktreePath)] = true
+		usedBranches[branchKey(data.Worktree.RepoPath, data.Worktree.BranchName)] = true
+		usedSessions[tmux.SessionName(data.Title)] = true
+		repos[data.Worktree.RepoPath] = true
+		if data.Status != Paused && data.Worktree.WorktreePath != "" {
+			if _, err := os.Stat(data.Worktree.WorktreePath); os.IsNotExist(err) {
+				orphans.Instances = append(orphans.Instances, data.Title)
+			}
+		}
+	}
+	for _, data := range forgotten {
+		repos[data.Worktree.RepoPath] = true
+	}
+
+	// Directories in the worktree directory which no instance uses, and the repositories they belong to.
+	entries, err := os.ReadDir(worktreeDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read worktree directory: %w", err)
+	}
+	dirs := make(map[string]string)
+	for _, entry := range entries {
+		path := filepath.Join(worktreeDir, entry.Name())
+		if !entry.IsDir() || usedWorktrees[path] {
+			continue
+		}
+		repo, err := git.RepoOfWorktree(path)
+		if err != nil {
+			repo = ""
+		} else {
+			repos[repo] = true
+		}
+		dirs[path] = repo
+	}
+
+	// Worktrees registered by the repositories in the worktree directory which no instance uses.
+	candidates := make(map[string]OrphanBranch)
+	for repo := range repos {
+		if repo == "" {
+			continue
+		}
+		worktrees, err := git.ListWorktrees(repo)
+		if err != nil {
+			// The repository may be gone.
+			continue
+		}
+		for _, worktree := range worktrees {
+			path := resolvePath(worktree.Path)
+			if !strings.HasPrefix(path, worktreeDir+string(filepath.Separator)) || usedWorktrees[path] {
+				continue
+			}
+			if _, ok := dirs[path]; ok {
+				delete(dirs, path)
+			} else if !worktree.Prunable {
+				continue
+			}
+			orphans.Worktrees = append(orphans.Worktrees, OrphanWorktree{
+				Path:    path,
+				Repo:    repo,
+				Branch:  worktree.Branch,
+				Missing: worktree.Prunable,
+			})
+			if worktree.Branch != "" {
+				candidates[branchKey(repo, worktree.Branch)] = OrphanBranch{Repo: repo, Name: worktree.Branch}
+			}
+		}
+	}
+	// Directories which their repository doesn't know anymore.
+	for path, repo := range dirs {
+		orphans.Worktrees = append(orphans.Worktrees, OrphanWorktree{Path: path, Repo: repo})
+	}
+
+	for _, data := range forgotten {
+		if data.Worktree.BranchName != "" {
+			candidates[branchKey(data.Worktree.RepoPath, data.Worktree.BranchName)] = OrphanBranch{
+				Repo: data.Worktree.RepoPath,
+				Name: data.Worktree.BranchName,
+			}
+		}
+	}
+	// The commits of an orphan branch are only safe on another branch if that one isn't an orphan as well.
+	orphanBranches := make(map[string][]string)
+	for key, branch := range candidates {
+		if usedBranches[key] || !git.BranchExists(branch.Repo, branch.Name) {
+			continue
+		}
+		orphans.Branches = append(orphans.Branches, branch)
+		orphanBranches[branch.Repo] = append(orphanBranches[branch.Repo], branch.Name)
+	}
+	for i := range orphans.Branches {
+		branch := &orphans.Branches[i]
+		unpushed, err := git.UnpushedCommits(branch.Repo, branch.Name, orphanBranches[branch.Repo])
+		if err != nil {
+			return nil, err
+		}
+		branch.Unpushed = unpushed
+	}
+
+	for _, name := range sessions {
+		if !usedSessions[name] {
+			orphans.Sessions = append(orphans.Sessions, name)
+		}
+	}
+
+	sort.Slice(orphans.Worktrees, func(i, j int) bool { return orphans.Worktrees[i].Path < orphans.Worktrees[j].Path })
+	sort.Slice(orphans.Branches, func(i, j int) bool {
+		return branchKey(orphans.Branches[i].Repo, orphans.Branches[i].Name) <
+			branchKey(orphans.Branches[j].Repo, orphans.Branches[j].Name)
+	})
+	sort.Strings(orphans.Sessions)
+	return orphans, nil
+}
+
+// resolvePath resolves the symlinks in the directory of the path, since git reports the worktrees by their real
+// path. The path itself may be gone.
+func resolvePath(path string) string {
+	dir, base := filepath.Split(filepath.Clean(path))
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return filepath.Join(resolved, base)
+	}
+	return filepath.Clean(path)
+}
+
+func branchKey(repo, branch string) string {
+	return repo + "\x00" + branch
+}
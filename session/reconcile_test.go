@@ -0,0 +1,127 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOrphans(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	worktreeDir := filepath.Join(home, ".claude-squad", "worktrees")
+	require.NoError(t, os.MkdirAll(worktreeDir, 0755))
+
+	repo := filepath.Join(home, "repo")
+	origin := filepath.Join(home, "origin.git")
+	runGit := func(dir string, args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	runGit(home, "init", "--bare", origin)
+	runGit(repo, "init", "-b", "main")
+	runGit(repo, "config", "--local", "user.email", "test@example.com")
+	runGit(repo, "config", "--local", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a"), 0644))
+	runGit(repo, "add", "a.txt")
+	runGit(repo, "commit", "-m", "initial commit")
+	runGit(repo, "remote", "add", "origin", origin)
+	runGit(repo, "push", "origin", "main")
+
+	worktree := func(name string) string {
+		path := filepath.Join(worktreeDir, name)
+		runGit(repo, "worktree", "add", "-b", name, path)
+		return path
+	}
+	kept := worktree("kept")
+	orphan := worktree("orphan")
+	require.NoError(t, os.WriteFile(filepath.Join(orphan, "b.txt"), []byte("b"), 0644))
+	runGit(orphan, "add", "b.txt")
+	runGit(orphan, "commit", "-m", "unpushed work")
+	gone := worktree("gone")
+	require.NoError(t, os.RemoveAll(gone))
+	stray := filepath.Join(worktreeDir, "stray")
+	require.NoError(t, os.MkdirAll(stray, 0755))
+
+	stored := []InstanceData{
+		{Title: "kept", Status: Ready, Worktree: GitWorktreeData{RepoPath: repo, WorktreePath: kept, BranchName: "kept"}},
+		{Title: "broken", Status: Ready, Worktree: GitWorktreeData{
+			RepoPath: repo, WorktreePath: filepath.Join(worktreeDir, "broken"), BranchName: "broken"}},
+	}
+	orphans, err := FindOrphans(stored, nil, []string{"claudesquad_kept", "claudesquad_old"})
+	require.NoError(t, err)
+	assert.Equal(t, []OrphanWorktree{
+		{Path: gone, Repo: repo, Branch: "gone", Missing: true},
+		{Path: orphan, Repo: repo, Branch: "orphan"},
+		{Path: stray},
+	}, orphans.Worktrees)
+	assert.Equal(t, []OrphanBranch{
+		{Repo: repo, Name: "gone"},
+		{Repo: repo, Name: "orphan", Unpushed: 1},
+	}, orphans.Branches)
+	assert.Equal(t, []string{"claudesquad_old"}, orphans.Sessions)
+	assert.Equal(t, []string{"broken"}, orphans.Instances)
+
+	for _, worktree := range orphans.Worktrees {
+		require.NoError(t, worktree.Remove())
+	}
+	require.NoError(t, orphans.Branches[0].Delete(false))
+	assert.ErrorContains(t, orphans.Branches[1].Delete(false), "1 unpushed commits")
+	assert.True(t, git.BranchExists(repo, "orphan"))
+	require.NoError(t, orphans.Branches[1].Delete(true))
+
+	worktrees, err := git.ListWorktrees(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []git.Worktree{{Path: kept, Branch: "kept"}}, worktrees)
+	assert.NoDirExists(t, stray)
+
+	// The branches of forgotten instances are orphans too, like after a reset.
+	orphans, err = FindOrphans(nil, stored, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []OrphanWorktree{{Path: kept, Repo: repo, Branch: "kept"}}, orphans.Worktrees)
+	assert.Equal(t, []OrphanBranch{{Repo: repo, Name: "kept"}}, orphans.Branches)
+}
+
+func TestFindOrphansSharingCommits(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := filepath.Join(home, "repo")
+	runGit := func(dir string, args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	runGit(repo, "init", "-b", "main")
+	runGit(repo, "config", "--local", "user.email", "test@example.com")
+	runGit(repo, "config", "--local", "user.name", "Test User")
+	runGit(repo, "commit", "--allow-empty", "-m", "initial commit")
+	runGit(repo, "checkout", "-b", "work")
+	runGit(repo, "commit", "--allow-empty", "-m", "unpushed work")
+	// A fork of the instance without commits of its own.
+	runGit(repo, "branch", "work-fork")
+	runGit(repo, "checkout", "main")
+
+	forgotten := []InstanceData{
+		{Title: "work", Worktree: GitWorktreeData{RepoPath: repo, BranchName: "work"}},
+		{Title: "work-fork", Worktree: GitWorktreeData{RepoPath: repo, BranchName: "work-fork"}},
+	}
+	orphans, err := FindOrphans(nil, forgotten, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []OrphanBranch{
+		{Repo: repo, Name: "work", Unpushed: 1},
+		{Repo: repo, Name: "work-fork", Unpushed: 1},
+	}, orphans.Branches)
+
+	// A branch which is kept does hold the commits.
+	runGit(repo, "branch", "kept", "work")
+	stored := []InstanceData{{Title: "kept", Worktree: GitWorktreeData{RepoPath: repo, BranchName: "kept"}}}
+	orphans, err = FindOrphans(stored, forgotten, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []OrphanBranch{{Repo: repo, Name: "work"}, {Repo: repo, Name: "work-fork"}}, orphans.Branches)
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/audit"
+	"claude-squad/session/git"
+	"claude-squad/session/transcript"
+	"fmt"
+)
+
+// Rename changes the title of a started instance, renaming its tmux session, its transcript and its audit log. The
+// branch keeps its name, see RenameBranch. Use SetTitle for instances which haven't started.
+func (i *Instance) Rename(title string) error {
+	if !i.started {
+		return fmt.Errorf("cannot rename instance that has not been started")
+	}
+	if title == "" {
+		return fmt.Errorf("instance title cannot be empty")
+	}
+	if title == i.Title {
+		return nil
+	}
+	if err := i.tmuxSession.Rename(title); err != nil {
+		return err
+	}
+	if err := transcript.Rename(i.Title, title); err != nil {
+		log.WarningLog.Printf("could not rename transcript of %s: %v", i.Title, err)
+	}
+	if err := audit.Rename(i.Title, title); err != nil {
+		log.WarningLog.Printf("could not rename audit log of %s: %v", i.Title, err)
+	}
+	i.Title = title
+	// Restarts of the agent record to the renamed transcript.
+	i.setupTranscript(i.tmuxSession, false)
+	return nil
+}
+
+// BranchForTitle returns the name of the branch which a new instance with the title of this one would get.
+func (i *Instance) BranchForTitle() string {
+	return git.BranchName(i.Title)
+}
+
+// RenameBranch renames the branch of the instance to BranchForTitle.
+func (i *Instance) RenameBranch() error {
+	if !i.started {
+		return fmt.Errorf("cannot rename branch of instance that has not been started")
+	}
+	name := i.BranchForTitle()
+	if name == i.gitWorktree.GetBranchName() {
+		return nil
+	}
+	if git.BranchExists(i.gitWorktree.GetRepoPath(), name) {
+		return fmt.Errorf("branch %s already exists", name)
+	}
+	if err := i.gitWorktree.RenameBranch(name); err != nil {
+		return err
+	}
+	i.Branch = name
+	return nil
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReviewComment is a comment on a line of the diff of an instance. Comments are collected while reviewing the
+// diff and sent back to the agent as a single prompt.
+type ReviewComment struct {
+	// Path is the path of the file relative to the repository root.
+	Path string `json:"path"`
+	// Line is the line number in the new file, or in the old file for removed lines.
+	Line int `json:"line"`
+	// Code is the commented line as it appears in the diff, including its +, - or space prefix. For comments
+	// on a whole hunk, this is the hunk header.
+	Code string `json:"code"`
+	// Text is the comment itself.
+	Text string `json:"text"`
+}
+
+// AddReviewComment adds a comment to the pending review of the instance.
+func (i *Instance) AddReviewComment(comment ReviewComment) {
+	i.reviewComments = append(i.reviewComments, comment)
+}
+
+// ReviewComments returns the comments which have not been sent to the agent yet.
+func (i *Instance) ReviewComments() []ReviewComment {
+	return i.reviewComments
+}
+
+// SendReviewComments sends all pending review comments to the agent as one prompt and clears them.
+func (i *Instance) SendReviewComments() error {
+	if len(i.reviewComments) == 0 {
+		return fmt.Errorf("no review comments to send")
+	}
+	if err := i.SendPrompt(FormatReviewComments(i.reviewComments)); err != nil {
+		return err
+	}
+	i.reviewComments = nil
+	return nil
+}
+
+// FormatReviewComments formats the comments as a single prompt which quotes every commented line.
+func FormatReviewComments(comments []ReviewComment) string {
+	var b strings.Builder
+	b.WriteString("Please address the following review comments on your changes:")
+	for idx, comment := range comments {
+		b.WriteString(fmt.Sprintf("\n\n%d. %s:%d\n", idx+1, comment.Path, comment.Line))
+		if comment.Code != "" {
+			b.WriteString(fmt.Sprintf("   > %s\n", comment.Code))
+		}
+		b.WriteString("   " + strings.ReplaceAll(strings.TrimSpace(comment.Text), "\n", "\n   "))
+	}
+	return b.String()
+}
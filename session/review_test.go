@@ -0,0 +1,18 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatReviewComments(t *testing.T) {
+	prompt := FormatReviewComments([]ReviewComment{
+		{Path: "main.go", Line: 3, Code: "+package main", Text: "Keep the old name."},
+		{Path: "README.md", Line: 1, Code: "@@ -0,0 +1 @@", Text: "Explain\nthe setup"},
+	})
+
+	assert.Equal(t, "Please address the following review comments on your changes:\n\n"+
+		"1. main.go:3\n   > +package main\n   Keep the old name.\n\n"+
+		"2. README.md:1\n   > @@ -0,0 +1 @@\n   Explain\n   the setup", prompt)
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/tmux"
+	"claude-squad/session/transcript"
+	"strings"
+)
+
+// SearchSource is where the text of a SearchDocument comes from.
+type SearchSource int
+
+const (
+	// SearchSourcePane is the tmux pane of a running instance, including its scrollback.
+	SearchSourcePane SearchSource = iota
+	// SearchSourceTranscript is the recorded transcript of an instance, which also covers output that scrolled
+	// out of the pane and instances that are paused.
+	SearchSourceTranscript
+)
+
+func (s SearchSource) String() string {
+	if s == SearchSourceTranscript {
+		return "transcript"
+	}
+	return "pane"
+}
+
+// SearchDocument is the searchable text of an instance.
+type SearchDocument struct {
+	Instance *Instance
+	Source   SearchSource
+	Lines    []string
+}
+
+// SearchMatch is a line of a document which contains the query.
+type SearchMatch struct {
+	Document *SearchDocument
+	// Line is the index of the line in the document.
+	Line int
+}
+
+// Text returns the matching line.
+func (m SearchMatch) Text() string {
+	return m.Document.Lines[m.Line]
+}
+
+// Context returns up to n lines before and after the matching line.
+func (m SearchMatch) Context(n int) (before, after []string) {
+	lines := m.Document.Lines
+	return lines[max(m.Line-n, 0):m.Line], lines[m.Line+1 : min(m.Line+1+n, len(lines))]
+}
+
+// CollectSearchDocuments captures the pane of every running instance and loads the transcript of every instance,
+// so they can be searched repeatedly while the query is typed. Instances without either are skipped.
+func CollectSearchDocuments(instances []*Instance) []*SearchDocument {
+	var documents []*SearchDocument
+	for _, instance := range instances {
+		if !instance.Started() {
+			continue
+		}
+		if !instance.Paused() {
+			content, err := instance.PreviewFullHistory()
+			if err != nil {
+				log.WarningLog.Printf("could not capture pane of %s for search: %v", instance.Title, err)
+			} else if content != "" {
+				documents = append(documents, &SearchDocument{
+					Instance: instance,
+					Source:   SearchSourcePane,
+					Lines:    strings.Split(tmux.StripANSI(content), "\n"),
+				})
+			}
+		}
+		if t, err := transcript.Load(instance.Title); err == nil {
+			documents = append(documents, &SearchDocument{
+				Instance: instance,
+				Source:   SearchSourceTranscript,
+				Lines:    strings.Split(t.Text(), "\n"),
+			})
+		}
+	}
+	return documents
+}
+
+// Search returns the lines of the documents which contain the query, ignoring case, with at most limit matches
+// per document. The latest matches of a document come first. Transcript lines which are also in the pane of the
+// same instance are skipped, since the pane match can be shown in place.
+func Search(documents []*SearchDocument, query string, limit int) []SearchMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var matches []SearchMatch
+	inPane := make(map[*Instance]map[string]bool)
+	for _, document := range documents {
+		if document.Source == SearchSourcePane {
+			inPane[document.Instance] = make(map[string]bool)
+		}
+		found := 0
+		for i := len(document.Lines) - 1; i >= 0 && found < limit; i-- {
+			line := document.Lines[i]
+			if !strings.Contains(strings.ToLower(line), query) {
+				continue
+			}
+			trimmed := strings.TrimSpace(line)
+			if document.Source == SearchSourcePane {
+				inPane[document.Instance][trimmed] = true
+			} else if inPane[document.Instance][trimmed] {
+				continue
+			}
+			matches = append(matches, SearchMatch{Document: document, Line: i})
+			found++
+		}
+	}
+	return matches
+}
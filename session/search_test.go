@@ -0,0 +1,42 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	api := &Instance{Title: "api"}
+	web := &Instance{Title: "web"}
+	documents := []*SearchDocument{
+		{Instance: api, Source: SearchSourcePane, Lines: []string{
+			"$ make migrate", "Migration failed: column exists", "  retrying", "Migration failed: column exists",
+		}},
+		{Instance: api, Source: SearchSourceTranscript, Lines: []string{
+			"old output", "migration FAILED: lock timeout", "Migration failed: column exists",
+		}},
+		{Instance: web, Source: SearchSourcePane, Lines: []string{"all good"}},
+	}
+
+	matches := Search(documents, "  migration failed ", 10)
+	require.Len(t, matches, 3)
+
+	// The latest pane matches come first, and transcript lines which are still in the pane are skipped.
+	assert.Equal(t, SearchSourcePane, matches[0].Document.Source)
+	assert.Equal(t, 3, matches[0].Line)
+	assert.Equal(t, 1, matches[1].Line)
+	assert.Equal(t, SearchSourceTranscript, matches[2].Document.Source)
+	assert.Equal(t, "migration FAILED: lock timeout", matches[2].Text())
+
+	before, after := matches[1].Context(1)
+	assert.Equal(t, []string{"$ make migrate"}, before)
+	assert.Equal(t, []string{"  retrying"}, after)
+	before, after = matches[0].Context(2)
+	assert.Equal(t, []string{"Migration failed: column exists", "  retrying"}, before)
+	assert.Empty(t, after)
+
+	assert.Len(t, Search(documents, "migration failed", 1), 2, "the limit applies per document")
+	assert.Empty(t, Search(documents, " ", 10))
+}
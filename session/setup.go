@@ -0,0 +1,190 @@
+package session
+
+import (
+	"bufio"
+	"claude-squad/config"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// setupOutputLines limits how much of the setup output an instance keeps to show while it's loading.
+const setupOutputLines = 200
+
+// setupWorktree copies and links the files of the repository config from the main checkout into a new worktree,
+// then runs the setup commands in it. Every line of output is passed to output.
+func setupWorktree(cfg *config.RepoConfig, repoPath, worktreePath string, output func(line string)) error {
+	for _, path := range cfg.Copy {
+		source, target := filepath.Join(repoPath, path), filepath.Join(worktreePath, path)
+		if _, err := os.Lstat(source); os.IsNotExist(err) {
+			output(fmt.Sprintf("skipping %s: not in %s", path, repoPath))
+			continue
+		}
+		output("copying " + path)
+		if err := copyPath(source, target); err != nil {
+			return fmt.Errorf("failed to copy %s into the worktree: %w", path, err)
+		}
+	}
+
+	for _, path := range cfg.Symlink {
+		source, target := filepath.Join(repoPath, path), filepath.Join(worktreePath, path)
+		if _, err := os.Lstat(source); os.IsNotExist(err) {
+			output(fmt.Sprintf("skipping %s: not in %s", path, repoPath))
+			continue
+		}
+		output("linking " + path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to link %s into the worktree: %w", path, err)
+		}
+		// Tracked files are checked out already, and are replaced by the link.
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("failed to link %s into the worktree: %w", path, err)
+		}
+		if err := os.Symlink(source, target); err != nil {
+			return fmt.Errorf("failed to link %s into the worktree: %w", path, err)
+		}
+	}
+
+	for _, command := range cfg.Setup {
+		output("$ " + command)
+		if err := runCommand(command, worktreePath, cfg.Env, output); err != nil {
+			return fmt.Errorf("setup %w", err)
+		}
+	}
+	return nil
+}
+
+// runCommand runs the command with the shell in the worktree, with the environment of the repository config.
+func runCommand(command, worktreePath string, env map[string]string, output func(line string)) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	cmd.Dir = worktreePath
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+env[key])
+	}
+
+	reader, writer := io.Pipe()
+	cmd.Stdout, cmd.Stderr = writer, writer
+	// The last lines go into the error, since they usually say what went wrong.
+	var tail []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := scanner.Text()
+			output(line)
+			tail = append(tail, line)
+			if len(tail) > 5 {
+				tail = tail[1:]
+			}
+		}
+		// Keep draining, so the command doesn't block on a line which is too long.
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	err := cmd.Run()
+	_ = writer.Close()
+	wg.Wait()
+	if err != nil {
+		if len(tail) > 0 {
+			return fmt.Errorf("command %q failed: %w\n%s", command, err, strings.Join(tail, "\n"))
+		}
+		return fmt.Errorf("command %q failed: %w", command, err)
+	}
+	return nil
+}
+
+// copyPath copies a file, a symlink or a directory with everything in it.
+func copyPath(source, target string) error {
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(dest, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return err
+			}
+			_ = os.Remove(dest)
+			return os.Symlink(link, dest)
+		case info.Mode().IsRegular():
+			return copyFile(path, dest, info.Mode().Perm())
+		}
+		// Sockets, devices and the like can't be copied.
+		return nil
+	})
+}
+
+func copyFile(source, target string, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// setupLog keeps the last lines of the setup output of an instance. The setup runs while the UI shows the output.
+type setupLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *setupLog) add(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+	if len(l.lines) > setupOutputLines {
+		l.lines = l.lines[len(l.lines)-setupOutputLines:]
+	}
+}
+
+func (l *setupLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"claude-squad/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupWorktree(t *testing.T) {
+	repo, worktree := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".env"), []byte("SECRET=1\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "config", "local"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "config", "local", "dev.json"), []byte("{}"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "node_modules", "left-pad"), 0755))
+	// A tracked file in the worktree is replaced by the link.
+	require.NoError(t, os.MkdirAll(filepath.Join(worktree, "node_modules"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "node_modules", ".keep"), nil, 0644))
+
+	cfg := &config.RepoConfig{
+		Copy:    []string{".env", "config/local", "missing"},
+		Symlink: []string{"node_modules"},
+		Setup:   []string{`echo "port $PORT" > generated.txt`, "echo done"},
+		Env:     map[string]string{"PORT": "3001"},
+	}
+	var output setupLog
+	require.NoError(t, setupWorktree(cfg, repo, worktree, output.add))
+
+	data, err := os.ReadFile(filepath.Join(worktree, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "SECRET=1\n", string(data))
+	info, err := os.Stat(filepath.Join(worktree, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.FileExists(t, filepath.Join(worktree, "config", "local", "dev.json"))
+
+	link, err := os.Readlink(filepath.Join(worktree, "node_modules"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "node_modules"), link)
+
+	data, err = os.ReadFile(filepath.Join(worktree, "generated.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "port 3001\n", string(data), "setup commands run in the worktree with the env")
+
+	assert.Equal(t, "copying .env\n"+
+		"copying config/local\n"+
+		"skipping missing: not in "+repo+"\n"+
+		"linking node_modules\n"+
+		`$ echo "port $PORT" > generated.txt`+"\n"+
+		"$ echo done\n"+
+		"done", output.String())
+}
+
+func TestSetupWorktreeFailure(t *testing.T) {
+	cfg := &config.RepoConfig{Setup: []string{"echo installing; echo broken >&2; exit 3", "touch never"}}
+	worktree := t.TempDir()
+	var output setupLog
+	err := setupWorktree(cfg, t.TempDir(), worktree, output.add)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Contains(t, err.Error(), "installing\nbroken", "the error ends with the last lines of output")
+	assert.NoFileExists(t, filepath.Join(worktree, "never"), "later commands don't run")
+}
+
+func TestSetupLogKeepsLastLines(t *testing.T) {
+	var output setupLog
+	for i := 0; i < setupOutputLines+10; i++ {
+		output.add("line")
+	}
+	output.add("last")
+	assert.Len(t, output.lines, setupOutputLines)
+	assert.Equal(t, "last", output.lines[setupOutputLines-1])
+}
@@ -18,10 +18,19 @@ type InstanceData struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	AutoYes   bool      `json:"auto_yes"`
+	Prompt    string    `json:"prompt,omitempty"`
+	Group     string    `json:"group,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	// LastActivity is when the pane or the diff of the instance last changed.
+	LastActivity time.Time `json:"last_activity,omitempty"`
+	// PausedAt is when the instance was paused.
+	PausedAt time.Time `json:"paused_at,omitempty"`
 
 	Program   string          `json:"program"`
 	Worktree  GitWorktreeData `json:"worktree"`
 	DiffStats DiffStatsData   `json:"diff_stats"`
+
+	ReviewComments []ReviewComment `json:"review_comments,omitempty"`
 }
 
 // GitWorktreeData represents the serializable data of a GitWorktree
@@ -71,14 +80,21 @@ func (s *Storage) SaveInstances(instances []*Instance) error {
 	return s.state.SaveInstances(jsonData)
 }
 
-// LoadInstances loads the list of instances from disk
-func (s *Storage) LoadInstances() ([]*Instance, error) {
-	jsonData := s.state.GetInstances()
-
+// LoadInstanceData loads the stored data of the instances without starting them
+func (s *Storage) LoadInstanceData() ([]InstanceData, error) {
 	var instancesData []InstanceData
-	if err := json.Unmarshal(jsonData, &instancesData); err != nil {
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
 	}
+	return instancesData, nil
+}
+
+// LoadInstances loads the list of instances from disk
+func (s *Storage) LoadInstances() ([]*Instance, error) {
+	instancesData, err := s.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
 
 	instances := make([]*Instance, len(instancesData))
 	for i, data := range instancesData {
@@ -92,19 +108,18 @@ func (s *Storage) LoadInstances() ([]*Instance, error) {
 	return instances, nil
 }
 
-// DeleteInstance removes an instance from storage
+// DeleteInstance removes an instance from storage. The other instances aren't loaded, so they aren't started.
 func (s *Storage) DeleteInstance(title string) error {
-	instances, err := s.LoadInstances()
+	instancesData, err := s.LoadInstanceData()
 	if err != nil {
 		return fmt.Errorf("failed to load instances: %w", err)
 	}
 
 	found := false
-	newInstances := make([]*Instance, 0)
-	for _, instance := range instances {
-		data := instance.ToInstanceData()
+	kept := make([]InstanceData, 0, len(instancesData))
+	for _, data := range instancesData {
 		if data.Title != title {
-			newInstances = append(newInstances, instance)
+			kept = append(kept, data)
 		} else {
 			found = true
 		}
@@ -114,7 +129,11 @@ func (s *Storage) DeleteInstance(title string) error {
 		return fmt.Errorf("instance not found: %s", title)
 	}
 
-	return s.SaveInstances(newInstances)
+	jsonData, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	return s.state.SaveInstances(jsonData)
 }
 
 // UpdateInstance updates an existing instance in storage
@@ -0,0 +1,149 @@
+package tmux
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// AttachResult tells how an attached session was left.
+type AttachResult int
+
+const (
+	// AttachDetached means the detach key was pressed, or the session ended.
+	AttachDetached AttachResult = iota
+	// AttachNext means the next session should be attached.
+	AttachNext
+	// AttachPrevious means the previous session should be attached.
+	AttachPrevious
+)
+
+// attachKey is a key sequence handled while attached instead of being sent to the session.
+type attachKey struct {
+	name   string
+	seq    []byte
+	result AttachResult
+}
+
+var (
+	attachKeysMu sync.RWMutex
+	attachKeys   = mustParseAttachKeys(config.AttachConfig{})
+)
+
+// SetAttachKeys sets the keys handled while attached to a session.
+func SetAttachKeys(cfg config.AttachConfig) error {
+	keys, err := parseAttachKeys(cfg)
+	if err != nil {
+		return err
+	}
+	attachKeysMu.Lock()
+	defer attachKeysMu.Unlock()
+	attachKeys = keys
+	return nil
+}
+
+// AttachKeyNames returns how the detach, next and previous keys are written in the config. Keys which aren't set
+// are empty.
+func AttachKeyNames() (detach, next, previous string) {
+	attachKeysMu.RLock()
+	defer attachKeysMu.RUnlock()
+	for _, key := range attachKeys {
+		switch key.result {
+		case AttachDetached:
+			detach = key.name
+		case AttachNext:
+			next = key.name
+		case AttachPrevious:
+			previous = key.name
+		}
+	}
+	return detach, next, previous
+}
+
+func currentAttachKeys() []attachKey {
+	attachKeysMu.RLock()
+	defer attachKeysMu.RUnlock()
+	return attachKeys
+}
+
+func parseAttachKeys(cfg config.AttachConfig) ([]attachKey, error) {
+	cfg = cfg.WithDefaults()
+	keys := []attachKey{{name: cfg.DetachKey, result: AttachDetached}}
+	if cfg.NextKey != "" {
+		keys = append(keys, attachKey{name: cfg.NextKey, result: AttachNext})
+	}
+	if cfg.PreviousKey != "" {
+		keys = append(keys, attachKey{name: cfg.PreviousKey, result: AttachPrevious})
+	}
+	for i := range keys {
+		seq, err := config.ParseKeySequence(keys[i].name)
+		if err != nil {
+			return nil, err
+		}
+		keys[i].seq = seq
+		for _, other := range keys[:i] {
+			if bytes.HasPrefix(seq, other.seq) || bytes.HasPrefix(other.seq, seq) {
+				return nil, fmt.Errorf("keys %q and %q overlap", other.name, keys[i].name)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func mustParseAttachKeys(cfg config.AttachConfig) []attachKey {
+	keys, err := parseAttachKeys(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+var (
+	// terminalResponse matches the replies of terminals to queries, which may arrive after attaching when they
+	// were asked for before. They would end up as input of the agent. They can't be typed on a keyboard:
+	// device attributes, mode reports, OSC replies such as colors, and terminal version reports.
+	terminalResponse = regexp.MustCompile(
+		`\x1b\[[?>][0-9;]*c|\x1b\[\?[0-9;]*\$y|\x1b\][0-9]+;[^\x07\x1b]*(\x07|\x1b\\)|\x1bP[>!]\|[^\x1b]*\x1b\\`)
+	// partialTerminalResponse matches the start of a terminal response at the end of the input, which is
+	// completed by the next read.
+	partialTerminalResponse = regexp.MustCompile(
+		`\x1b(\[[?>][0-9;$]*|\][0-9]+(;[^\x07\x1b]*)?\x1b?|P[>!](\|[^\x1b]*)?\x1b?)$`)
+)
+
+// maxPendingInput limits how much input is held back waiting for the rest of a terminal response.
+const maxPendingInput = 256
+
+// attachInput filters the input read from the terminal while attached. It drops terminal responses and
+// recognizes the attach keys. A key is only recognized when it's read on its own, so that pasted text is sent to
+// the session as is.
+type attachInput struct {
+	keys []attachKey
+	// pending is input held back because it may be the start of a terminal response or of a key.
+	pending []byte
+}
+
+// Feed returns the input to send to the session, and the key which was pressed, if any.
+func (in *attachInput) Feed(data []byte) ([]byte, *attachKey) {
+	data = append(in.pending, data...)
+	in.pending = nil
+
+	data = terminalResponse.ReplaceAll(data, nil)
+	if loc := partialTerminalResponse.FindIndex(data); loc != nil && len(data)-loc[0] < maxPendingInput {
+		in.pending = append([]byte(nil), data[loc[0]:]...)
+		return data[:loc[0]], nil
+	}
+
+	for i := range in.keys {
+		key := &in.keys[i]
+		if bytes.Equal(data, key.seq) {
+			return nil, key
+		}
+		if len(data) > 0 && len(data) < len(key.seq) && bytes.HasPrefix(key.seq, data) {
+			in.pending = append([]byte(nil), data...)
+			return nil, nil
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+package tmux
+
+import (
+	"claude-squad/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachInput(t *testing.T) {
+	keys, err := parseAttachKeys(config.AttachConfig{DetachKey: "ctrl-b d", NextKey: "ctrl-]"})
+	require.NoError(t, err)
+	input := &attachInput{keys: keys}
+
+	forward, key := input.Feed([]byte("\x1b[?62;22c\x1b[>0;95;0chello"))
+	assert.Equal(t, "hello", string(forward), "device attributes are dropped")
+	assert.Nil(t, key)
+
+	// A color report split across reads.
+	forward, _ = input.Feed([]byte("ab\x1b]11;rgb:f8f8/f8f8"))
+	assert.Equal(t, "ab", string(forward))
+	forward, _ = input.Feed([]byte("/f8f8\x1b\\cd"))
+	assert.Equal(t, "cd", string(forward))
+
+	// Keys typed on their own are passed through, including escape sequences.
+	for _, typed := range []string{"\x1b", "\x1b[A", "\x1b]", "\r", "\x03"} {
+		forward, key = input.Feed([]byte(typed))
+		assert.Equal(t, typed, string(forward))
+		assert.Nil(t, key)
+	}
+
+	forward, key = input.Feed([]byte{0x02})
+	assert.Empty(t, forward, "the start of the detach key is held back")
+	assert.Nil(t, key)
+	forward, key = input.Feed([]byte("d"))
+	assert.Empty(t, forward)
+	require.NotNil(t, key)
+	assert.Equal(t, AttachDetached, key.result)
+
+	forward, key = input.Feed([]byte{0x02})
+	assert.Nil(t, key)
+	forward, _ = input.Feed([]byte("c"))
+	assert.Equal(t, "\x02c", string(forward), "other keys after the prefix are sent to the session")
+
+	_, key = input.Feed([]byte{0x1d})
+	require.NotNil(t, key)
+	assert.Equal(t, AttachNext, key.result)
+
+	forward, key = input.Feed([]byte("paste with \x1d inside"))
+	assert.Nil(t, key, "keys are only recognized on their own")
+	assert.Equal(t, "paste with \x1d inside", string(forward))
+}
+
+func TestParseAttachKeys(t *testing.T) {
+	_, err := parseAttachKeys(config.AttachConfig{DetachKey: "ctrl-b", NextKey: "ctrl-b n"})
+	assert.Error(t, err, "the detach key is a prefix of the next key")
+
+	_, err = parseAttachKeys(config.AttachConfig{PreviousKey: "nope-x"})
+	assert.Error(t, err)
+
+	require.NoError(t, SetAttachKeys(config.AttachConfig{NextKey: "alt-n"}))
+	defer func() { require.NoError(t, SetAttachKeys(config.AttachConfig{})) }()
+	detach, next, previous := AttachKeyNames()
+	assert.Equal(t, []string{"ctrl-q", "alt-n", ""}, []string{detach, next, previous})
+
+	// Only the detach key is handled by default.
+	keys, err := parseAttachKeys(config.AttachConfig{})
+	require.NoError(t, err)
+	input := &attachInput{keys: keys}
+	for _, typed := range []string{"\x1d", "\x1c"} {
+		forward, key := input.Feed([]byte(typed))
+		assert.Equal(t, typed, string(forward))
+		assert.Nil(t, key)
+	}
+	_, key := input.Feed([]byte{0x11})
+	require.NotNil(t, key)
+	assert.Equal(t, AttachDetached, key.result)
+}
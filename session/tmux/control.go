@@ -0,0 +1,109 @@
+package tmux
+
+import (
+	"bufio"
+	"bytes"
+	"claude-squad/cmd"
+	"claude-squad/log"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// controlClient is a tmux control mode client attached to one session. tmux sends it a %output notification
+// whenever a pane of the session prints something, so the pane only has to be captured again after that.
+//
+// tmux only notifies control clients about the panes of the session they are attached to, so there is one
+// client per session rather than per server. Control clients don't affect the size of the windows.
+type controlClient struct {
+	stdin io.WriteCloser
+	done  chan struct{}
+
+	mu sync.Mutex
+	// outputs counts the %output notifications. It only grows, so a changed count means new output.
+	outputs uint64
+}
+
+// startControlClient attaches a control mode client to the session. It returns an error if the executor can't
+// stream commands, in which case the caller should poll instead.
+func startControlClient(cmdExec cmd.Executor, sessionName string) (*controlClient, error) {
+	streamer, ok := cmdExec.(cmd.Streamer)
+	if !ok {
+		return nil, fmt.Errorf("executor does not support streaming")
+	}
+	stdin, stdout, err := streamer.Stream(exec.Command("tmux", "-C", "attach-session", "-t", sessionName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tmux control client: %w", err)
+	}
+	c := &controlClient{stdin: stdin, done: make(chan struct{})}
+	go c.read(stdout, sessionName)
+	return c, nil
+}
+
+// read handles the notifications until the client exits. Control mode escapes the output, so every notification
+// is a single line.
+func (c *controlClient) read(stdout io.ReadCloser, sessionName string) {
+	defer close(c.done)
+	defer func() {
+		if err := stdout.Close(); err != nil {
+			log.InfoLog.Printf("tmux control client of %s exited: %v", sessionName, err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case bytes.HasPrefix(line, []byte("%output ")), bytes.HasPrefix(line, []byte("%extended-output ")):
+			c.mu.Lock()
+			c.outputs++
+			c.mu.Unlock()
+		case bytes.HasPrefix(line, []byte("%session-changed ")):
+			// With detach-on-destroy off, tmux moves the client to another session when its session is killed.
+			// Its output must not count for this session.
+			if fields := bytes.Fields(line); len(fields) == 3 && string(fields[2]) != sessionName {
+				_ = c.stdin.Close()
+			}
+		case bytes.HasPrefix(line, []byte("%exit")):
+			_ = c.stdin.Close()
+		}
+	}
+}
+
+// outputCount returns the number of %output notifications so far, and false if the client exited, in which case
+// the count says nothing about the pane anymore.
+func (c *controlClient) outputCount() (uint64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	select {
+	case <-c.done:
+		return 0, false
+	default:
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.outputs, true
+}
+
+// alive returns true if the client hasn't exited.
+func (c *controlClient) alive() bool {
+	_, ok := c.outputCount()
+	return ok
+}
+
+// Close detaches the client by closing its stdin and waits briefly for it to exit.
+func (c *controlClient) Close() {
+	if c == nil {
+		return
+	}
+	_ = c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(time.Second):
+		log.WarningLog.Printf("tmux control client did not exit")
+	}
+}
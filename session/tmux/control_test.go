@@ -0,0 +1,103 @@
+package tmux
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"claude-squad/cmd/cmd_test"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeControlMode is a tmux control mode client which prints whatever the test writes.
+type fakeControlMode struct {
+	stdinR, stdoutR *io.PipeReader
+	stdinW, stdoutW *io.PipeWriter
+}
+
+func newFakeControlMode() *fakeControlMode {
+	f := &fakeControlMode{}
+	f.stdinR, f.stdinW = io.Pipe()
+	f.stdoutR, f.stdoutW = io.Pipe()
+	// Like tmux, exit when stdin is closed.
+	go func() {
+		_, _ = io.Copy(io.Discard, f.stdinR)
+		_ = f.stdoutW.Close()
+	}()
+	return f
+}
+
+func (f *fakeControlMode) print(t *testing.T, line string) {
+	_, err := f.stdoutW.Write([]byte(line + "\n"))
+	require.NoError(t, err)
+}
+
+func TestControlClientAvoidsCapturingUnchangedPane(t *testing.T) {
+	control := newFakeControlMode()
+	var captures atomic.Int32
+	var streamed string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			if strings.Contains(cmd.String(), "capture-pane") {
+				captures.Add(1)
+			}
+			return []byte("content"), nil
+		},
+		StreamFunc: func(cmd *exec.Cmd) (io.WriteCloser, io.ReadCloser, error) {
+			streamed = strings.Join(cmd.Args, " ")
+			return control.stdinW, control.stdoutR, nil
+		},
+	}
+
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	require.NoError(t, session.Restore())
+	require.Equal(t, "tmux -C attach-session -t claudesquad_test-session", streamed)
+
+	session.HasUpdated()
+	_, _ = session.CapturePaneContent()
+	session.HasUpdated()
+	require.Equal(t, int32(1), captures.Load(), "the pane didn't print anything")
+
+	control.print(t, `%output %0 hello\015\012`)
+	require.Eventually(t, func() bool {
+		_, _ = session.CapturePaneContent()
+		return captures.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+	_, _ = session.CapturePaneContent()
+	require.Equal(t, int32(2), captures.Load())
+
+	// Without the control client, the pane is polled again.
+	control.print(t, "%exit")
+	require.Eventually(t, func() bool { return !session.control.alive() }, time.Second, 10*time.Millisecond)
+	_, _ = session.CapturePaneContent()
+	_, _ = session.CapturePaneContent()
+	require.Equal(t, int32(4), captures.Load())
+}
+
+func TestControlClientIgnoresOtherSessions(t *testing.T) {
+	control := newFakeControlMode()
+	cmdExec := cmd_test.MockCmdExec{
+		StreamFunc: func(cmd *exec.Cmd) (io.WriteCloser, io.ReadCloser, error) {
+			return control.stdinW, control.stdoutR, nil
+		},
+	}
+
+	client, err := startControlClient(cmdExec, "claudesquad_a")
+	require.NoError(t, err)
+	control.print(t, "%session-changed $1 claudesquad_a")
+	control.print(t, "%output %0 x")
+	require.Eventually(t, func() bool {
+		outputs, _ := client.outputCount()
+		return outputs == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// tmux moves the client to another session when its session is killed.
+	control.print(t, "%session-changed $2 claudesquad_b")
+	require.Eventually(t, func() bool { return !client.alive() }, time.Second, 10*time.Millisecond)
+	client.Close()
+}
@@ -3,7 +3,9 @@ package tmux
 import (
 	"bytes"
 	"claude-squad/cmd"
+	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/session/policy"
 	"context"
 	"crypto/sha256"
 	"errors"
@@ -12,6 +14,8 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,22 +23,28 @@ import (
 	"github.com/creack/pty"
 )
 
-const ProgramClaude = "claude"
-
-const ProgramAider = "aider"
-const ProgramGemini = "gemini"
-
 // TmuxSession represents a managed tmux session
 type TmuxSession struct {
 	// Initialized by NewTmuxSession
 	//
 	// The name of the tmux session and the sanitized name used for tmux commands.
+	name          string
 	sanitizedName string
 	program       string
+	// profile describes how to launch the program and read its screen.
+	profile config.AgentProfile
+	// patterns are the compiled patterns of the profile.
+	patterns *agentPatterns
 	// ptyFactory is used to create a PTY for the tmux session.
 	ptyFactory PtyFactory
 	// cmdExec is used to execute commands in the tmux session.
 	cmdExec cmd.Executor
+	// recorder is the shell command the output of the pane is piped to. See SetRecorder.
+	recorder string
+	// extraWindows are created next to the agent by Start. See SetWindows.
+	extraWindows []config.WindowConfig
+	// env are environment variables of the session in addition to those of the profile. See SetEnv.
+	env map[string]string
 
 	// Initialized by Start or Restore
 	//
@@ -44,6 +54,15 @@ type TmuxSession struct {
 	ptmx *os.File
 	// monitor monitors the tmux pane content and sends signals to the UI when it's status changes
 	monitor *statusMonitor
+	// control is notified of the output of the pane, so the pane is only captured again after it changed. It's
+	// nil if the control client couldn't be started, in which case the pane is captured on every call.
+	control *controlClient
+	// capture caches the last captured pane content while the control client is running.
+	capture paneCapture
+	// windows are the windows of the session, starting with the agent, and selectedWindow is the index of the
+	// one shown by the preview and when attaching.
+	windows        []tmuxWindow
+	selectedWindow int
 
 	// Initialized by Attach
 	// Deinitilaized by Detach
@@ -55,6 +74,73 @@ type TmuxSession struct {
 	ctx    context.Context
 	cancel func()
 	wg     *sync.WaitGroup
+	// attachResult tells how the session was left the last time it was attached.
+	attachResult AttachResult
+
+	// exited is true if the pane showed one of the exit patterns of the profile on the last check.
+	exited bool
+}
+
+// agentPatterns holds the compiled patterns of an agent profile.
+type agentPatterns struct {
+	trust    *regexp.Regexp
+	request  *regexp.Regexp
+	approval []*regexp.Regexp
+	busy     []*regexp.Regexp
+	idle     []*regexp.Regexp
+	exit     []*regexp.Regexp
+}
+
+// compileAgentPatterns compiles the patterns of the profile. Invalid patterns are logged and ignored, since
+// profiles are validated when they are loaded.
+func compileAgentPatterns(profile config.AgentProfile) *agentPatterns {
+	compile := func(patterns []string) []*regexp.Regexp {
+		var compiled []*regexp.Regexp
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				log.ErrorLog.Printf("invalid pattern in agent profile %s: %v", profile.Name, err)
+				continue
+			}
+			compiled = append(compiled, re)
+		}
+		return compiled
+	}
+
+	patterns := &agentPatterns{
+		approval: compile(profile.ApprovalPatterns),
+		busy:     compile(profile.BusyPatterns),
+		idle:     compile(profile.IdlePatterns),
+		exit:     compile(profile.ExitPatterns),
+	}
+	if profile.TrustPattern != "" {
+		if trust := compile([]string{profile.TrustPattern}); len(trust) > 0 {
+			patterns.trust = trust[0]
+		}
+	}
+	if profile.RequestPattern != "" {
+		if request := compile([]string{profile.RequestPattern}); len(request) > 0 {
+			patterns.request = request[0]
+		}
+	}
+	return patterns
+}
+
+// matchAny returns true if any of the patterns matches the content.
+func matchAny(patterns []*regexp.Regexp, content string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
+
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// StripANSI removes the color codes from the captured pane, so that patterns don't have to account for them.
+func StripANSI(content string) string {
+	return ansiEscapeRegex.ReplaceAllString(content, "")
 }
 
 const TmuxPrefix = "claudesquad_"
@@ -78,9 +164,13 @@ func NewTmuxSessionWithDeps(name string, program string, ptyFactory PtyFactory,
 }
 
 func newTmuxSession(name string, program string, ptyFactory PtyFactory, cmdExec cmd.Executor) *TmuxSession {
+	profile := config.AgentProfileFor(program)
 	return &TmuxSession{
+		name:          name,
 		sanitizedName: toClaudeSquadTmuxName(name),
 		program:       program,
+		profile:       profile,
+		patterns:      compileAgentPatterns(profile),
 		ptyFactory:    ptyFactory,
 		cmdExec:       cmdExec,
 	}
@@ -94,8 +184,24 @@ func (t *TmuxSession) Start(workDir string) error {
 		return fmt.Errorf("tmux session already exists: %s", t.sanitizedName)
 	}
 
-	// Create a new detached tmux session and start claude in it
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", t.sanitizedName, "-c", workDir, t.program)
+	// Create a new detached tmux session and start the agent in it
+	args := []string{"new-session", "-d", "-s", t.sanitizedName, "-c", workDir}
+	env := make(map[string]string, len(t.profile.Env)+len(t.env))
+	for key, value := range t.profile.Env {
+		env[key] = value
+	}
+	for key, value := range t.env {
+		env[key] = value
+	}
+	envKeys := make([]string, 0, len(env))
+	for key := range env {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+	for _, key := range envKeys {
+		args = append(args, "-e", key+"="+env[key])
+	}
+	cmd := exec.Command("tmux", append(args, t.profile.LaunchCommand(t.program))...)
 
 	ptmx, err := t.ptyFactory.Start(cmd)
 	if err != nil {
@@ -135,6 +241,8 @@ func (t *TmuxSession) Start(workDir string) error {
 		log.InfoLog.Printf("Warning: failed to set history-limit for session %s: %v", t.sanitizedName, err)
 	}
 
+	t.startWindows(workDir)
+
 	err = t.Restore()
 	if err != nil {
 		if cleanupErr := t.Close(); cleanupErr != nil {
@@ -143,45 +251,44 @@ func (t *TmuxSession) Start(workDir string) error {
 		return fmt.Errorf("error restoring tmux session: %w", err)
 	}
 
-	if strings.HasSuffix(t.program, ProgramClaude) || strings.HasSuffix(t.program, ProgramAider) || strings.HasSuffix(t.program, ProgramGemini) {
-		searchString := "Do you trust the files in this folder?"
-		tapFunc := t.TapEnter
-		maxWaitTime := 30 * time.Second // Much longer timeout for slower systems
-		if !strings.HasSuffix(t.program, ProgramClaude) {
-			searchString = "Open documentation url for more info"
-			tapFunc = t.TapDAndEnter
-			maxWaitTime = 45 * time.Second // Aider/Gemini take longer to start
-		}
+	if t.patterns.trust != nil {
+		t.acceptTrustScreen()
+	}
+	return nil
+}
 
-		// Deal with "do you trust the files" screen by sending an enter keystroke.
-		// Use exponential backoff with longer timeout for reliability on slow systems
-		startTime := time.Now()
-		sleepDuration := 100 * time.Millisecond
-		attempt := 0
+// acceptTrustScreen waits for the screen asking whether to trust the folder and answers it with the trust keys of
+// the profile. It gives up after the trust timeout, since the agent may have been trusted before.
+func (t *TmuxSession) acceptTrustScreen() {
+	keys := t.profile.TrustKeys
+	if keys == "" {
+		keys = "\r"
+	}
+	maxWaitTime := 30 * time.Second // Much longer timeout for slower systems
+	if t.profile.TrustTimeout > 0 {
+		maxWaitTime = time.Duration(t.profile.TrustTimeout) * time.Second
+	}
 
-		for time.Since(startTime) < maxWaitTime {
-			attempt++
-			time.Sleep(sleepDuration)
-			content, err := t.CapturePaneContent()
-			if err != nil {
-				// Session might not be ready yet, continue waiting
-			} else {
-				if strings.Contains(content, searchString) {
-					if err := tapFunc(); err != nil {
-						log.ErrorLog.Printf("could not tap enter on trust screen: %v", err)
-					}
-					break
-				}
+	// Use exponential backoff with longer timeout for reliability on slow systems
+	startTime := time.Now()
+	sleepDuration := 100 * time.Millisecond
+	for time.Since(startTime) < maxWaitTime {
+		time.Sleep(sleepDuration)
+		content, err := t.CapturePaneContent()
+		// If there is an error, the session might not be ready yet, so continue waiting
+		if err == nil && t.patterns.trust.MatchString(StripANSI(content)) {
+			if err := t.SendKeys(keys); err != nil {
+				log.ErrorLog.Printf("could not answer trust screen: %v", err)
 			}
+			return
+		}
 
-			// Exponential backoff with cap at 1 second
-			sleepDuration = time.Duration(float64(sleepDuration) * 1.2)
-			if sleepDuration > time.Second {
-				sleepDuration = time.Second
-			}
+		// Exponential backoff with cap at 1 second
+		sleepDuration = time.Duration(float64(sleepDuration) * 1.2)
+		if sleepDuration > time.Second {
+			sleepDuration = time.Second
 		}
 	}
-	return nil
 }
 
 // Restore attaches to an existing session and restores the window size
@@ -192,12 +299,82 @@ func (t *TmuxSession) Restore() error {
 	}
 	t.ptmx = ptmx
 	t.monitor = newStatusMonitor()
+	t.loadWindows()
+	t.startRecorder()
+	t.startControl()
+	return nil
+}
+
+// startControl starts the control client unless it's still running. If it can't be started, the pane is polled.
+func (t *TmuxSession) startControl() {
+	if t.control.alive() {
+		return
+	}
+	control, err := startControlClient(t.cmdExec, t.sanitizedName)
+	if err != nil {
+		log.InfoLog.Printf("polling session %s: %v", t.sanitizedName, err)
+		control = nil
+	}
+	t.control = control
+	t.capture.invalidate()
+}
+
+// Rename renames the tmux session, which keeps running if it is.
+func (t *TmuxSession) Rename(name string) error {
+	sanitizedName := toClaudeSquadTmuxName(name)
+	if t.DoesSessionExist() {
+		renameCmd := exec.Command("tmux", "rename-session", fmt.Sprintf("-t=%s", t.sanitizedName), sanitizedName)
+		if err := t.cmdExec.Run(renameCmd); err != nil {
+			return fmt.Errorf("failed to rename tmux session %s to %s: %w", t.sanitizedName, sanitizedName, err)
+		}
+	}
+	t.name, t.sanitizedName = name, sanitizedName
 	return nil
 }
 
+// SetEnv sets environment variables of the session from the next Start on. They take precedence over those of the
+// agent profile.
+func (t *TmuxSession) SetEnv(env map[string]string) {
+	t.env = env
+}
+
+// SetRecorder sets the shell command which receives all output of the pane from the next Start or Restore on.
+// An empty command disables recording.
+func (t *TmuxSession) SetRecorder(command string) {
+	t.recorder = command
+}
+
+// startRecorder pipes the output of the pane to the recorder unless a pipe is already open. It's called on every
+// Restore, and pipe-pane would close a pipe which is open, even with -o, so the pane is checked first.
+func (t *TmuxSession) startRecorder() {
+	if t.recorder == "" {
+		return
+	}
+	output, err := t.cmdExec.Output(exec.Command("tmux", "display-message", "-p", "-t", t.agentTarget(), "#{pane_pipe}"))
+	if err != nil {
+		log.WarningLog.Printf("failed to check the transcript pipe of session %s: %v", t.sanitizedName, err)
+		return
+	}
+	if strings.TrimSpace(string(output)) == "1" {
+		return
+	}
+	pipeCmd := exec.Command("tmux", "pipe-pane", "-t", t.agentTarget(), t.recorder)
+	if err := t.cmdExec.Run(pipeCmd); err != nil {
+		log.WarningLog.Printf("failed to record transcript of session %s: %v", t.sanitizedName, err)
+	}
+}
+
 type statusMonitor struct {
 	// Store hashes to save memory.
 	prevOutputHash []byte
+
+	// checked is true if the result of the last check is cached for the output count of the control client in
+	// outputs. As long as the count doesn't change, the pane is the same and so is the result.
+	checked bool
+	outputs uint64
+	// busy is the result of HasUpdated for an unchanged pane, and hasPrompt whether it shows an approval prompt.
+	busy      bool
+	hasPrompt bool
 }
 
 func newStatusMonitor() *statusMonitor {
@@ -221,11 +398,14 @@ func (t *TmuxSession) TapEnter() error {
 	return nil
 }
 
-// TapDAndEnter sends 'D' followed by an enter keystroke to the tmux pane.
-func (t *TmuxSession) TapDAndEnter() error {
-	_, err := t.ptmx.Write([]byte{0x44, 0x0D})
-	if err != nil {
-		return fmt.Errorf("error sending enter keystroke to PTY: %w", err)
+// Approve sends the approval keys of the agent profile to the tmux pane to accept a pending prompt.
+func (t *TmuxSession) Approve() error {
+	keys := t.profile.ApprovalKeys
+	if keys == "" {
+		keys = "\r"
+	}
+	if _, err := t.ptmx.Write([]byte(keys)); err != nil {
+		return fmt.Errorf("error sending approval keys to PTY: %w", err)
 	}
 	return nil
 }
@@ -235,33 +415,76 @@ func (t *TmuxSession) SendKeys(keys string) error {
 	return err
 }
 
+// Reject sends the reject keys of the agent profile to the tmux pane to decline a pending prompt.
+func (t *TmuxSession) Reject() error {
+	keys := t.profile.RejectKeys
+	if keys == "" {
+		keys = "\x1b"
+	}
+	if _, err := t.ptmx.Write([]byte(keys)); err != nil {
+		return fmt.Errorf("error sending reject keys to PTY: %w", err)
+	}
+	return nil
+}
+
+// PendingRequest returns what the agent asks to be approved on the current screen, extracted with the request
+// pattern of the agent profile. The request is empty if the profile has no pattern or it doesn't match.
+func (t *TmuxSession) PendingRequest() (policy.Request, error) {
+	content, err := t.CapturePaneContent()
+	if err != nil {
+		return policy.Request{}, err
+	}
+	return policy.ExtractRequest(StripANSI(content), t.patterns.request), nil
+}
+
 // HasUpdated checks if the tmux pane content has changed since the last tick. It also returns true if
-// the tmux pane has a prompt for aider or claude code.
+// the tmux pane shows one of the approval prompts of the agent profile. The busy and idle patterns of the profile
+// override the change detection.
 func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
+	outputs, streaming := t.control.outputCount()
+	if streaming && t.monitor.checked && t.monitor.outputs == outputs {
+		return t.monitor.busy, t.monitor.hasPrompt
+	}
+
 	content, err := t.CapturePaneContent()
 	if err != nil {
 		log.ErrorLog.Printf("error capturing pane content in status monitor: %v", err)
 		return false, false
 	}
 
-	// Only set hasPrompt for claude and aider. Use these strings to check for a prompt.
-	if t.program == ProgramClaude {
-		hasPrompt = strings.Contains(content, "No, and tell Claude what to do differently")
-	} else if strings.HasPrefix(t.program, ProgramAider) {
-		hasPrompt = strings.Contains(content, "(Y)es/(N)o/(D)on't ask again")
-	} else if strings.HasPrefix(t.program, ProgramGemini) {
-		hasPrompt = strings.Contains(content, "Yes, allow once")
-	}
+	text := StripANSI(content)
+	hasPrompt = matchAny(t.patterns.approval, text)
+	t.exited = matchAny(t.patterns.exit, text)
 
 	if !bytes.Equal(t.monitor.hash(content), t.monitor.prevOutputHash) {
 		t.monitor.prevOutputHash = t.monitor.hash(content)
-		return true, hasPrompt
+		updated = true
 	}
-	return false, hasPrompt
+
+	busy := false
+	switch {
+	case t.exited || matchAny(t.patterns.idle, text):
+		updated = false
+	case matchAny(t.patterns.busy, text):
+		updated, busy = true, true
+	}
+	t.monitor.checked, t.monitor.outputs = streaming, outputs
+	t.monitor.busy, t.monitor.hasPrompt = busy, hasPrompt
+	return updated, hasPrompt
+}
+
+// AgentExited returns true if the pane showed one of the exit patterns of the agent profile on the last call to
+// HasUpdated.
+func (t *TmuxSession) AgentExited() bool {
+	return t.exited
 }
 
+// Attach attaches the terminal to the session until the detach, next or previous key is pressed. The returned
+// channel is closed after detaching, and AttachResult tells which key it was.
 func (t *TmuxSession) Attach() (chan struct{}, error) {
 	t.attachCh = make(chan struct{})
+	t.attachResult = AttachDetached
+	t.setStatusLine()
 
 	t.wg = &sync.WaitGroup{}
 	t.wg.Add(1)
@@ -284,19 +507,13 @@ func (t *TmuxSession) Attach() (chan struct{}, error) {
 		default:
 			// If context is not done, it was likely an abnormal termination (Ctrl-D)
 			// Print warning message
-			fmt.Fprintf(os.Stderr, "\n\033[31mError: Session terminated without detaching. Use Ctrl-Q to properly detach from tmux sessions.\033[0m\n")
+			detachKey, _, _ := AttachKeyNames()
+			fmt.Fprintf(os.Stderr, "\n\033[31mError: Session terminated without detaching. Use %s to properly detach from tmux sessions.\033[0m\n", detachKey)
 		}
 	}()
 
 	go func() {
-		// Close the channel after 50ms
-		timeoutCh := make(chan struct{})
-		go func() {
-			time.Sleep(50 * time.Millisecond)
-			close(timeoutCh)
-		}()
-
-		// Read input from stdin and check for Ctrl+q
+		input := &attachInput{keys: currentAttachKeys()}
 		buf := make([]byte, 32)
 		for {
 			nr, err := os.Stdin.Read(buf)
@@ -307,29 +524,17 @@ func (t *TmuxSession) Attach() (chan struct{}, error) {
 				continue
 			}
 
-			// Nuke the first bytes of stdin, up to 64, to prevent tmux from reading it.
-			// When we attach, there tends to be terminal control sequences like ?[?62c0;95;0c or
-			// ]10;rgb:f8f8f8. The control sequences depend on the terminal (warp vs iterm). We should use regex ideally
-			// but this works well for now. Log this for debugging.
-			//
-			// There seems to always be control characters, but I think it's possible for there not to be. The heuristic
-			// here can be: if there's characters within 50ms, then assume they are control characters and nuke them.
-			select {
-			case <-timeoutCh:
-			default:
-				log.InfoLog.Printf("nuked first stdin: %s", buf[:nr])
-				continue
-			}
-
-			// Check for Ctrl+q (ASCII 17)
-			if nr == 1 && buf[0] == 17 {
-				// Detach from the session
+			forward, key := input.Feed(buf[:nr])
+			if key != nil {
+				t.attachResult = key.result
 				t.Detach()
 				return
 			}
 
 			// Forward other input to tmux
-			_, _ = t.ptmx.Write(buf[:nr])
+			if len(forward) > 0 {
+				_, _ = t.ptmx.Write(forward)
+			}
 		}
 	}()
 
@@ -337,6 +542,32 @@ func (t *TmuxSession) Attach() (chan struct{}, error) {
 	return t.attachCh, nil
 }
 
+// AttachResult returns how the session was left the last time it was attached.
+func (t *TmuxSession) AttachResult() AttachResult {
+	return t.attachResult
+}
+
+// setStatusLine shows the name of the session and the attach keys in the tmux status line.
+func (t *TmuxSession) setStatusLine() {
+	detach, next, previous := AttachKeyNames()
+	// # starts a format in tmux options.
+	left := " " + strings.ReplaceAll(t.name, "#", "##") + " "
+	right := fmt.Sprintf(" %s detach ", detach)
+	if next != "" || previous != "" {
+		right += fmt.Sprintf("· %s next/prev session ", strings.Trim(next+"/"+previous, "/"))
+	}
+	right = strings.ReplaceAll(right, "#", "##")
+	args := []string{
+		"set-option", "-t", t.sanitizedName, "status-left", left, ";",
+		"set-option", "-t", t.sanitizedName, "status-left-length", strconv.Itoa(len(left)), ";",
+		"set-option", "-t", t.sanitizedName, "status-right", right, ";",
+		"set-option", "-t", t.sanitizedName, "status-right-length", strconv.Itoa(len(right)),
+	}
+	if err := t.cmdExec.Run(exec.Command("tmux", args...)); err != nil {
+		log.WarningLog.Printf("failed to set the status line of session %s: %v", t.sanitizedName, err)
+	}
+}
+
 // DetachSafely disconnects from the current tmux session without panicking
 func (t *TmuxSession) DetachSafely() error {
 	// Only detach if we're actually attached
@@ -414,10 +645,28 @@ func (t *TmuxSession) Detach() {
 	t.wg.Wait()
 }
 
+// Release stops watching the session without terminating it, so that another process can take it over.
+func (t *TmuxSession) Release() error {
+	t.control.Close()
+	t.control = nil
+	if t.ptmx == nil {
+		return nil
+	}
+	err := t.ptmx.Close()
+	t.ptmx = nil
+	if err != nil {
+		return fmt.Errorf("error closing PTY: %w", err)
+	}
+	return nil
+}
+
 // Close terminates the tmux session and cleans up resources
 func (t *TmuxSession) Close() error {
 	var errs []error
 
+	t.control.Close()
+	t.control = nil
+
 	if t.ptmx != nil {
 		if err := t.ptmx.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("error closing PTY: %w", err))
@@ -447,6 +696,8 @@ func (t *TmuxSession) Close() error {
 // SetDetachedSize set the width and height of the session while detached. This makes the
 // tmux output conform to the specified shape.
 func (t *TmuxSession) SetDetachedSize(width, height int) error {
+	// Resizing rewraps the pane, which the agent may not redraw.
+	t.capture.invalidate()
 	return t.updateWindowSize(width, height)
 }
 
@@ -466,22 +717,61 @@ func (t *TmuxSession) DoesSessionExist() bool {
 	return t.cmdExec.Run(existsCmd) == nil
 }
 
-// CapturePaneContent captures the content of the tmux pane
+// CapturePaneContent captures the content of the pane of the agent. While the control client is running, the content is
+// only captured again after the pane printed something.
 func (t *TmuxSession) CapturePaneContent() (string, error) {
+	// The count is read before capturing, so output which arrives meanwhile leads to another capture next time.
+	outputs, streaming := t.control.outputCount()
+	if streaming {
+		if content, ok := t.capture.get(outputs); ok {
+			return content, nil
+		}
+	}
+
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.sanitizedName)
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.agentTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("error capturing pane content: %v", err)
 	}
+	if streaming {
+		t.capture.set(string(output), outputs)
+	}
 	return string(output), nil
 }
 
-// CapturePaneContentWithOptions captures the pane content with additional options
+// paneCapture is the pane content captured at an output count of the control client. The preview and the status
+// monitor capture the pane from different goroutines.
+type paneCapture struct {
+	mu      sync.Mutex
+	valid   bool
+	content string
+	outputs uint64
+}
+
+func (c *paneCapture) get(outputs uint64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.content, c.valid && c.outputs == outputs
+}
+
+func (c *paneCapture) set(content string, outputs uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.valid, c.content, c.outputs = true, content, outputs
+}
+
+func (c *paneCapture) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.valid = false
+}
+
+// CapturePaneContentWithOptions captures the content of the selected window with additional options
 // start and end specify the starting and ending line numbers (use "-" for the start/end of history)
 func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.sanitizedName)
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.selectedTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to capture tmux pane content with options: %v", err)
@@ -489,31 +779,48 @@ func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string,
 	return string(output), nil
 }
 
-// CleanupSessions kills all tmux sessions that start with "session-"
-func CleanupSessions(cmdExec cmd.Executor) error {
-	// First try to list sessions
-	cmd := exec.Command("tmux", "ls")
-	output, err := cmdExec.Output(cmd)
-
-	// If there's an error and it's because no server is running, that's fine
-	// Exit code 1 typically means no sessions exist
+// ListSessions returns the names of the tmux sessions of instances.
+func ListSessions(cmdExec cmd.Executor) ([]string, error) {
+	output, err := cmdExec.Output(exec.Command("tmux", "ls", "-F", "#{session_name}"))
 	if err != nil {
+		// Exit code 1 typically means no server is running, so there are no sessions.
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return nil // No sessions to clean up
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list tmux sessions: %v", err)
+	}
+	var names []string
+	for _, name := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(name, TmuxPrefix) {
+			names = append(names, name)
 		}
-		return fmt.Errorf("failed to list tmux sessions: %v", err)
 	}
+	return names, nil
+}
+
+// SessionName returns the name of the tmux session of the instance with the title.
+func SessionName(title string) string {
+	return toClaudeSquadTmuxName(title)
+}
 
-	re := regexp.MustCompile(fmt.Sprintf(`%s.*:`, TmuxPrefix))
-	matches := re.FindAllString(string(output), -1)
-	for i, match := range matches {
-		matches[i] = match[:strings.Index(match, ":")]
+// KillSession kills the tmux session with the name.
+func KillSession(cmdExec cmd.Executor, name string) error {
+	if err := cmdExec.Run(exec.Command("tmux", "kill-session", "-t", name)); err != nil {
+		return fmt.Errorf("failed to kill tmux session %s: %v", name, err)
 	}
+	return nil
+}
 
-	for _, match := range matches {
-		log.InfoLog.Printf("cleaning up session: %s", match)
-		if err := cmdExec.Run(exec.Command("tmux", "kill-session", "-t", match)); err != nil {
-			return fmt.Errorf("failed to kill tmux session %s: %v", match, err)
+// CleanupSessions kills the tmux sessions of all instances.
+func CleanupSessions(cmdExec cmd.Executor) error {
+	names, err := ListSessions(cmdExec)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		log.InfoLog.Printf("cleaning up session: %s", name)
+		if err := KillSession(cmdExec, name); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -2,6 +2,8 @@ package tmux
 
 import (
 	cmd2 "claude-squad/cmd"
+	"claude-squad/config"
+	"claude-squad/log"
 	"fmt"
 	"math/rand"
 	"os"
@@ -15,6 +17,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+
+	os.Exit(m.Run())
+}
+
 type MockPtyFactory struct {
 	t *testing.T
 
@@ -86,3 +95,100 @@ func TestStartTmuxSession(t *testing.T) {
 	_, err = ptyFactory.files[1].Stat()
 	require.NoError(t, err)
 }
+
+func TestHasUpdatedUsesAgentProfile(t *testing.T) {
+	content := "\x1b[1mWorking...\x1b[0m (esc to interrupt)"
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte(content), nil
+		},
+	}
+
+	session := newTmuxSession("test-session", "my-agent", NewMockPtyFactory(t), cmdExec)
+	session.profile = config.AgentProfile{
+		Name:             "my-agent",
+		ApprovalPatterns: []string{`Allow \w+\?`},
+		BusyPatterns:     []string{`esc to interrupt`},
+		IdlePatterns:     []string{`^> $`},
+		ExitPatterns:     []string{`agent exited`},
+	}
+	session.patterns = compileAgentPatterns(session.profile)
+	session.monitor = newStatusMonitor()
+
+	updated, hasPrompt := session.HasUpdated()
+	require.True(t, updated)
+	require.False(t, hasPrompt)
+
+	// The busy pattern keeps the agent running even though the pane didn't change.
+	updated, _ = session.HasUpdated()
+	require.True(t, updated)
+
+	content = "Allow Bash?"
+	updated, hasPrompt = session.HasUpdated()
+	require.True(t, updated)
+	require.True(t, hasPrompt)
+
+	content = "> "
+	updated, _ = session.HasUpdated()
+	require.False(t, updated, "the idle pattern overrides the changed pane")
+
+	content = "agent exited"
+	updated, _ = session.HasUpdated()
+	require.False(t, updated)
+	require.True(t, session.AgentExited())
+}
+
+func TestStartTmuxSessionWithEnv(t *testing.T) {
+	ptyFactory := NewMockPtyFactory(t)
+	created := false
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			if strings.Contains(cmd.String(), "has-session") && !created {
+				created = true
+				return fmt.Errorf("session already exists")
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("output"), nil
+		},
+	}
+
+	workdir := t.TempDir()
+	session := newTmuxSession("test-session", "my-agent", ptyFactory, cmdExec)
+	session.profile = config.AgentProfile{
+		Name:    "my-agent",
+		Command: "my-agent --yolo",
+		Env:     map[string]string{"B": "2", "A": "1"},
+	}
+	// The environment of the repository takes precedence over the profile.
+	session.SetEnv(map[string]string{"B": "3", "C": "4"})
+
+	require.NoError(t, session.Start(workdir))
+	require.Equal(t, fmt.Sprintf("tmux new-session -d -s claudesquad_test-session -c %s -e A=1 -e B=3 -e C=4 my-agent --yolo", workdir),
+		cmd2.ToString(ptyFactory.cmds[0]))
+}
+
+func TestRestoreKeepsRecording(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux is not installed")
+	}
+	transcript := filepath.Join(t.TempDir(), "transcript")
+	session := newTmuxSession(fmt.Sprintf("recorder-test-%d", rand.Int31()), "sh", NewMockPtyFactory(t),
+		cmd2.MakeExecutor())
+	session.SetRecorder("cat >> " + transcript)
+	require.NoError(t, exec.Command("tmux", "new-session", "-d", "-s", session.sanitizedName, "sh").Run())
+	defer func() { _ = session.Close() }()
+
+	panePipe := func() string {
+		out, err := exec.Command("tmux", "display-message", "-p", "-t", session.sanitizedName, "#{pane_pipe}").Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+	// Restore runs on every attach and detach, so the pipe has to stay open.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, session.Restore())
+		require.Equal(t, "1", panePipe(), "restore %d", i+1)
+	}
+}
@@ -0,0 +1,102 @@
+package tmux
+
+import (
+	"claude-squad/cmd"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// watchSessionPrefix names the sessions which tile several watched sessions. It differs from TmuxPrefix, so they
+// aren't mistaken for the session of an instance.
+const watchSessionPrefix = "claudesquad-watch-"
+
+// Watch attaches the terminal read-only to the sessions of the instances with the given titles. Keys other than
+// detaching (the tmux prefix, then d) are ignored, and the watcher doesn't resize the agent's window. Several
+// sessions are tiled in a temporary session which is destroyed when the watcher detaches.
+func Watch(titles []string) error {
+	names := make([]string, len(titles))
+	for i, title := range titles {
+		names[i] = toClaudeSquadTmuxName(title)
+	}
+	return watch(cmd.MakeExecutor(), names, os.Getpid())
+}
+
+// WatchAll watches the sessions of all instances, like Watch.
+func WatchAll() error {
+	cmdExec := cmd.MakeExecutor()
+	names, err := ListSessions(cmdExec)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no running sessions to watch")
+	}
+	return watch(cmdExec, names, os.Getpid())
+}
+
+func watch(cmdExec cmd.Executor, names []string, pid int) error {
+	for _, name := range names {
+		if cmdExec.Run(exec.Command("tmux", "has-session", "-t="+name)) != nil {
+			return fmt.Errorf("no running session for %s", strings.TrimPrefix(name, TmuxPrefix))
+		}
+	}
+	if len(names) == 1 {
+		return attachTerminal(cmdExec, readOnlyAttachArgs(names[0]))
+	}
+
+	watchName := fmt.Sprintf("%s%d", watchSessionPrefix, pid)
+	// The panes run a nested tmux client each, which needs TMUX to be unset. It connects to the socket in TMUX, in
+	// case the server doesn't use the default one.
+	paneCommand := func(name string) string {
+		args := readOnlyAttachArgs(name)
+		for i := range args {
+			args[i] = cmd.ShellQuote(args[i])
+		}
+		return `socket="${TMUX%%,*}"; unset TMUX; exec tmux -S "$socket" ` + strings.Join(args, " ")
+	}
+	args := []string{"new-session", "-d", "-s", watchName, paneCommand(names[0]), ";",
+		"select-pane", "-t", watchName, "-T", strings.TrimPrefix(names[0], TmuxPrefix)}
+	for _, name := range names[1:] {
+		// Tiling after every split keeps room for the next one.
+		args = append(args, ";", "split-window", "-t", watchName, paneCommand(name),
+			";", "select-pane", "-t", watchName, "-T", strings.TrimPrefix(name, TmuxPrefix),
+			";", "select-layout", "-t", watchName, "tiled")
+	}
+	args = append(args,
+		";", "set-option", "-t", watchName, "pane-border-status", "top",
+		";", "set-option", "-t", watchName, "pane-border-format", " #{pane_title} ",
+		";", "set-option", "-t", watchName, "status-left", fmt.Sprintf(" watching %d sessions ", len(names)),
+		";", "set-option", "-t", watchName, "status-left-length", "30",
+		";", "set-option", "-t", watchName, "status-right", " read-only · prefix d to stop ",
+	)
+	if err := cmdExec.Run(exec.Command("tmux", args...)); err != nil {
+		_ = cmdExec.Run(exec.Command("tmux", "kill-session", "-t", watchName))
+		return fmt.Errorf("failed to tile sessions: %v", err)
+	}
+	// The session would be destroyed right away if it were set to be destroyed before a client attached.
+	err := attachTerminal(cmdExec, []string{"attach-session", "-t", watchName,
+		";", "set-option", "-t", watchName, "destroy-unattached", "on"})
+	if err != nil {
+		_ = cmdExec.Run(exec.Command("tmux", "kill-session", "-t", watchName))
+	}
+	return err
+}
+
+// readOnlyAttachArgs returns the tmux arguments to attach a client which only watches the session.
+func readOnlyAttachArgs(name string) []string {
+	return []string{"attach-session", "-f", "read-only,ignore-size", "-t", name}
+}
+
+// attachTerminal runs tmux with the terminal of this process until the client detaches.
+func attachTerminal(cmdExec cmd.Executor, args []string) error {
+	c := exec.Command("tmux", args...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// Attaching from inside tmux needs TMUX to be unset.
+	c.Env = append(os.Environ(), "TMUX=")
+	if err := cmdExec.Run(c); err != nil {
+		return fmt.Errorf("failed to attach to tmux: %v", err)
+	}
+	return nil
+}
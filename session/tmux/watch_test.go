@@ -0,0 +1,49 @@
+package tmux
+
+import (
+	cmd2 "claude-squad/cmd"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"claude-squad/cmd/cmd_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			if strings.Contains(cmd.String(), "has-session -t=claudesquad_gone") {
+				return fmt.Errorf("exit status 1")
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("other\nclaudesquad_api\nclaudesquad_web\n"), nil
+		},
+	}
+
+	require.NoError(t, watch(cmdExec, []string{"claudesquad_api"}, 1))
+	assert.Equal(t, "tmux attach-session -f read-only,ignore-size -t claudesquad_api", ran[len(ran)-1])
+
+	ran = nil
+	names, err := ListSessions(cmdExec)
+	require.NoError(t, err)
+	require.Equal(t, []string{"claudesquad_api", "claudesquad_web"}, names)
+	require.NoError(t, watch(cmdExec, names, 7))
+	require.Len(t, ran, 4)
+	assert.Contains(t, ran[2], "new-session -d -s claudesquad-watch-7")
+	assert.Contains(t, ran[2], "attach-session' '-f' 'read-only,ignore-size' '-t' 'claudesquad_api'")
+	assert.Contains(t, ran[2], "split-window -t claudesquad-watch-7")
+	assert.Contains(t, ran[2], "select-layout -t claudesquad-watch-7 tiled")
+	assert.Equal(t, "tmux attach-session -t claudesquad-watch-7 ; set-option -t claudesquad-watch-7 destroy-unattached on",
+		ran[3])
+
+	err = watch(cmdExec, []string{"claudesquad_api", "claudesquad_gone"}, 1)
+	require.ErrorContains(t, err, "no running session for gone")
+}
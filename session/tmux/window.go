@@ -0,0 +1,118 @@
+package tmux
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// agentWindowName is shown for the window of the agent, which tmux names after the running program.
+const agentWindowName = "agent"
+
+// tmuxWindow is a window of the session.
+type tmuxWindow struct {
+	id   string
+	name string
+}
+
+// SetWindows sets the extra windows which the next Start creates next to the agent.
+func (t *TmuxSession) SetWindows(windows []config.WindowConfig) {
+	t.extraWindows = windows
+}
+
+// startWindows creates the extra windows in the work directory. A command runs in the shell, which stays when the
+// command exits or is interrupted.
+func (t *TmuxSession) startWindows(workDir string) {
+	for _, window := range t.extraWindows {
+		args := []string{"new-window", "-d", "-t", t.sanitizedName + ":", "-n", window.Name, "-c", workDir}
+		if window.Command != "" {
+			// The trap keeps the shell from exiting on ctrl-c, while the command gets the default handling.
+			args = append(args, "trap : INT; "+window.Command+`; exec "${SHELL:-/bin/sh}"`)
+		}
+		if err := t.cmdExec.Run(exec.Command("tmux", args...)); err != nil {
+			log.WarningLog.Printf("failed to create window %s in session %s: %v", window.Name, t.sanitizedName, err)
+		}
+	}
+}
+
+// loadWindows reads the windows of the session. The agent runs in the first one.
+func (t *TmuxSession) loadWindows() {
+	t.windows, t.selectedWindow = nil, 0
+	listCmd := exec.Command("tmux", "list-windows", "-t", t.sanitizedName,
+		"-F", "#{window_id} #{window_active} #{window_name}")
+	output, err := t.cmdExec.Output(listCmd)
+	if err != nil {
+		log.WarningLog.Printf("failed to list the windows of session %s: %v", t.sanitizedName, err)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], "@") {
+			continue
+		}
+		if fields[1] == "1" {
+			t.selectedWindow = len(t.windows)
+		}
+		t.windows = append(t.windows, tmuxWindow{id: fields[0], name: fields[2]})
+	}
+}
+
+// agentTarget is the tmux target of the pane of the agent. Without the windows, it's the current window of the
+// session, which is the agent unless it was changed.
+func (t *TmuxSession) agentTarget() string {
+	if len(t.windows) == 0 {
+		return t.sanitizedName
+	}
+	return t.windows[0].id
+}
+
+// selectedTarget is the tmux target of the window selected with SelectWindow.
+func (t *TmuxSession) selectedTarget() string {
+	if t.selectedWindow >= len(t.windows) {
+		return t.agentTarget()
+	}
+	return t.windows[t.selectedWindow].id
+}
+
+// WindowNames returns the names of the windows of the session. The first one is the agent.
+func (t *TmuxSession) WindowNames() []string {
+	names := make([]string, len(t.windows))
+	for i, window := range t.windows {
+		names[i] = window.name
+	}
+	if len(names) > 0 {
+		names[0] = agentWindowName
+	}
+	return names
+}
+
+// SelectedWindow returns the index of the window shown by the preview and when attaching.
+func (t *TmuxSession) SelectedWindow() int {
+	return t.selectedWindow
+}
+
+// SelectWindow makes the window with the given index the one shown by the preview and when attaching.
+func (t *TmuxSession) SelectWindow(index int) error {
+	if index < 0 || index >= len(t.windows) {
+		return fmt.Errorf("session %s has no window %d", t.sanitizedName, index)
+	}
+	if err := t.cmdExec.Run(exec.Command("tmux", "select-window", "-t", t.windows[index].id)); err != nil {
+		return fmt.Errorf("failed to select window %s: %w", t.windows[index].name, err)
+	}
+	t.selectedWindow = index
+	return nil
+}
+
+// CaptureSelectedWindow captures the content of the selected window.
+func (t *TmuxSession) CaptureSelectedWindow() (string, error) {
+	if t.selectedTarget() == t.agentTarget() {
+		return t.CapturePaneContent()
+	}
+	output, err := t.cmdExec.Output(exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.selectedTarget()))
+	if err != nil {
+		return "", fmt.Errorf("error capturing window content: %v", err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,63 @@
+package tmux
+
+import (
+	cmd2 "claude-squad/cmd"
+	"claude-squad/config"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"claude-squad/cmd/cmd_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindows(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			command := cmd2.ToString(cmd)
+			if strings.Contains(command, "list-windows") {
+				return []byte("@1 1 node\n@2 0 shell\n@3 0 test server\n"), nil
+			}
+			ran = append(ran, command)
+			return []byte("content"), nil
+		},
+	}
+
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	session.SetWindows([]config.WindowConfig{{Name: "shell"}, {Name: "test", Command: "go test ./..."}})
+	session.startWindows("/work")
+	assert.Equal(t, []string{
+		"tmux new-window -d -t claudesquad_test-session: -n shell -c /work",
+		`tmux new-window -d -t claudesquad_test-session: -n test -c /work trap : INT; go test ./...; exec "${SHELL:-/bin/sh}"`,
+	}, ran)
+
+	require.NoError(t, session.Restore())
+	assert.Equal(t, []string{"agent", "shell", "test server"}, session.WindowNames())
+	assert.Equal(t, 0, session.SelectedWindow())
+
+	ran = nil
+	_, err := session.CaptureSelectedWindow()
+	require.NoError(t, err)
+	require.NoError(t, session.SelectWindow(2))
+	_, err = session.CaptureSelectedWindow()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"tmux capture-pane -p -e -J -t @1",
+		"tmux select-window -t @3",
+		"tmux capture-pane -p -e -J -t @3",
+	}, ran)
+
+	// The status of the agent is still detected in its own window.
+	ran = nil
+	session.HasUpdated()
+	assert.Equal(t, []string{"tmux capture-pane -p -e -J -t @1"}, ran)
+
+	assert.Error(t, session.SelectWindow(3))
+}
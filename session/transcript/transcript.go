@@ -0,0 +1,433 @@
+package transcript
+
+import (
+	"bufio"
+	"claude-squad/cmd"
+	"claude-squad/config"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DirName is the directory in the config directory which holds the transcript of every instance.
+const DirName = "transcripts"
+
+// idleTimeLimit is stored in the header so that players skip the time an instance was paused or idle.
+const idleTimeLimit = 2.0
+
+// sizeCheckInterval is how often the recorder checks whether the pane was resized.
+const sizeCheckInterval = 2 * time.Second
+
+// maxSize is the size at which the recorder moves the transcript to the first rotated file and starts a new one.
+// maxFiles is how many rotated files are kept. They are variables so tests can replace them.
+var (
+	maxSize  int64 = 20 << 20
+	maxFiles       = 2
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version       int     `json:"version"`
+	Width         int     `json:"width"`
+	Height        int     `json:"height"`
+	Timestamp     int64   `json:"timestamp"`
+	IdleTimeLimit float64 `json:"idle_time_limit,omitempty"`
+	Title         string  `json:"title,omitempty"`
+}
+
+// Event is output of the pane ("o") or a resize of the pane ("r"), in seconds since the start of the recording.
+type Event struct {
+	Time float64
+	Type string
+	Data string
+}
+
+// Transcript is a parsed asciicast v2 file.
+type Transcript struct {
+	Header Header
+	Events []Event
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Path returns the path of the transcript of the instance.
+func Path(instance string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, DirName, unsafeFileChars.ReplaceAllString(instance, "_")+".cast"), nil
+}
+
+// rotatedPath returns the path of the n-th rotated file of the transcript at path, or path itself if n is 0.
+func rotatedPath(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d.cast", strings.TrimSuffix(path, ".cast"), n)
+}
+
+// rotate shifts the transcript at path to the first rotated file, and each rotated file to the next one. The
+// oldest file is removed once there are maxFiles rotated files.
+func rotate(path string) error {
+	if err := os.Remove(rotatedPath(path, maxFiles)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove oldest transcript: %w", err)
+	}
+	for n := maxFiles - 1; n >= 0; n-- {
+		if err := os.Rename(rotatedPath(path, n), rotatedPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate transcript: %w", err)
+		}
+	}
+	return nil
+}
+
+// move renames the transcript at from to to, along with its rotated files.
+func move(from, to string) error {
+	for n := 0; n <= maxFiles; n++ {
+		if err := os.Rename(rotatedPath(from, n), rotatedPath(to, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Archive moves an existing transcript of the instance and its rotated files aside, so that a new instance with
+// the same title starts a new transcript. It does nothing if there is no transcript.
+func Archive(instance string) error {
+	path, err := Path(instance)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	archived := strings.TrimSuffix(path, ".cast") + "-" + strconv.FormatInt(time.Now().Unix(), 10) + ".cast"
+	if err := move(path, archived); err != nil {
+		return fmt.Errorf("failed to archive transcript: %w", err)
+	}
+	return nil
+}
+
+// Rename moves the transcript of an instance and its rotated files to its new title, archiving a transcript which already exists under
+// the new title. It does nothing if there is no transcript. A recorder which is still running keeps appending to
+// the moved file.
+func Rename(oldInstance, newInstance string) error {
+	oldPath, err := Path(oldInstance)
+	if err != nil {
+		return err
+	}
+	newPath, err := Path(newInstance)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) || oldPath == newPath {
+		return nil
+	}
+	if err := Archive(newInstance); err != nil {
+		return err
+	}
+	if err := move(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename transcript: %w", err)
+	}
+	return nil
+}
+
+// RecordCommand returns the shell command tmux pipe-pane runs to append the output of the pane to the transcript
+// of the instance. It runs this executable with the hidden transcript-record command.
+func RecordCommand(instance string) (string, error) {
+	path, err := Path(instance)
+	if err != nil {
+		return "", err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find executable: %w", err)
+	}
+	// tmux expands formats in the command, so #{pane_id} becomes the pane and literal #s need to be doubled.
+	escape := func(s string) string { return strings.ReplaceAll(cmd.ShellQuote(s), "#", "##") }
+	return fmt.Sprintf("%s transcript-record --pane '#{pane_id}' %s", escape(executable), escape(path)), nil
+}
+
+// paneSize returns the size of the tmux pane. It is a variable so tests can replace it.
+var paneSize = func(pane string) (int, int, error) {
+	out, err := exec.Command("tmux", "display-message", "-p", "-t", pane, "#{pane_width} #{pane_height}").Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get pane size: %w", err)
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(string(out), "%d %d", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse pane size %q: %w", out, err)
+	}
+	return width, height, nil
+}
+
+// Record appends what is read from r to the transcript at path until r is closed. A new file gets a header with
+// the size of the pane. An existing one is continued, so the time the pane wasn't recorded shows as idle time. Once
+// the transcript reaches maxSize, it is rotated and recording continues in a new file.
+func Record(r io.Reader, path, pane string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	width, height, err := paneSize(pane)
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	var (
+		f          *os.File
+		size       int64
+		start      time.Time
+		writeEvent func(eventType, data string) error
+	)
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	// openFile continues the transcript at path, or starts it with a header if there is none.
+	openFile := func() error {
+		var err error
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open transcript: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat transcript: %w", err)
+		}
+		size = info.Size()
+		header, err := readHeader(f)
+		if err != nil {
+			header = Header{Version: 2, Width: width, Height: height, Timestamp: time.Now().Unix(), IdleTimeLimit: idleTimeLimit}
+			data, err := json.Marshal(header)
+			if err != nil {
+				return fmt.Errorf("failed to marshal transcript header: %w", err)
+			}
+			n, err := f.Write(append(data, '\n'))
+			size += int64(n)
+			if err != nil {
+				return fmt.Errorf("failed to write transcript header: %w", err)
+			}
+		}
+		start = time.Unix(header.Timestamp, 0)
+		if width != header.Width || height != header.Height {
+			return writeEvent("r", fmt.Sprintf("%dx%d", width, height))
+		}
+		return nil
+	}
+	writeEvent = func(eventType, data string) error {
+		line, err := json.Marshal([]interface{}{time.Since(start).Seconds(), eventType, data})
+		if err != nil {
+			return fmt.Errorf("failed to marshal transcript event: %w", err)
+		}
+		n, err := f.Write(append(line, '\n'))
+		size += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write transcript event: %w", err)
+		}
+		if size < maxSize {
+			return nil
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to close transcript: %w", err)
+		}
+		f = nil
+		if err := rotate(path); err != nil {
+			return err
+		}
+		return openFile()
+	}
+	if err := openFile(); err != nil {
+		return err
+	}
+
+	lastSizeCheck := time.Now()
+	buf := make([]byte, 32*1024)
+	var pending []byte
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			if time.Since(lastSizeCheck) > sizeCheckInterval {
+				lastSizeCheck = time.Now()
+				if w, h, err := paneSize(pane); err == nil && (w != width || h != height) {
+					width, height = w, h
+					if err := writeEvent("r", fmt.Sprintf("%dx%d", width, height)); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Keep an incomplete UTF-8 sequence at the end for the next read, so it isn't replaced when marshaled.
+			pending = append(pending, buf[:n]...)
+			complete := len(pending)
+			for i := len(pending) - 1; i >= 0 && i >= len(pending)-utf8.UTFMax; i-- {
+				if utf8.RuneStart(pending[i]) {
+					if !utf8.FullRune(pending[i:]) {
+						complete = i
+					}
+					break
+				}
+			}
+			if complete > 0 {
+				if err := writeEvent("o", string(pending[:complete])); err != nil {
+					return err
+				}
+				pending = append(pending[:0], pending[complete:]...)
+			}
+		}
+		if readErr == io.EOF {
+			if len(pending) > 0 {
+				return writeEvent("o", string(pending))
+			}
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read pane output: %w", readErr)
+		}
+	}
+}
+
+// readHeader reads the header of an existing transcript.
+func readHeader(f *os.File) (Header, error) {
+	var header Header
+	line, err := bufio.NewReader(io.NewSectionReader(f, 0, 1<<20)).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return header, err
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, err
+	}
+	if header.Version != 2 {
+		return header, fmt.Errorf("unsupported transcript version %d", header.Version)
+	}
+	return header, nil
+}
+
+// Load reads the transcript of the instance.
+func Load(instance string) (*Transcript, error) {
+	path, err := Path(instance)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no transcript recorded for %s", instance)
+		}
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads an asciicast v2 file. Lines which are not valid events are skipped, since the last line may be
+// incomplete while the recorder is writing it.
+func Parse(r io.Reader) (*Transcript, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read transcript: %w", err)
+		}
+		return nil, fmt.Errorf("transcript is empty")
+	}
+	t := &Transcript{}
+	if err := json.Unmarshal(scanner.Bytes(), &t.Header); err != nil {
+		return nil, fmt.Errorf("invalid transcript header: %w", err)
+	}
+
+	for scanner.Scan() {
+		var fields []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil || len(fields) != 3 {
+			continue
+		}
+		eventTime, ok1 := fields[0].(float64)
+		eventType, ok2 := fields[1].(string)
+		data, ok3 := fields[2].(string)
+		if ok1 && ok2 && ok3 {
+			t.Events = append(t.Events, Event{Time: eventTime, Type: eventType, Data: data})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return t, nil
+}
+
+// WriteCast writes the transcript as an asciicast v2 file.
+func (t *Transcript) WriteCast(w io.Writer) error {
+	data, err := json.Marshal(t.Header)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	for _, event := range t.Events {
+		line, err := json.Marshal([]interface{}{event.Time, event.Type, event.Data})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	oscRegex = regexp.MustCompile(`\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+	// Cursor movements to another row start a new line, since the agent is usually redrawing part of the screen.
+	rowMoveRegex = regexp.MustCompile(`\x1b\[[0-9;]*[ABEFHf]`)
+	csiRegex     = regexp.MustCompile(`\x1b\[[0-9;?<>=!]*[ -/]*[@-~]`)
+	escapeRegex  = regexp.MustCompile(`\x1b(?:[()*+][0-9A-Za-z]|[@-Z\\-_=>78c])`)
+)
+
+// Text returns the output of the transcript as plain text. Terminals redraw in place, which can't be replayed as
+// text, so this is an approximation: escape sequences are removed, a line overwritten after a carriage return
+// keeps its last version, and lines repeated with only blank lines in between are dropped.
+func (t *Transcript) Text() string {
+	var raw strings.Builder
+	for _, event := range t.Events {
+		if event.Type == "o" {
+			raw.WriteString(event.Data)
+		}
+	}
+	content := oscRegex.ReplaceAllString(raw.String(), "")
+	content = rowMoveRegex.ReplaceAllString(content, "\n")
+	content = csiRegex.ReplaceAllString(content, "")
+	content = escapeRegex.ReplaceAllString(content, "")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		line = strings.TrimRight(strings.Map(func(r rune) rune {
+			if r < 0x20 && r != '\t' {
+				return -1
+			}
+			return r
+		}, line), " \t")
+		// A line repeated after blank lines is redrawn in place, so the blank lines are dropped with it.
+		last := len(lines) - 1
+		for last >= 0 && lines[last] == "" && line != "" {
+			last--
+		}
+		if last >= 0 && lines[last] == line {
+			lines = lines[:last+1]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
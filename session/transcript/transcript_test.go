@@ -0,0 +1,136 @@
+package transcript
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkReader returns one chunk per Read, to split the output like a pipe would.
+type chunkReader struct {
+	chunks [][]byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestRecord(t *testing.T) {
+	paneSize = func(pane string) (int, int, error) { return 120, 40, nil }
+	path := filepath.Join(t.TempDir(), "session.cast")
+
+	// "é" is split across two reads.
+	err := Record(&chunkReader{chunks: [][]byte{[]byte("caf\xc3"), []byte("\xa9\r\n")}}, path, "%1")
+	require.NoError(t, err)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	recorded, err := Parse(f)
+	require.NoError(t, err)
+	assert.Equal(t, 2, recorded.Header.Version)
+	assert.Equal(t, 120, recorded.Header.Width)
+	assert.Equal(t, 40, recorded.Header.Height)
+	require.Len(t, recorded.Events, 2)
+	assert.Equal(t, "caf", recorded.Events[0].Data)
+	assert.Equal(t, "é\r\n", recorded.Events[1].Data)
+
+	t.Run("continues an existing transcript", func(t *testing.T) {
+		paneSize = func(pane string) (int, int, error) { return 100, 30, nil }
+		require.NoError(t, Record(strings.NewReader("again\r\n"), path, "%1"))
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		recorded, err := Parse(f)
+		require.NoError(t, err)
+		assert.Equal(t, 120, recorded.Header.Width, "the header is kept")
+		require.Len(t, recorded.Events, 4)
+		assert.Equal(t, Event{Time: recorded.Events[2].Time, Type: "r", Data: "100x30"}, recorded.Events[2])
+		assert.Equal(t, "café\nagain", recorded.Text())
+
+		var cast bytes.Buffer
+		require.NoError(t, recorded.WriteCast(&cast))
+		assert.Equal(t, 5, strings.Count(cast.String(), "\n"))
+	})
+}
+
+func TestRecordRotates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	paneSize = func(pane string) (int, int, error) { return 80, 24, nil }
+	defer func(size int64) { maxSize = size }(maxSize)
+	maxSize = 150
+	path, err := Path("task")
+	require.NoError(t, err)
+
+	var chunks [][]byte
+	for i := 0; i < 10; i++ {
+		chunks = append(chunks, []byte(strings.Repeat("x", 40)+"\r\n"))
+	}
+	require.NoError(t, Record(&chunkReader{chunks: chunks}, path, "%1"))
+
+	for n := 0; n <= maxFiles; n++ {
+		f, err := os.Open(rotatedPath(path, n))
+		require.NoError(t, err)
+		recorded, err := Parse(f)
+		f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, 2, recorded.Header.Version, "every file starts with a header")
+		if n > 0 {
+			assert.NotEmpty(t, recorded.Events)
+		}
+	}
+	_, err = os.Stat(rotatedPath(path, maxFiles+1))
+	assert.True(t, os.IsNotExist(err), "the oldest file is removed")
+
+	t.Run("rename moves the rotated files", func(t *testing.T) {
+		require.NoError(t, Rename("task", "renamed"))
+		renamed, err := Path("renamed")
+		require.NoError(t, err)
+		for n := 0; n <= maxFiles; n++ {
+			_, err := os.Stat(rotatedPath(renamed, n))
+			assert.NoError(t, err)
+			_, err = os.Stat(rotatedPath(path, n))
+			assert.True(t, os.IsNotExist(err))
+		}
+	})
+}
+
+func TestText(t *testing.T) {
+	transcript := &Transcript{Events: []Event{
+		{Type: "o", Data: "\x1b]0;title\x07\x1b[1m> \x1b[0mfix the tests\r\n"},
+		{Type: "o", Data: "Working 1\rWorking 2\r\n"},
+		{Type: "o", Data: "\x1b[2K\x1b[1AWorking 2\r\n"},
+		{Type: "r", Data: "80x24"},
+		{Type: "o", Data: "done\r\n\r\n\r\n"},
+	}}
+	assert.Equal(t, "> fix the tests\nWorking 2\ndone", transcript.Text())
+}
+
+func TestParseSkipsIncompleteLines(t *testing.T) {
+	input := `{"version": 2, "width": 80, "height": 24, "timestamp": 1}
+[0.5, "o", "hello"]
+[1.0, "o", "wor`
+	recorded, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, recorded.Events, 1)
+	assert.Equal(t, "hello", recorded.Events[0].Data)
+}
+
+func TestRecordCommand(t *testing.T) {
+	t.Setenv("HOME", "/home/a#b")
+	command, err := RecordCommand("my task")
+	require.NoError(t, err)
+	assert.Contains(t, command, "transcript-record --pane '#{pane_id}' '/home/a##b/.claude-squad/transcripts/my_task.cast'")
+}
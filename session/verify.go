@@ -0,0 +1,58 @@
+package session
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// verifyOutputLines limits how much of the output of the verify command a verification keeps.
+const verifyOutputLines = 10
+
+// Verification is the result of running the verify command of the repository in the worktree of an instance.
+type Verification struct {
+	Command  string
+	Passed   bool
+	Duration time.Duration
+	// Output are the last lines printed by the command.
+	Output string
+}
+
+func (v *Verification) String() string {
+	result := "failed"
+	if v.Passed {
+		result = "passed"
+	}
+	return fmt.Sprintf("%s in %s", result, v.Duration.Round(100*time.Millisecond))
+}
+
+// Verify runs the verify command of the repository config in the worktree of the instance, which can take a while.
+// It returns nil if the repository has no verify command.
+func (i *Instance) Verify() (*Verification, error) {
+	if !i.started || i.Paused() {
+		return nil, fmt.Errorf("cannot verify instance %s without a worktree", i.Title)
+	}
+	repoConfig, err := config.LoadRepoConfig(i.gitWorktree.GetRepoPath())
+	if err != nil {
+		return nil, err
+	}
+	if repoConfig.Verify == "" {
+		return nil, nil
+	}
+
+	var tail []string
+	start := time.Now()
+	err = runCommand(repoConfig.Verify, i.gitWorktree.GetWorktreePath(), repoConfig.Env, func(line string) {
+		tail = append(tail, line)
+		if len(tail) > verifyOutputLines {
+			tail = tail[1:]
+		}
+	})
+	return &Verification{
+		Command:  repoConfig.Verify,
+		Passed:   err == nil,
+		Duration: time.Since(start),
+		Output:   strings.Join(tail, "\n"),
+	}, nil
+}
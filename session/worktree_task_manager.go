@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"claude-squad/log"
+	"claude-squad/session/audit"
 	"claude-squad/session/git"
+	"claude-squad/session/notify"
 )
 
 // WorktreeTaskManager manages the execution of worktree-based tasks
@@ -175,6 +177,8 @@ func (wtm *WorktreeTaskManager) executeMainTaskAsync(mainTask *MainTask) {
 	// Send main task completion webhook
 	payload := CreateMainTaskCompletedPayload(mainTask)
 	wtm.webhookQueue.Enqueue(wtm.ctx, mainTask.WebhookURL, payload)
+	notify.Notify(notify.EventWTaskFinished, mainTask.ID,
+		fmt.Sprintf("Task %s %s", mainTask.Title, mainTask.Status))
 	
 	// Cleanup worktree after completion
 	go wtm.cleanupMainTask(mainTask.ID)
@@ -206,6 +210,7 @@ func (wtm *WorktreeTaskManager) executeSubTask(mainTask *MainTask, subTask *SubT
 	if err != nil {
 		return fmt.Errorf("failed to create instance: %w", err)
 	}
+	instance.SetAuditActor(audit.ActorWTask)
 	
 	// Start the instance
 	if err := instance.Start(true); err != nil {
@@ -0,0 +1,388 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	compareSelectedStyle = lipgloss.NewStyle().Bold(true).Foreground(highlightColor)
+	comparePassedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
+	compareFailedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef4444"))
+)
+
+// CompareAction is what the app should do after a key was pressed in the compare view.
+type CompareAction int
+
+const (
+	CompareNone CompareAction = iota
+	// CompareClose closes the view.
+	CompareClose
+	// CompareVerify runs the verify command for every candidate.
+	CompareVerify
+	// CompareKeep keeps the selected candidate and kills the others.
+	CompareKeep
+)
+
+// compareVerification is the state of verifying a candidate.
+type compareVerification struct {
+	running bool
+	// result is nil if the repository has no verify command.
+	result *session.Verification
+	err    error
+}
+
+// CompareView shows the instances of a group, like those of a best-of-N, next to each other: their diff stats,
+// the result of verifying them, and the diffs of the selected one and another one side by side.
+type CompareView struct {
+	group         string
+	candidates    []*session.Instance
+	verifications map[*session.Instance]*compareVerification
+	// selected is the candidate in the left column, which is kept, and other the one in the right column.
+	selected, other int
+	// offset is the first line of the diffs which is shown.
+	offset        int
+	width, height int
+}
+
+// NewCompareView creates a view comparing the candidates of the group.
+func NewCompareView(group string, candidates []*session.Instance) *CompareView {
+	return &CompareView{
+		group:         group,
+		candidates:    candidates,
+		verifications: make(map[*session.Instance]*compareVerification),
+		other:         min(1, len(candidates)-1),
+	}
+}
+
+// SetSize sets the size of the whole view.
+func (v *CompareView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
+
+// Group returns the group which is compared.
+func (v *CompareView) Group() string {
+	return v.group
+}
+
+// Candidates returns the compared instances.
+func (v *CompareView) Candidates() []*session.Instance {
+	return v.candidates
+}
+
+// Selected returns the candidate in the left column.
+func (v *CompareView) Selected() *session.Instance {
+	if len(v.candidates) == 0 {
+		return nil
+	}
+	return v.candidates[v.selected]
+}
+
+// SetVerifying marks the verification of the instance as running.
+func (v *CompareView) SetVerifying(instance *session.Instance) {
+	v.verifications[instance] = &compareVerification{running: true}
+}
+
+// Verifying returns true if the instance is being verified.
+func (v *CompareView) Verifying(instance *session.Instance) bool {
+	verification, ok := v.verifications[instance]
+	return ok && verification.running
+}
+
+// SetVerification sets the result of verifying the instance. The result is nil if there is no verify command.
+func (v *CompareView) SetVerification(instance *session.Instance, result *session.Verification, err error) {
+	v.verifications[instance] = &compareVerification{result: result, err: err}
+}
+
+// HandleKeyPress handles a key and returns what the app should do.
+func (v *CompareView) HandleKeyPress(msg tea.KeyMsg) CompareAction {
+	n := len(v.candidates)
+	switch msg.String() {
+	case "esc", "q", "=":
+		return CompareClose
+	case "v":
+		return CompareVerify
+	case "enter":
+		return CompareKeep
+	case "up", "k":
+		if n > 0 {
+			v.selectCandidate((v.selected - 1 + n) % n)
+		}
+	case "down", "j":
+		if n > 0 {
+			v.selectCandidate((v.selected + 1) % n)
+		}
+	case "tab":
+		if n > 1 {
+			v.other = (v.other + 1) % n
+			if v.other == v.selected {
+				v.other = (v.other + 1) % n
+			}
+			v.offset = 0
+		}
+	case "J", "shift+down":
+		v.offset++
+	case "K", "shift+up":
+		v.offset = max(v.offset-1, 0)
+	case "pgdown", " ":
+		v.offset += max(v.height/2, 1)
+	case "pgup":
+		v.offset = max(v.offset-max(v.height/2, 1), 0)
+	}
+	return CompareNone
+}
+
+// selectCandidate selects the candidate for the left column and moves the other one out of its way.
+func (v *CompareView) selectCandidate(idx int) {
+	v.selected = idx
+	if v.other == v.selected && len(v.candidates) > 1 {
+		v.other = (v.selected + 1) % len(v.candidates)
+	}
+	v.offset = 0
+}
+
+func (v *CompareView) String() string {
+	if v.width <= 0 || v.height <= 0 {
+		return ""
+	}
+	lines := []string{
+		transcriptTitleStyle.Render(fmt.Sprintf("Compare %s", v.group)) + " " +
+			transcriptHintStyle.Render(fmt.Sprintf("(%d sessions)", len(v.candidates))),
+		"",
+	}
+	lines = append(lines, v.renderTable()...)
+	lines = append(lines, v.renderOutput()...)
+	lines = append(lines, "")
+
+	hint := transcriptHintStyle.Render(
+		"↑/↓ select · tab other · J/K scroll · v verify · ↵ keep selected, kill the rest · esc close")
+	height := v.height - len(lines) - 1
+	if height > 0 {
+		diff := v.renderDiffs(height)
+		lines = append(lines, diff...)
+		for i := len(diff); i < height; i++ {
+			lines = append(lines, "")
+		}
+	}
+	lines = append(lines, hint)
+	return strings.Join(lines, "\n")
+}
+
+// renderTable renders a row per candidate with its program, status, diff stats and verification.
+func (v *CompareView) renderTable() []string {
+	rows := [][]string{{"", "Session", "Program", "Status", "Diff", "Files", "Verify"}}
+	for i, instance := range v.candidates {
+		marker := " "
+		if i == v.selected {
+			marker = "▶"
+		} else if i == v.other {
+			marker = "│"
+		}
+		added, removed, files := "", "", ""
+		if stats := instance.GetDiffStats(); stats != nil && stats.Error == nil {
+			added = fmt.Sprintf("+%d", stats.Added)
+			removed = fmt.Sprintf("-%d", stats.Removed)
+			files = fmt.Sprintf("%d", len(stats.ParsedFiles()))
+		}
+		rows = append(rows, []string{marker, instance.Title, instance.Program, statusName(instance),
+			added + " " + removed, files, v.verificationText(instance)})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], runewidth.StringWidth(cell))
+		}
+	}
+	// The program can be a long command line, so it gets whatever room is left.
+	fixed := 0
+	for i, w := range widths {
+		if i != 2 {
+			fixed += w + 2
+		}
+	}
+	widths[2] = max(min(widths[2], v.width-fixed), 0)
+
+	lines := make([]string, 0, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = runewidth.FillRight(runewidth.Truncate(cell, widths[i], "…"), widths[i])
+			switch {
+			case r == 0:
+				cell = transcriptHintStyle.Render(cell)
+			case i == 4:
+				cell = v.styleDiffCell(cell)
+			case i == 6:
+				cell = v.styleVerificationCell(v.candidates[r-1], cell)
+			case r-1 == v.selected:
+				cell = compareSelectedStyle.Render(cell)
+			}
+			cells[i] = cell
+		}
+		lines = append(lines, strings.Join(cells, "  "))
+	}
+	return lines
+}
+
+// styleDiffCell colors the added and removed counts of a padded diff cell.
+func (v *CompareView) styleDiffCell(cell string) string {
+	added, removed, ok := strings.Cut(cell, " ")
+	if !ok || added == "" {
+		return cell
+	}
+	return AdditionStyle.Render(added) + " " + DeletionStyle.Render(removed)
+}
+
+func (v *CompareView) styleVerificationCell(instance *session.Instance, cell string) string {
+	verification := v.verifications[instance]
+	switch {
+	case verification == nil || verification.running || verification.result == nil && verification.err == nil:
+		return transcriptHintStyle.Render(cell)
+	case verification.err == nil && verification.result.Passed:
+		return comparePassedStyle.Render(cell)
+	default:
+		return compareFailedStyle.Render(cell)
+	}
+}
+
+// verificationText describes the state of verifying the instance.
+func (v *CompareView) verificationText(instance *session.Instance) string {
+	verification := v.verifications[instance]
+	switch {
+	case verification == nil:
+		return "-"
+	case verification.running:
+		return "running…"
+	case verification.err != nil:
+		return "error"
+	case verification.result == nil:
+		return "no verify command"
+	default:
+		return verification.result.String()
+	}
+}
+
+// renderOutput renders the output of the verification of the selected candidate if it failed.
+func (v *CompareView) renderOutput() []string {
+	verification := v.verifications[v.Selected()]
+	if verification == nil || verification.running {
+		return nil
+	}
+	var text string
+	switch {
+	case verification.err != nil:
+		text = verification.err.Error()
+	case verification.result != nil && !verification.result.Passed:
+		text = fmt.Sprintf("$ %s\n%s", verification.result.Command, verification.result.Output)
+	default:
+		return nil
+	}
+	lines := []string{""}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		lines = append(lines, transcriptHintStyle.Render(runewidth.Truncate(line, v.width, "…")))
+	}
+	return lines
+}
+
+// renderDiffs renders the diffs of the selected and the other candidate in two columns.
+func (v *CompareView) renderDiffs(height int) []string {
+	if len(v.candidates) == 0 {
+		return nil
+	}
+	columnWidth := max((v.width-1)/2, 1)
+	left := compareDiffColumn(v.candidates[v.selected], columnWidth)
+	var right []string
+	if v.other != v.selected {
+		right = compareDiffColumn(v.candidates[v.other], columnWidth)
+	}
+
+	v.offset = min(v.offset, max(max(len(left), len(right))-height, 0))
+	blank := strings.Repeat(" ", columnWidth)
+	lines := make([]string, 0, height)
+	for i := v.offset; i < v.offset+height && (i < len(left) || i < len(right)); i++ {
+		l, r := blank, ""
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		lines = append(lines, l+sideBySideSep+r)
+	}
+	return lines
+}
+
+// compareDiffColumn renders the unified diff of an instance with every line padded to the width.
+func compareDiffColumn(instance *session.Instance, width int) []string {
+	pad := func(line string) string {
+		return line + strings.Repeat(" ", max(width-lipgloss.Width(line), 0))
+	}
+	plain := func(style lipgloss.Style, text string) string {
+		return pad(style.Render(runewidth.Truncate(text, width, "…")))
+	}
+
+	lines := []string{plain(compareSelectedStyle, instance.Title)}
+	stats := instance.GetDiffStats()
+	switch {
+	case stats == nil:
+		return append(lines, plain(transcriptHintStyle, "no diff yet"))
+	case stats.Error != nil:
+		return append(lines, plain(compareFailedStyle, stats.Error.Error()))
+	case stats.IsEmpty():
+		return append(lines, plain(transcriptHintStyle, "no changes"))
+	}
+
+	files := stats.ParsedFiles()
+	for f := range files {
+		file := &files[f]
+		name := file.Path()
+		if file.IsRename() {
+			name = fmt.Sprintf("%s → %s", file.OldPath, file.NewPath)
+		}
+		counts := fmt.Sprintf(" +%d -%d", file.Added, file.Removed)
+		header := runewidth.Truncate(fmt.Sprintf("%s %s", fileStatusGlyph(file), name),
+			max(width-len(counts), 1), "…")
+		lines = append(lines, pad(fileHeaderStyle.Render(header)+" "+
+			AdditionStyle.Render(fmt.Sprintf("+%d", file.Added))+" "+
+			DeletionStyle.Render(fmt.Sprintf("-%d", file.Removed))))
+
+		lang := languageForPath(name)
+		for h := range file.Hunks {
+			hunk := &file.Hunks[h]
+			lines = append(lines, plain(HunkStyle, hunk.Header))
+			renderer := newHunkRenderer(hunk, lang)
+			for idx, line := range hunk.Lines {
+				content := renderer.line(idx).truncate(max(width-1, 0))
+				lines = append(lines, pad(diffLinePrefix(line.Kind, false)+content.render(false)))
+			}
+		}
+	}
+	return lines
+}
+
+// statusName describes the status of an instance in a word.
+func statusName(instance *session.Instance) string {
+	switch {
+	case !instance.Started():
+		return "starting"
+	case instance.Paused():
+		return "paused"
+	}
+	switch instance.Status {
+	case session.Running:
+		return "running"
+	case session.Ready:
+		return "ready"
+	case session.Loading:
+		return "loading"
+	default:
+		return "unknown"
+	}
+}
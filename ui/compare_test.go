@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareView(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old line\n+%s line\n"
+	var candidates []*session.Instance
+	for _, name := range []string{"claude", "aider", "codex"} {
+		instance, err := session.FromInstanceData(session.InstanceData{
+			Title:     "fix-" + name,
+			Program:   name,
+			Group:     "fix",
+			Status:    session.Paused,
+			DiffStats: session.DiffStatsData{Added: 1, Removed: 1, Content: fmt.Sprintf(diff, name)},
+		})
+		require.NoError(t, err)
+		candidates = append(candidates, instance)
+	}
+	v := NewCompareView("fix", candidates)
+	v.SetSize(120, 30)
+
+	key := func(s string) CompareAction {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+		switch s {
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		return v.HandleKeyPress(msg)
+	}
+
+	out := v.String()
+	assert.Contains(t, out, "Compare fix")
+	assert.Contains(t, out, "fix-claude")
+	assert.Contains(t, out, "+claude line")
+	assert.Contains(t, out, "+aider line")
+	assert.NotContains(t, out, "+codex line")
+	assert.Equal(t, 30, strings.Count(out, "\n")+1)
+
+	// The other column skips the selected candidate.
+	assert.Equal(t, CompareNone, key("tab"))
+	assert.Contains(t, v.String(), "+codex line")
+	assert.Equal(t, CompareNone, key("tab"))
+	assert.Contains(t, v.String(), "+aider line")
+	assert.Equal(t, CompareNone, key("j"))
+	assert.Equal(t, candidates[1], v.Selected())
+	assert.Contains(t, v.String(), "+codex line")
+
+	v.SetVerifying(candidates[0])
+	assert.True(t, v.Verifying(candidates[0]))
+	assert.Contains(t, v.String(), "running…")
+	v.SetVerification(candidates[1], &session.Verification{
+		Command: "go test ./...", Duration: 2 * time.Second, Output: "--- FAIL: TestX"}, nil)
+	v.SetVerification(candidates[2], nil, nil)
+	out = v.String()
+	assert.Contains(t, out, "failed in 2s")
+	assert.Contains(t, out, "--- FAIL: TestX")
+	assert.Contains(t, out, "no verify command")
+
+	assert.Equal(t, CompareVerify, key("v"))
+	assert.Equal(t, CompareKeep, key("enter"))
+	assert.Equal(t, CompareClose, key("q"))
+}
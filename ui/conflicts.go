@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	conflictTitleStyle  = lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("#7D56F4"))
+	conflictHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#36CFC9"))
+	conflictPairStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFCC00"))
+)
+
+// RenderConflictMatrix renders the conflict report as a list of colliding session pairs per repository,
+// each followed by the paths that both sessions modify.
+func RenderConflictMatrix(report *session.ConflictReport) string {
+	lines := []string{conflictTitleStyle.Render("File Conflicts"), ""}
+	if report.IsEmpty() {
+		lines = append(lines, "No files are modified by more than one session.")
+		return lipgloss.JoinVertical(lipgloss.Left, lines...)
+	}
+
+	// repo path -> "a ↔ b" -> paths
+	pairs := make(map[string]map[string][]string)
+	var repos []string
+	for _, conflict := range report.Conflicts {
+		if _, ok := pairs[conflict.RepoPath]; !ok {
+			pairs[conflict.RepoPath] = make(map[string][]string)
+			repos = append(repos, conflict.RepoPath)
+		}
+		for a := 0; a < len(conflict.Sessions); a++ {
+			for b := a + 1; b < len(conflict.Sessions); b++ {
+				pair := fmt.Sprintf("%s ↔ %s", conflict.Sessions[a], conflict.Sessions[b])
+				pairs[conflict.RepoPath][pair] = append(pairs[conflict.RepoPath][pair], conflict.Path)
+			}
+		}
+	}
+
+	for i, repo := range repos {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, conflictHeaderStyle.Render(filepath.Base(repo)+":"))
+
+		names := make([]string, 0, len(pairs[repo]))
+		for pair := range pairs[repo] {
+			names = append(names, pair)
+		}
+		sort.Strings(names)
+		for _, pair := range names {
+			paths := pairs[repo][pair]
+			lines = append(lines, conflictPairStyle.Render(pair)+fmt.Sprintf(" (%d)", len(paths)))
+			lines = append(lines, "    "+strings.Join(paths, "\n    "))
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
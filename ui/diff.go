@@ -2,7 +2,9 @@ package ui
 
 import (
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
@@ -13,89 +15,427 @@ var (
 	AdditionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
 	DeletionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef4444"))
 	HunkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#0ea5e9"))
+	ConflictStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#d97706", Dark: "#FFCC00"})
 )
 
+var (
+	fileHeaderStyle         = lipgloss.NewStyle().Bold(true)
+	selectedFileHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#1a1a1a")).Background(lipgloss.Color("#dde4f0"))
+	fileListStyle           = lipgloss.NewStyle().
+				BorderForeground(highlightColor).
+				Border(lipgloss.NormalBorder(), false, true, false, false)
+	fileListSelectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+	fileListHintStyle     = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#808080", Dark: "#808080"})
+	reviewCommentStyle    = lipgloss.NewStyle().Italic(true).Foreground(lipgloss.AdaptiveColor{Light: "#7D56F4", Dark: "#a78bfa"})
+)
+
+// minWidthForFileList is the minimum pane width at which the file list is shown next to the diff.
+const minWidthForFileList = 60
+
 type DiffPane struct {
-	viewport viewport.Model
-	diff     string
-	stats    string
-	width    int
-	height   int
+	viewport  viewport.Model
+	stats     string
+	conflicts string
+	width     int
+	height    int
+
+	// title is the title of the instance whose diff is displayed. Selection and folds are reset when it changes.
+	title string
+	// renderedKey is the raw diff and conflict text that was last rendered. Used to skip re-rendering on every tick.
+	renderedKey string
+	// files is the parsed diff of the current instance.
+	files []git.FileDiff
+	// collapsed holds the paths of files whose hunks are hidden.
+	collapsed map[string]bool
+	// selectedFile and selectedHunk are the indices of the file and hunk under the cursor.
+	selectedFile int
+	selectedHunk int
+	// selectedLine is the index of the line under the cursor within the selected hunk, or -1 for the hunk itself.
+	selectedLine int
+	// markedHunks holds the keys of the hunks marked for staging or reverting. See hunkKey.
+	markedHunks map[string]bool
+	// comments are the pending review comments of the current instance.
+	comments []session.ReviewComment
+	// fileOffsets and hunkOffsets are the line offsets of the file and hunk headers in the viewport content.
+	fileOffsets []int
+	hunkOffsets [][]int
+	// sideBySide is true if the user prefers the side-by-side view. See IsSideBySide.
+	sideBySide bool
+	// cursorOffset is the line offset of the line under the cursor in the viewport content.
+	cursorOffset int
 }
 
 func NewDiffPane() *DiffPane {
 	return &DiffPane{
-		viewport: viewport.New(0, 0),
+		viewport:     viewport.New(0, 0),
+		collapsed:    make(map[string]bool),
+		markedHunks:  make(map[string]bool),
+		selectedLine: -1,
 	}
 }
 
 func (d *DiffPane) SetSize(width, height int) {
 	d.width = width
 	d.height = height
-	d.viewport.Width = width
 	d.viewport.Height = height
+	d.viewport.Width = width
 	// Update viewport content if diff exists
-	if d.diff != "" || d.stats != "" {
-		d.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, d.stats, d.diff))
+	if len(d.files) > 0 {
+		d.render()
 	}
 }
 
-func (d *DiffPane) SetDiff(instance *session.Instance) {
-	centeredFallbackMessage := lipgloss.Place(
-		d.width,
-		d.height,
-		lipgloss.Center,
-		lipgloss.Center,
-		"No changes",
-	)
+// fileListWidth returns the width of the file list, or 0 if it should not be displayed.
+func (d *DiffPane) fileListWidth() int {
+	if len(d.files) == 0 || d.width < minWidthForFileList {
+		return 0
+	}
+	width := d.width / 4
+	if width < 20 {
+		width = 20
+	}
+	if width > 40 {
+		width = 40
+	}
+	return width
+}
 
+// SetConflicts sets the files which the current instance shares with other instances, mapped to the titles of
+// those instances. Call this before SetDiff.
+func (d *DiffPane) SetConflicts(conflicts map[string][]string) {
+	if len(conflicts) == 0 {
+		d.conflicts = ""
+		return
+	}
+
+	paths := make([]string, 0, len(conflicts))
+	for path := range conflicts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	lines := []string{ConflictStyle.Render(fmt.Sprintf("⚠ %d file(s) also modified by other sessions:", len(paths)))}
+	for _, path := range paths {
+		lines = append(lines, ConflictStyle.Render(fmt.Sprintf("  %s (%s)", path, strings.Join(conflicts[path], ", "))))
+	}
+	d.conflicts = strings.Join(lines, "\n")
+}
+
+// setFallback displays a centered message instead of a diff.
+func (d *DiffPane) setFallback(message string) {
+	d.files = nil
+	d.renderedKey = ""
+	d.viewport.Width = d.width
+	d.viewport.SetContent(lipgloss.Place(d.width, d.height, lipgloss.Center, lipgloss.Center, message))
+}
+
+func (d *DiffPane) SetDiff(instance *session.Instance) {
 	if instance == nil || !instance.Started() {
-		d.viewport.SetContent(centeredFallbackMessage)
+		d.title = ""
+		d.setFallback("No changes")
 		return
 	}
 
-	stats := instance.GetDiffStats()
+	if instance.Title != d.title {
+		// A different instance was selected, so start from the top with everything expanded.
+		d.title = instance.Title
+		d.selectedFile = 0
+		d.selectedHunk = 0
+		d.selectedLine = -1
+		d.collapsed = make(map[string]bool)
+		d.markedHunks = make(map[string]bool)
+		d.viewport.GotoTop()
+	}
+	d.comments = instance.ReviewComments()
+
+	stats := instance.GetScopedDiffStats()
+	scope := instance.DiffScopeLabel()
 	if stats == nil {
 		// Show loading message if worktree is not ready
-		centeredMessage := lipgloss.Place(
-			d.width,
-			d.height,
-			lipgloss.Center,
-			lipgloss.Center,
-			"Setting up worktree...",
-		)
-		d.viewport.SetContent(centeredMessage)
+		d.setFallback("Setting up worktree...")
 		return
 	}
 
 	if stats.Error != nil {
 		// Show error message
-		centeredMessage := lipgloss.Place(
-			d.width,
-			d.height,
-			lipgloss.Center,
-			lipgloss.Center,
-			fmt.Sprintf("Error: %v", stats.Error),
-		)
-		d.viewport.SetContent(centeredMessage)
+		d.setFallback(fmt.Sprintf("Error: %v", stats.Error))
 		return
 	}
 
 	if stats.IsEmpty() {
 		d.stats = ""
-		d.diff = ""
-		d.viewport.SetContent(centeredFallbackMessage)
-	} else {
+		d.setFallback(fmt.Sprintf("No changes (%s)", scope))
+		return
+	}
+
+	if key := scope + stats.Content + d.conflicts + commentsKey(d.comments); key != d.renderedKey || len(d.files) == 0 {
 		additions := AdditionStyle.Render(fmt.Sprintf("%d additions(+)", stats.Added))
 		deletions := DeletionStyle.Render(fmt.Sprintf("%d deletions(-)", stats.Removed))
-		d.stats = lipgloss.JoinHorizontal(lipgloss.Center, additions, " ", deletions)
-		d.diff = colorizeDiff(stats.Content)
-		d.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, d.stats, d.diff))
+		d.stats = lipgloss.JoinHorizontal(lipgloss.Center, additions, " ", deletions,
+			fmt.Sprintf(" in %d file(s) ", len(stats.ParsedFiles())), HunkStyle.Render("["+scope+"]"))
+		d.files = stats.ParsedFiles()
+		d.renderedKey = key
+		d.clampSelection()
+		d.render()
+	}
+}
+
+// clampSelection keeps the cursor within the current files and hunks after the diff changed.
+func (d *DiffPane) clampSelection() {
+	if d.selectedFile >= len(d.files) {
+		d.selectedFile = len(d.files) - 1
+	}
+	if d.selectedFile < 0 {
+		d.selectedFile = 0
+		d.selectedHunk = 0
+		return
+	}
+	if hunks := len(d.files[d.selectedFile].Hunks); d.selectedHunk >= hunks {
+		d.selectedHunk = hunks - 1
+	}
+	if d.selectedHunk < 0 {
+		d.selectedHunk = 0
+	}
+	if hunk := d.currentHunk(); hunk == nil || d.selectedLine >= len(hunk.Lines) {
+		d.selectedLine = -1
+	}
+}
+
+// commentsKey returns a string which changes whenever the comments change.
+func commentsKey(comments []session.ReviewComment) string {
+	var b strings.Builder
+	for _, comment := range comments {
+		fmt.Fprintf(&b, "\x00%s:%d:%s:%s", comment.Path, comment.Line, comment.Code, comment.Text)
+	}
+	return b.String()
+}
+
+// hunkKey identifies a hunk across re-renders of the same diff.
+func hunkKey(file *git.FileDiff, hunk *git.Hunk) string {
+	return file.Path() + "\x00" + hunk.Header
+}
+
+// currentHunk returns the hunk under the cursor, or nil if there is none.
+func (d *DiffPane) currentHunk() *git.Hunk {
+	if d.selectedFile >= len(d.files) {
+		return nil
 	}
+	file := &d.files[d.selectedFile]
+	if d.selectedHunk >= len(file.Hunks) {
+		return nil
+	}
+	return &file.Hunks[d.selectedHunk]
+}
+
+// render builds the viewport content from the parsed files and records the offsets of file and hunk headers.
+func (d *DiffPane) render() {
+	var lines []string
+	if d.stats != "" {
+		lines = append(lines, d.stats)
+	}
+	if d.conflicts != "" {
+		lines = append(lines, strings.Split(d.conflicts, "\n")...)
+	}
+	lines = append(lines, "")
+
+	d.viewport.Width = d.width - d.fileListWidth()
+	if d.fileListWidth() > 0 {
+		d.viewport.Width -= fileListStyle.GetHorizontalFrameSize()
+	}
+	// Both columns of the side-by-side view share the width, minus the separator.
+	columnWidth := 0
+	if d.IsSideBySide() {
+		columnWidth = (d.viewport.Width - 1) / 2
+	}
+
+	d.fileOffsets = make([]int, len(d.files))
+	d.hunkOffsets = make([][]int, len(d.files))
+	for i := range d.files {
+		file := &d.files[i]
+		d.fileOffsets[i] = len(lines)
+		if i == d.selectedFile {
+			d.cursorOffset = len(lines)
+		}
+		lines = append(lines, d.renderFileHeader(i))
+		d.hunkOffsets[i] = make([]int, len(file.Hunks))
+		if d.collapsed[file.Path()] {
+			continue
+		}
+		if file.IsBinary {
+			lines = append(lines, "Binary file")
+		}
+		lang := languageForPath(file.Path())
+		for j := range file.Hunks {
+			hunk := &file.Hunks[j]
+			isCurrent := i == d.selectedFile && j == d.selectedHunk
+			d.hunkOffsets[i][j] = len(lines)
+			if isCurrent {
+				d.cursorOffset = len(lines)
+			}
+			marker := "  "
+			if isCurrent {
+				marker = "▶ "
+			}
+			if d.markedHunks[hunkKey(file, hunk)] {
+				marker += "✓ "
+			}
+			lines = append(lines, HunkStyle.Render(marker+hunk.Header))
+			lines = append(lines, d.renderComments(file.Path(), 0, hunk.Header)...)
+
+			cursorLine := -1
+			if isCurrent {
+				cursorLine = d.selectedLine
+			}
+			renderer := newHunkRenderer(hunk, lang)
+			if columnWidth > 0 {
+				for _, row := range renderer.sideBySideRows() {
+					if cursorLine >= 0 && (row.left == cursorLine || row.right == cursorLine) {
+						d.cursorOffset = len(lines)
+					}
+					lines = append(lines, renderer.sideBySide(row, columnWidth, cursorLine))
+					lines = append(lines, d.renderLineComments(file.Path(), hunk, row.left)...)
+					if row.right != row.left {
+						lines = append(lines, d.renderLineComments(file.Path(), hunk, row.right)...)
+					}
+				}
+				continue
+			}
+			for k := range hunk.Lines {
+				if k == cursorLine {
+					d.cursorOffset = len(lines)
+				}
+				lines = append(lines, renderer.unified(k, k == cursorLine))
+				lines = append(lines, d.renderLineComments(file.Path(), hunk, k)...)
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	d.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// renderComments renders the review comments on the given line below it. A line number of 0 matches any line,
+// which is used for comments on a whole hunk.
+func (d *DiffPane) renderComments(path string, lineNumber int, code string) []string {
+	var lines []string
+	for _, comment := range d.comments {
+		if comment.Path != path || comment.Code != code || (lineNumber != 0 && comment.Line != lineNumber) {
+			continue
+		}
+		for _, text := range strings.Split(comment.Text, "\n") {
+			lines = append(lines, reviewCommentStyle.Render("    ┃ "+text))
+		}
+	}
+	return lines
+}
+
+// renderLineComments renders the review comments on the line of the hunk at the given index. The index may be -1.
+func (d *DiffPane) renderLineComments(path string, hunk *git.Hunk, idx int) []string {
+	if idx < 0 {
+		return nil
+	}
+	line := hunk.Lines[idx]
+	return d.renderComments(path, diffLineNumber(line), line.String())
+}
+
+// diffLineNumber returns the line number shown for a diff line: the new line, or the old line if it was removed.
+func diffLineNumber(line git.DiffLine) int {
+	if line.Kind == git.DiffLineRemoved {
+		return line.OldLine
+	}
+	return line.NewLine
+}
+
+// renderFileHeader renders the line introducing a file in the diff.
+func (d *DiffPane) renderFileHeader(idx int) string {
+	file := &d.files[idx]
+	fold := "▾"
+	if d.collapsed[file.Path()] {
+		fold = "▸"
+	}
+	name := file.Path()
+	if file.IsRename() {
+		name = fmt.Sprintf("%s → %s", file.OldPath, file.NewPath)
+	}
+	header := fmt.Sprintf("%s %s %s", fold, fileStatusGlyph(file), name)
+
+	style := fileHeaderStyle
+	if idx == d.selectedFile {
+		style = selectedFileHeaderStyle
+	}
+	return style.Render(header) + " " + AdditionStyle.Render(fmt.Sprintf("+%d", file.Added)) + " " +
+		DeletionStyle.Render(fmt.Sprintf("-%d", file.Removed))
+}
+
+// fileStatusGlyph returns a one letter status like git status --short.
+func fileStatusGlyph(file *git.FileDiff) string {
+	switch {
+	case file.IsNew:
+		return "A"
+	case file.IsDeleted:
+		return "D"
+	case file.IsRename():
+		return "R"
+	default:
+		return "M"
+	}
+}
+
+// renderFileList renders the list of changed files shown on the left of the diff.
+func (d *DiffPane) renderFileList() string {
+	width := d.fileListWidth()
+	hint := fileListHintStyle.Render(truncateLeft("[] file {} hunk z fold", width))
+	available := d.height - 1
+	if available < 1 {
+		available = 1
+	}
+
+	// Scroll the list so the selected file is always visible.
+	start := 0
+	if d.selectedFile >= available {
+		start = d.selectedFile - available + 1
+	}
+
+	var lines []string
+	for i := start; i < len(d.files) && len(lines) < available; i++ {
+		file := &d.files[i]
+		counts := fmt.Sprintf(" +%d -%d", file.Added, file.Removed)
+		name := truncateLeft(file.Path(), width-2-2-len(counts))
+		line := fmt.Sprintf("%s %s%s", fileStatusGlyph(file), name, counts)
+		if i == d.selectedFile {
+			lines = append(lines, fileListSelectedStyle.Render("› "+line))
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	for len(lines) < available {
+		lines = append(lines, "")
+	}
+	lines = append(lines, hint)
+
+	return fileListStyle.Width(width).Height(d.height).Render(strings.Join(lines, "\n"))
+}
+
+// truncateLeft shortens s to width by cutting characters from the start, so the file name stays visible.
+func truncateLeft(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 {
+		return ""
+	}
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[len(runes)-width:])
+	}
+	return "…" + string(runes[len(runes)-width+1:])
 }
 
 func (d *DiffPane) String() string {
-	return d.viewport.View()
+	if d.fileListWidth() == 0 {
+		return d.viewport.View()
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, d.renderFileList(), d.viewport.View())
 }
 
 // ScrollUp scrolls the viewport up
@@ -108,30 +448,240 @@ func (d *DiffPane) ScrollDown() {
 	d.viewport.LineDown(1)
 }
 
-func colorizeDiff(diff string) string {
-	var coloredOutput strings.Builder
-
-	lines := strings.Split(diff, "\n")
-	for _, line := range lines {
-		if len(line) > 0 {
-			if strings.HasPrefix(line, "@@") {
-				// Color hunk headers cyan
-				coloredOutput.WriteString(HunkStyle.Render(line) + "\n")
-			} else if line[0] == '+' && (len(line) == 1 || line[1] != '+') {
-				// Color added lines green, excluding metadata like '+++'
-				coloredOutput.WriteString(AdditionStyle.Render(line) + "\n")
-			} else if line[0] == '-' && (len(line) == 1 || line[1] != '-') {
-				// Color removed lines red, excluding metadata like '---'
-				coloredOutput.WriteString(DeletionStyle.Render(line) + "\n")
-			} else {
-				// Print metadata and unchanged lines without color
-				coloredOutput.WriteString(line + "\n")
+// NextFile moves the cursor to the next file and scrolls to it.
+func (d *DiffPane) NextFile() {
+	if d.selectedFile < len(d.files)-1 {
+		d.selectedFile++
+		d.selectedHunk = 0
+	}
+	d.selectedLine = -1
+	d.scrollToSelection()
+}
+
+// PrevFile moves the cursor to the previous file and scrolls to it.
+func (d *DiffPane) PrevFile() {
+	if d.selectedFile > 0 {
+		d.selectedFile--
+		d.selectedHunk = 0
+	}
+	d.selectedLine = -1
+	d.scrollToSelection()
+}
+
+// NextHunk moves the cursor to the next hunk, continuing into the next file at the end of the current one.
+func (d *DiffPane) NextHunk() {
+	if len(d.files) == 0 {
+		return
+	}
+	file := &d.files[d.selectedFile]
+	if !d.collapsed[file.Path()] && d.selectedHunk < len(file.Hunks)-1 {
+		d.selectedHunk++
+	} else if d.selectedFile < len(d.files)-1 {
+		d.selectedFile++
+		d.selectedHunk = 0
+	}
+	d.selectedLine = -1
+	d.scrollToSelection()
+}
+
+// PrevHunk moves the cursor to the previous hunk, continuing into the previous file at the start of the current one.
+func (d *DiffPane) PrevHunk() {
+	if len(d.files) == 0 {
+		return
+	}
+	file := &d.files[d.selectedFile]
+	if !d.collapsed[file.Path()] && d.selectedHunk > 0 {
+		d.selectedHunk--
+	} else if d.selectedFile > 0 {
+		d.selectedFile--
+		d.selectedHunk = len(d.files[d.selectedFile].Hunks) - 1
+		if d.selectedHunk < 0 {
+			d.selectedHunk = 0
+		}
+	}
+	d.selectedLine = -1
+	d.scrollToSelection()
+}
+
+// ToggleFile collapses or expands the hunks of the selected file.
+func (d *DiffPane) ToggleFile() {
+	if len(d.files) == 0 {
+		return
+	}
+	path := d.files[d.selectedFile].Path()
+	d.collapsed[path] = !d.collapsed[path]
+	d.selectedHunk = 0
+	d.selectedLine = -1
+	d.scrollToSelection()
+}
+
+// ToggleAllFiles collapses all files, or expands all of them if they are already collapsed.
+func (d *DiffPane) ToggleAllFiles() {
+	if len(d.files) == 0 {
+		return
+	}
+	collapse := false
+	for i := range d.files {
+		if !d.collapsed[d.files[i].Path()] {
+			collapse = true
+			break
+		}
+	}
+	for i := range d.files {
+		d.collapsed[d.files[i].Path()] = collapse
+	}
+	d.selectedHunk = 0
+	d.selectedLine = -1
+	d.scrollToSelection()
+}
+
+// scrollToSelection re-renders the selection markers and scrolls the selected hunk, or the file if it has no
+// visible hunks, to the top of the viewport.
+func (d *DiffPane) scrollToSelection() {
+	if len(d.files) == 0 {
+		return
+	}
+	d.render()
+	offset := d.fileOffsets[d.selectedFile]
+	if file := &d.files[d.selectedFile]; !d.collapsed[file.Path()] && d.selectedHunk < len(file.Hunks) && d.selectedHunk > 0 {
+		offset = d.hunkOffsets[d.selectedFile][d.selectedHunk]
+	}
+	d.viewport.SetYOffset(offset)
+}
+
+// NextLine moves the line cursor down, continuing into the next hunk at the end of the current one.
+func (d *DiffPane) NextLine() {
+	hunk := d.currentHunk()
+	if hunk == nil || d.collapsed[d.files[d.selectedFile].Path()] {
+		d.NextHunk()
+		return
+	}
+	if d.selectedLine < len(hunk.Lines)-1 {
+		d.selectedLine++
+		d.scrollToCursor()
+		return
+	}
+	prevFile, prevHunk := d.selectedFile, d.selectedHunk
+	d.NextHunk()
+	if d.selectedFile != prevFile || d.selectedHunk != prevHunk {
+		return
+	}
+	d.scrollToCursor()
+}
+
+// PrevLine moves the line cursor up, continuing at the last line of the previous hunk at the start of the
+// current one.
+func (d *DiffPane) PrevLine() {
+	if d.selectedLine >= 0 {
+		d.selectedLine--
+		d.scrollToCursor()
+		return
+	}
+	prevFile, prevHunk := d.selectedFile, d.selectedHunk
+	d.PrevHunk()
+	if d.selectedFile == prevFile && d.selectedHunk == prevHunk {
+		return
+	}
+	if hunk := d.currentHunk(); hunk != nil && !d.collapsed[d.files[d.selectedFile].Path()] {
+		d.selectedLine = len(hunk.Lines) - 1
+	}
+	d.scrollToCursor()
+}
+
+// scrollToCursor re-renders the cursor and scrolls just enough to keep the line under it visible.
+func (d *DiffPane) scrollToCursor() {
+	d.render()
+	if d.cursorOffset < d.viewport.YOffset {
+		d.viewport.SetYOffset(d.cursorOffset)
+	} else if height := d.viewport.Height; height > 0 && d.cursorOffset >= d.viewport.YOffset+height {
+		d.viewport.SetYOffset(d.cursorOffset - height + 1)
+	}
+}
+
+// ToggleHunkMark marks or unmarks the hunk under the cursor for staging or reverting.
+func (d *DiffPane) ToggleHunkMark() {
+	hunk := d.currentHunk()
+	if hunk == nil || d.collapsed[d.files[d.selectedFile].Path()] {
+		return
+	}
+	key := hunkKey(&d.files[d.selectedFile], hunk)
+	if d.markedHunks[key] {
+		delete(d.markedHunks, key)
+	} else {
+		d.markedHunks[key] = true
+	}
+	d.render()
+}
+
+// ClearHunkMarks unmarks all hunks.
+func (d *DiffPane) ClearHunkMarks() {
+	d.markedHunks = make(map[string]bool)
+	if len(d.files) > 0 {
+		d.render()
+	}
+}
+
+// MarkedPatch returns a patch of all marked hunks that can be passed to git apply, along with the number of
+// hunks it contains. If no hunk is marked, the patch contains the hunk under the cursor.
+func (d *DiffPane) MarkedPatch() (string, int) {
+	var patch strings.Builder
+	count := 0
+	for i := range d.files {
+		file := &d.files[i]
+		var hunks []int
+		for j := range file.Hunks {
+			if d.markedHunks[hunkKey(file, &file.Hunks[j])] {
+				hunks = append(hunks, j)
 			}
-		} else {
-			// Preserve empty lines
-			coloredOutput.WriteString("\n")
 		}
+		if len(hunks) > 0 {
+			patch.WriteString(file.Patch(hunks...))
+			count += len(hunks)
+		}
+	}
+	if count > 0 {
+		return patch.String(), count
+	}
+
+	if d.currentHunk() == nil || d.collapsed[d.files[d.selectedFile].Path()] {
+		return "", 0
 	}
+	return d.files[d.selectedFile].Patch(d.selectedHunk), 1
+}
 
-	return coloredOutput.String()
+// CommentTarget returns an empty comment on the line under the cursor, or on the selected hunk if the cursor is
+// not on a line. It returns false if there is nothing to comment on.
+func (d *DiffPane) CommentTarget() (session.ReviewComment, bool) {
+	hunk := d.currentHunk()
+	if hunk == nil || d.collapsed[d.files[d.selectedFile].Path()] {
+		return session.ReviewComment{}, false
+	}
+	comment := session.ReviewComment{Path: d.files[d.selectedFile].Path()}
+	if d.selectedLine < 0 {
+		comment.Line = hunk.NewStart
+		comment.Code = hunk.Header
+	} else {
+		line := hunk.Lines[d.selectedLine]
+		comment.Line = diffLineNumber(line)
+		comment.Code = line.String()
+	}
+	return comment, true
+}
+
+// ToggleSideBySide switches between the unified and the side-by-side view. The side-by-side view is only used
+// while the pane is wide enough.
+func (d *DiffPane) ToggleSideBySide() {
+	d.sideBySide = !d.sideBySide
+	if len(d.files) > 0 {
+		d.render()
+	}
+}
+
+// IsSideBySide returns true if the diff is currently shown side by side.
+func (d *DiffPane) IsSideBySide() bool {
+	width := d.width - d.fileListWidth()
+	if d.fileListWidth() > 0 {
+		width -= fileListStyle.GetHorizontalFrameSize()
+	}
+	return d.sideBySide && width >= minWidthForSideBySide
 }
@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	syntaxKeywordColor = lipgloss.AdaptiveColor{Light: "#8250df", Dark: "#c678dd"}
+	syntaxStringColor  = lipgloss.AdaptiveColor{Light: "#0a3069", Dark: "#e5c07b"}
+	syntaxCommentColor = lipgloss.AdaptiveColor{Light: "#6e7781", Dark: "#7f848e"}
+	syntaxNumberColor  = lipgloss.AdaptiveColor{Light: "#953800", Dark: "#d19a66"}
+
+	addedWordBackground   = lipgloss.AdaptiveColor{Light: "#abf2bc", Dark: "#1f4a2c"}
+	removedWordBackground = lipgloss.AdaptiveColor{Light: "#ffc1c0", Dark: "#5c1a1f"}
+
+	lineNumberStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#a0a0a0", Dark: "#5c6370"})
+	sideBySideSep   = lipgloss.NewStyle().Foreground(highlightColor).Render("│")
+)
+
+// minWidthForSideBySide is the minimum width of the diff viewport at which the side-by-side view is used.
+const minWidthForSideBySide = 100
+
+// tabWidth is the number of spaces a tab is expanded to.
+const tabWidth = 4
+
+// maxWordDiffTokens limits the size of lines that get intra-line highlighting, since the diff is quadratic.
+const maxWordDiffTokens = 200
+
+// styledLine is the content of a diff line along with the information needed to color every rune.
+type styledLine struct {
+	kind    git.DiffLineKind
+	runes   []rune
+	syntax  []syntaxKind
+	changed []bool
+}
+
+// newStyledLine expands the tabs of the line and highlights its syntax. changed may be nil.
+func newStyledLine(line git.DiffLine, lang *syntaxLanguage, changed []bool) styledLine {
+	runes := []rune(line.Content)
+	if line.Kind == git.DiffLineNoNewline {
+		lang = nil
+	}
+	syntax := highlightSyntax(runes, lang)
+
+	// Expand tabs after highlighting so that the flags stay aligned with the runes.
+	var expanded []rune
+	var expandedSyntax []syntaxKind
+	var expandedChanged []bool
+	for i, r := range runes {
+		count := 1
+		if r == '\t' {
+			r, count = ' ', tabWidth
+		}
+		for j := 0; j < count; j++ {
+			expanded = append(expanded, r)
+			expandedSyntax = append(expandedSyntax, syntax[i])
+			expandedChanged = append(expandedChanged, changed != nil && changed[i])
+		}
+	}
+	return styledLine{kind: line.Kind, runes: expanded, syntax: expandedSyntax, changed: expandedChanged}
+}
+
+// truncate cuts the line to the given display width.
+func (l styledLine) truncate(width int) styledLine {
+	used := 0
+	for i, r := range l.runes {
+		used += runewidth.RuneWidth(r)
+		if used > width {
+			return styledLine{kind: l.kind, runes: l.runes[:i], syntax: l.syntax[:i], changed: l.changed[:i]}
+		}
+	}
+	return l
+}
+
+// width returns the display width of the line.
+func (l styledLine) width() int {
+	return runewidth.StringWidth(string(l.runes))
+}
+
+// render colors the line. Runs of runes with the same syntax kind and change flag are rendered together.
+func (l styledLine) render(cursor bool) string {
+	var b strings.Builder
+	for start := 0; start < len(l.runes); {
+		end := start + 1
+		for end < len(l.runes) && l.syntax[end] == l.syntax[start] && l.changed[end] == l.changed[start] {
+			end++
+		}
+		b.WriteString(l.style(l.syntax[start], l.changed[start], cursor).Render(string(l.runes[start:end])))
+		start = end
+	}
+	return b.String()
+}
+
+func (l styledLine) style(syntax syntaxKind, changed bool, cursor bool) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch l.kind {
+	case git.DiffLineAdded:
+		style = AdditionStyle
+		if changed {
+			style = style.Background(addedWordBackground)
+		}
+	case git.DiffLineRemoved:
+		style = DeletionStyle
+		if changed {
+			style = style.Background(removedWordBackground)
+		}
+	}
+	switch syntax {
+	case syntaxKeyword:
+		style = style.Foreground(syntaxKeywordColor)
+	case syntaxString:
+		style = style.Foreground(syntaxStringColor)
+	case syntaxComment:
+		style = style.Foreground(syntaxCommentColor)
+	case syntaxNumber:
+		style = style.Foreground(syntaxNumberColor)
+	}
+	if cursor {
+		style = style.Reverse(true)
+	}
+	return style
+}
+
+// diffLinePrefix renders the +, - or space in front of a line.
+func diffLinePrefix(kind git.DiffLineKind, cursor bool) string {
+	style := lipgloss.NewStyle()
+	prefix := " "
+	switch kind {
+	case git.DiffLineAdded:
+		style, prefix = AdditionStyle, "+"
+	case git.DiffLineRemoved:
+		style, prefix = DeletionStyle, "-"
+	case git.DiffLineNoNewline:
+		prefix = ""
+	}
+	return style.Reverse(cursor).Render(prefix)
+}
+
+// hunkRenderer renders the lines of a hunk, either unified or side by side.
+type hunkRenderer struct {
+	hunk *git.Hunk
+	lang *syntaxLanguage
+	// changed holds the intra-line changes of removed and added lines which replace each other.
+	changed map[int][]bool
+}
+
+func newHunkRenderer(hunk *git.Hunk, lang *syntaxLanguage) *hunkRenderer {
+	r := &hunkRenderer{hunk: hunk, lang: lang, changed: make(map[int][]bool)}
+	for removed, added := range pairChangedLines(hunk.Lines) {
+		oldChanged, newChanged, ok := wordDiff([]rune(hunk.Lines[removed].Content), []rune(hunk.Lines[added].Content))
+		if ok {
+			r.changed[removed] = oldChanged
+			r.changed[added] = newChanged
+		}
+	}
+	return r
+}
+
+// line returns the styled content of the line at the given index.
+func (r *hunkRenderer) line(idx int) styledLine {
+	return newStyledLine(r.hunk.Lines[idx], r.lang, r.changed[idx])
+}
+
+// unified renders the line at the given index like git diff does.
+func (r *hunkRenderer) unified(idx int, cursor bool) string {
+	return diffLinePrefix(r.hunk.Lines[idx].Kind, cursor) + r.line(idx).render(cursor)
+}
+
+// sideBySideRow is a row of the side-by-side view. Either side is -1 if it is empty.
+type sideBySideRow struct {
+	left, right int
+}
+
+// sideBySideRows lays out the lines of the hunk so that removed lines are shown next to the added lines which
+// replace them, and context lines on both sides.
+func (r *hunkRenderer) sideBySideRows() []sideBySideRow {
+	var rows []sideBySideRow
+	lines := r.hunk.Lines
+	for i := 0; i < len(lines); {
+		switch lines[i].Kind {
+		case git.DiffLineRemoved, git.DiffLineAdded:
+			var removed, added []int
+			for ; i < len(lines) && lines[i].Kind == git.DiffLineRemoved; i++ {
+				removed = append(removed, i)
+			}
+			for ; i < len(lines) && lines[i].Kind == git.DiffLineAdded; i++ {
+				added = append(added, i)
+			}
+			for j := 0; j < max(len(removed), len(added)); j++ {
+				row := sideBySideRow{left: -1, right: -1}
+				if j < len(removed) {
+					row.left = removed[j]
+				}
+				if j < len(added) {
+					row.right = added[j]
+				}
+				rows = append(rows, row)
+			}
+		case git.DiffLineNoNewline:
+			// The marker belongs to the side of the line before it.
+			row := sideBySideRow{left: i, right: i}
+			if i > 0 && lines[i-1].Kind == git.DiffLineRemoved {
+				row.right = -1
+			} else if i > 0 && lines[i-1].Kind == git.DiffLineAdded {
+				row.left = -1
+			}
+			rows = append(rows, row)
+			i++
+		default:
+			rows = append(rows, sideBySideRow{left: i, right: i})
+			i++
+		}
+	}
+	return rows
+}
+
+// sideBySide renders a row of the side-by-side view with both columns padded to the given width.
+func (r *hunkRenderer) sideBySide(row sideBySideRow, columnWidth int, cursorLine int) string {
+	return r.sideBySideCell(row.left, true, columnWidth, cursorLine) + sideBySideSep +
+		r.sideBySideCell(row.right, false, columnWidth, cursorLine)
+}
+
+// sideBySideCell renders one side of a row: the line number in the old or new file and the content.
+func (r *hunkRenderer) sideBySideCell(idx int, old bool, width int, cursorLine int) string {
+	const gutterWidth = 6
+	if idx < 0 || width <= gutterWidth {
+		return strings.Repeat(" ", max(width, 0))
+	}
+
+	line := r.hunk.Lines[idx]
+	number := line.NewLine
+	if old {
+		number = line.OldLine
+	}
+	gutter := strings.Repeat(" ", gutterWidth-1)
+	if line.Kind != git.DiffLineNoNewline {
+		gutter = lineNumberStyle.Render(fmt.Sprintf("%4d ", number))
+	}
+
+	cursor := idx == cursorLine
+	content := r.line(idx).truncate(width - gutterWidth)
+	padding := strings.Repeat(" ", width-gutterWidth-content.width())
+	prefix := diffLinePrefix(line.Kind, cursor)
+	if line.Kind == git.DiffLineNoNewline {
+		prefix = " "
+	}
+	return gutter + prefix + content.render(cursor) + padding
+}
+
+// pairChangedLines maps removed lines to the added lines which directly follow and replace them. The first
+// removed line of a block is paired with the first added line, and so on.
+func pairChangedLines(lines []git.DiffLine) map[int]int {
+	pairs := make(map[int]int)
+	for i := 0; i < len(lines); {
+		if lines[i].Kind != git.DiffLineRemoved {
+			i++
+			continue
+		}
+		var removed []int
+		for ; i < len(lines) && lines[i].Kind == git.DiffLineRemoved; i++ {
+			removed = append(removed, i)
+		}
+		for j := 0; i < len(lines) && lines[i].Kind == git.DiffLineAdded; i, j = i+1, j+1 {
+			if j < len(removed) {
+				pairs[removed[j]] = i
+			}
+		}
+	}
+	return pairs
+}
+
+// wordDiff compares two lines word by word and returns which runes of each were changed. It returns false if
+// the lines are too long or have nothing in common, in which case highlighting the words adds nothing.
+func wordDiff(old, new []rune) (oldChanged, newChanged []bool, ok bool) {
+	oldTokens, newTokens := tokenizeWords(old), tokenizeWords(new)
+	if len(oldTokens) > maxWordDiffTokens || len(newTokens) > maxWordDiffTokens {
+		return nil, nil, false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of oldTokens[i:] and newTokens[j:].
+	lcs := make([][]int, len(oldTokens)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newTokens)+1)
+	}
+	for i := len(oldTokens) - 1; i >= 0; i-- {
+		for j := len(newTokens) - 1; j >= 0; j-- {
+			if string(oldTokens[i]) == string(newTokens[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	oldChanged, newChanged = make([]bool, len(old)), make([]bool, len(new))
+	oldPos, newPos, common := 0, 0, 0
+	i, j := 0, 0
+	for i < len(oldTokens) || j < len(newTokens) {
+		switch {
+		case i < len(oldTokens) && j < len(newTokens) && string(oldTokens[i]) == string(newTokens[j]):
+			if strings.TrimSpace(string(oldTokens[i])) != "" {
+				common++
+			}
+			oldPos += len(oldTokens[i])
+			newPos += len(newTokens[j])
+			i++
+			j++
+		case j < len(newTokens) && (i == len(oldTokens) || lcs[i][j+1] >= lcs[i+1][j]):
+			for k := 0; k < len(newTokens[j]); k++ {
+				newChanged[newPos+k] = true
+			}
+			newPos += len(newTokens[j])
+			j++
+		default:
+			for k := 0; k < len(oldTokens[i]); k++ {
+				oldChanged[oldPos+k] = true
+			}
+			oldPos += len(oldTokens[i])
+			i++
+		}
+	}
+	return oldChanged, newChanged, common > 0
+}
+
+// tokenizeWords splits a line into words, runs of whitespace and single punctuation characters.
+func tokenizeWords(line []rune) [][]rune {
+	var tokens [][]rune
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	for i := 0; i < len(line); {
+		start := i
+		switch {
+		case isWord(line[i]):
+			for i < len(line) && isWord(line[i]) {
+				i++
+			}
+		case unicode.IsSpace(line[i]):
+			for i < len(line) && unicode.IsSpace(line[i]) {
+				i++
+			}
+		default:
+			i++
+		}
+		tokens = append(tokens, line[start:i])
+	}
+	return tokens
+}
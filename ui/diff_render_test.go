@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"claude-squad/session/git"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordDiff(t *testing.T) {
+	oldChanged, newChanged, ok := wordDiff([]rune(`x := foo(a)`), []rune(`x := bar(a, b)`))
+	require.True(t, ok)
+
+	changedText := func(line string, changed []bool) string {
+		var out []rune
+		for i, r := range []rune(line) {
+			if changed[i] {
+				out = append(out, r)
+			}
+		}
+		return string(out)
+	}
+	assert.Equal(t, "foo", changedText(`x := foo(a)`, oldChanged))
+	assert.Equal(t, "bar, b", changedText(`x := bar(a, b)`, newChanged))
+
+	_, _, ok = wordDiff([]rune("alpha"), []rune("beta"))
+	assert.False(t, ok, "lines without common words are not highlighted")
+}
+
+func TestHighlightSyntax(t *testing.T) {
+	line := []rune(`return "a" // 1`)
+	kinds := highlightSyntax(line, languageForPath("main.go"))
+
+	assert.Equal(t, syntaxKeyword, kinds[0])
+	assert.Equal(t, syntaxPlain, kinds[6])
+	assert.Equal(t, syntaxString, kinds[7])
+	assert.Equal(t, syntaxComment, kinds[11])
+	assert.Equal(t, []syntaxKind(make([]syntaxKind, len(line))), highlightSyntax(line, languageForPath("notes.txt")))
+}
+
+func TestSideBySideRows(t *testing.T) {
+	hunk := &git.Hunk{Lines: []git.DiffLine{
+		{Kind: git.DiffLineContext},
+		{Kind: git.DiffLineRemoved},
+		{Kind: git.DiffLineRemoved},
+		{Kind: git.DiffLineAdded},
+		{Kind: git.DiffLineAdded},
+		{Kind: git.DiffLineAdded},
+	}}
+
+	assert.Equal(t, []sideBySideRow{
+		{left: 0, right: 0},
+		{left: 1, right: 3},
+		{left: 2, right: 4},
+		{left: -1, right: 5},
+	}, newHunkRenderer(hunk, nil).sideBySideRows())
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// syntaxKind is the kind of a piece of code, used to pick its color.
+type syntaxKind int
+
+const (
+	syntaxPlain syntaxKind = iota
+	syntaxKeyword
+	syntaxString
+	syntaxComment
+	syntaxNumber
+)
+
+// syntaxLanguage describes just enough of a language to highlight single lines of a diff. Constructs spanning
+// multiple lines, like block comments or multi-line strings, are not tracked.
+type syntaxLanguage struct {
+	keywords map[string]bool
+	// lineComments start a comment which runs until the end of the line.
+	lineComments []string
+	// quotes are the characters which start and end a string.
+	quotes string
+}
+
+func newSyntaxLanguage(keywords string, lineComments []string, quotes string) *syntaxLanguage {
+	lang := &syntaxLanguage{keywords: make(map[string]bool), lineComments: lineComments, quotes: quotes}
+	for _, keyword := range strings.Fields(keywords) {
+		lang.keywords[keyword] = true
+	}
+	return lang
+}
+
+var (
+	goSyntax = newSyntaxLanguage(`break case chan const continue default defer else fallthrough for func go goto if
+		import interface map package range return select struct switch type var nil true false`,
+		[]string{"//"}, "\"'`")
+	pythonSyntax = newSyntaxLanguage(`and as assert async await break class continue def del elif else except False
+		finally for from global if import in is lambda None nonlocal not or pass raise return True try while with
+		yield self`,
+		[]string{"#"}, "\"'")
+	jsSyntax = newSyntaxLanguage(`async await break case catch class const continue debugger default delete do else
+		enum export extends false finally for from function if implements import in instanceof interface let new
+		null of private protected public readonly return static super switch this throw true try type typeof
+		undefined var void while yield`,
+		[]string{"//"}, "\"'`")
+	rustSyntax = newSyntaxLanguage(`as async await break const continue crate dyn else enum extern false fn for if
+		impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use
+		where while`,
+		[]string{"//"}, "\"")
+	cSyntax = newSyntaxLanguage(`auto break case catch char class const continue default delete do double else
+		enum extends extern false final finally float for goto if implements import int interface long namespace
+		new null nullptr package private protected public return short signed sizeof static struct super switch
+		template this throw true try typedef union unsigned using virtual void volatile while`,
+		[]string{"//"}, "\"'")
+	shellSyntax = newSyntaxLanguage(`case do done elif else esac export fi for function if in local return then
+		until while`,
+		[]string{"#"}, "\"'")
+	rubySyntax = newSyntaxLanguage(`begin class def do else elsif end ensure false if module nil require rescue
+		return self then true unless until while yield`,
+		[]string{"#"}, "\"'")
+)
+
+// syntaxLanguages maps file extensions to the language used to highlight them.
+var syntaxLanguages = map[string]*syntaxLanguage{
+	".go":   goSyntax,
+	".py":   pythonSyntax,
+	".js":   jsSyntax,
+	".jsx":  jsSyntax,
+	".mjs":  jsSyntax,
+	".ts":   jsSyntax,
+	".tsx":  jsSyntax,
+	".rs":   rustSyntax,
+	".c":    cSyntax,
+	".h":    cSyntax,
+	".cc":   cSyntax,
+	".cpp":  cSyntax,
+	".hpp":  cSyntax,
+	".java": cSyntax,
+	".kt":   cSyntax,
+	".cs":   cSyntax,
+	".sh":   shellSyntax,
+	".bash": shellSyntax,
+	".zsh":  shellSyntax,
+	".rb":   rubySyntax,
+}
+
+// languageForPath returns the language of the file, or nil if the extension is unknown.
+func languageForPath(path string) *syntaxLanguage {
+	return syntaxLanguages[strings.ToLower(filepath.Ext(path))]
+}
+
+// highlightSyntax returns the syntax kind of every rune of the line. The language may be nil, in which case
+// everything is plain.
+func highlightSyntax(line []rune, lang *syntaxLanguage) []syntaxKind {
+	kinds := make([]syntaxKind, len(line))
+	if lang == nil {
+		return kinds
+	}
+
+	for i := 0; i < len(line); {
+		r := line[i]
+		switch {
+		case lang.startsLineComment(line[i:]):
+			for ; i < len(line); i++ {
+				kinds[i] = syntaxComment
+			}
+		case strings.ContainsRune(lang.quotes, r):
+			start := i
+			for i++; i < len(line) && line[i] != r; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			for j := start; j < i && j < len(line); j++ {
+				kinds[j] = syntaxString
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(line) && (unicode.IsLetter(line[i]) || unicode.IsDigit(line[i]) || line[i] == '_') {
+				i++
+			}
+			if lang.keywords[string(line[start:i])] {
+				for j := start; j < i; j++ {
+					kinds[j] = syntaxKeyword
+				}
+			}
+		case unicode.IsDigit(r):
+			for i < len(line) && (unicode.IsDigit(line[i]) || unicode.IsLetter(line[i]) || line[i] == '.' || line[i] == '_') {
+				kinds[i] = syntaxNumber
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return kinds
+}
+
+func (l *syntaxLanguage) startsLineComment(rest []rune) bool {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(string(rest[:min(len(rest), len(prefix))]), prefix) {
+			return true
+		}
+	}
+	return false
+}
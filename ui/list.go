@@ -5,14 +5,19 @@ import (
 	"claude-squad/session"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 const readyIcon = "● "
 const pausedIcon = "⏸ "
+const conflictIcon = "⚠"
+const autoYesIcon = "»"
 
 var readyStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#51bd73", Dark: "#51bd73"})
@@ -23,6 +28,12 @@ var addedLinesStyle = lipgloss.NewStyle().
 var removedLinesStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("#de613e"))
 
+var conflictStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#d97706", Dark: "#FFCC00"})
+
+var autoYesIconStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("62"))
+
 var pausedStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#888888", Dark: "#888888"})
 
@@ -52,9 +63,56 @@ var autoYesStyle = lipgloss.NewStyle().
 	Background(lipgloss.Color("#dde4f0")).
 	Foreground(lipgloss.Color("#1a1a1a"))
 
+var listStatusStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"})
+
+var groupHeaderStyle = lipgloss.NewStyle().
+	Padding(1, 1, 0, 1).
+	Bold(true).
+	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
+
+var selectedGroupHeaderStyle = groupHeaderStyle.
+	Background(lipgloss.Color("#dde4f0")).
+	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#1a1a1a"})
+
+// SortOrder is the order of the instances within the list and within each of its groups.
+type SortOrder int
+
+const (
+	// SortCreated shows the instances in the order in which they were created.
+	SortCreated SortOrder = iota
+	// SortUpdated shows the most recently active instances first.
+	SortUpdated
+	// SortDiffSize shows the instances with the most added and removed lines first.
+	SortDiffSize
+)
+
+func (s SortOrder) String() string {
+	switch s {
+	case SortUpdated:
+		return "updated"
+	case SortDiffSize:
+		return "diff size"
+	default:
+		return "created"
+	}
+}
+
+// listRow is a row of the list, which is either an instance or the header of a group.
+type listRow struct {
+	// instance is nil for a group header.
+	instance *session.Instance
+	group    string
+	// count is the number of visible instances in the group of a header.
+	count int
+}
+
 type List struct {
-	items         []*session.Instance
+	items []*session.Instance
+	// rows are the visible rows, rebuilt from items by update.
+	rows          []listRow
 	selectedIdx   int
+	offset        int
 	height, width int
 	renderer      *InstanceRenderer
 	autoyes       bool
@@ -62,14 +120,28 @@ type List struct {
 	// map of repo name to number of instances using it. Used to display the repo name only if there are
 	// multiple repos in play.
 	repos map[string]int
+
+	// conflicts holds the files which are modified by more than one instance. May be nil.
+	conflicts *session.ConflictReport
+
+	filterText    string
+	filter        session.Filter
+	editingFilter bool
+	sortOrder     SortOrder
+	// collapsed holds the groups whose instances are hidden.
+	collapsed map[string]bool
+	// marked holds the instances marked for sending them all the same prompt.
+	marked map[*session.Instance]bool
 }
 
 func NewList(spinner *spinner.Model, autoYes bool) *List {
 	return &List{
-		items:    []*session.Instance{},
-		renderer: &InstanceRenderer{spinner: spinner},
-		repos:    make(map[string]int),
-		autoyes:  autoYes,
+		items:     []*session.Instance{},
+		renderer:  &InstanceRenderer{spinner: spinner},
+		repos:     make(map[string]int),
+		autoyes:   autoYes,
+		collapsed: make(map[string]bool),
+		marked:    make(map[*session.Instance]bool),
 	}
 }
 
@@ -100,12 +172,93 @@ func (l *List) NumInstances() int {
 	return len(l.items)
 }
 
+// update rebuilds the visible rows from the instances, applying the filter, the sort order and the collapsed
+// groups. The selection stays on the same instance or group header where possible.
+func (l *List) update() {
+	var previous listRow
+	if l.selectedIdx < len(l.rows) {
+		previous = l.rows[l.selectedIdx]
+	}
+
+	// Instances which are being created are always shown, so that the name can be entered.
+	var visible []*session.Instance
+	for _, item := range l.items {
+		if !item.Started() || l.filter.Match(item) {
+			visible = append(visible, item)
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool {
+		switch l.sortOrder {
+		case SortUpdated:
+			return visible[i].LastActivity().After(visible[j].LastActivity())
+		case SortDiffSize:
+			return diffSize(visible[i]) > diffSize(visible[j])
+		default:
+			return false
+		}
+	})
+
+	// Instances without a group come first, then the groups by name.
+	l.rows = l.rows[:0]
+	groups := make(map[string][]*session.Instance)
+	var names []string
+	for _, item := range visible {
+		if item.Group == "" {
+			l.rows = append(l.rows, listRow{instance: item})
+			continue
+		}
+		if _, ok := groups[item.Group]; !ok {
+			names = append(names, item.Group)
+		}
+		groups[item.Group] = append(groups[item.Group], item)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		l.rows = append(l.rows, listRow{group: name, count: len(groups[name])})
+		if l.collapsed[name] {
+			continue
+		}
+		for _, item := range groups[name] {
+			l.rows = append(l.rows, listRow{instance: item, group: name})
+		}
+	}
+
+	for idx, row := range l.rows {
+		if previous.instance != nil && row.instance == previous.instance {
+			l.selectedIdx = idx
+			return
+		}
+	}
+	// The instance may be in a group which was collapsed, or it may be a header which was selected.
+	for idx, row := range l.rows {
+		if row.instance == nil && row.group == previous.group && (previous.instance == nil || l.collapsed[row.group]) {
+			l.selectedIdx = idx
+			return
+		}
+	}
+	l.selectedIdx = max(min(l.selectedIdx, len(l.rows)-1), 0)
+}
+
+// diffSize returns the number of added and removed lines of the instance.
+func diffSize(i *session.Instance) int {
+	stat := i.GetDiffStats()
+	if stat == nil || stat.Error != nil {
+		return 0
+	}
+	return stat.Added + stat.Removed
+}
+
 // InstanceRenderer handles rendering of session.Instance objects
 type InstanceRenderer struct {
 	spinner *spinner.Model
 	width   int
 }
 
+// SetConflicts sets the conflict report used to flag instances that edit the same files.
+func (l *List) SetConflicts(report *session.ConflictReport) {
+	l.conflicts = report
+}
+
 func (r *InstanceRenderer) setWidth(width int) {
 	r.width = AdjustPreviewWidth(width)
 }
@@ -113,11 +266,15 @@ func (r *InstanceRenderer) setWidth(width int) {
 // ɹ and ɻ are other options.
 const branchIcon = "Ꮧ"
 
-func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, hasMultipleRepos bool) string {
+func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, hasMultipleRepos bool, hasConflicts bool,
+	marked bool) string {
 	prefix := fmt.Sprintf(" %d. ", idx)
 	if idx >= 10 {
 		prefix = prefix[:len(prefix)-1]
 	}
+	if marked {
+		prefix = "✓" + prefix[1:]
+	}
 	titleS := selectedTitleStyle
 	descS := selectedDescStyle
 	if !selected {
@@ -128,7 +285,7 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	// add spinner next to title if it's running
 	var join string
 	switch i.Status {
-	case session.Running:
+	case session.Running, session.Loading:
 		join = fmt.Sprintf("%s ", r.spinner.View())
 	case session.Ready:
 		join = readyStyle.Render(readyIcon)
@@ -137,15 +294,29 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	default:
 	}
 
+	// Flag instances which modify files that other instances on the same repo also modify, and those whose prompts
+	// are answered automatically.
+	var conflict string
+	conflictWidth := 0
+	if hasConflicts {
+		conflict = conflictStyle.Background(titleS.GetBackground()).Render(conflictIcon + " ")
+		conflictWidth = 2
+	}
+	if i.AutoYes {
+		conflict += autoYesIconStyle.Background(titleS.GetBackground()).Render(autoYesIcon + " ")
+		conflictWidth += 2
+	}
+
 	// Cut the title if it's too long
 	titleText := i.Title
-	widthAvail := r.width - 3 - len(prefix) - 1
+	widthAvail := r.width - 3 - len(prefix) - 1 - conflictWidth
 	if widthAvail > 0 && widthAvail < len(titleText) && len(titleText) >= widthAvail-3 {
 		titleText = titleText[:widthAvail-3] + "..."
 	}
 	title := titleS.Render(lipgloss.JoinHorizontal(
 		lipgloss.Left,
-		lipgloss.Place(r.width-3, 1, lipgloss.Left, lipgloss.Center, fmt.Sprintf("%s %s", prefix, titleText)),
+		lipgloss.Place(r.width-3-conflictWidth, 1, lipgloss.Left, lipgloss.Center, fmt.Sprintf("%s %s", prefix, titleText)),
+		conflict,
 		" ",
 		join,
 	))
@@ -226,6 +397,8 @@ func (l *List) String() string {
 	const titleText = " Instances "
 	const autoYesText = " auto-yes "
 
+	l.update()
+
 	// Write the title.
 	var b strings.Builder
 	b.WriteString("\n")
@@ -247,45 +420,114 @@ func (l *List) String() string {
 	}
 
 	b.WriteString("\n")
+	b.WriteString(l.statusLine(titleWidth))
 	b.WriteString("\n")
 
-	// Render the list.
-	for i, item := range l.items {
-		b.WriteString(l.renderer.Render(item, i+1, i == l.selectedIdx, len(l.repos) > 1))
-		if i != len(l.items)-1 {
+	// Render the rows, numbering the instances.
+	blocks := make([]string, len(l.rows))
+	number := 0
+	for i, row := range l.rows {
+		if row.instance == nil {
+			blocks[i] = l.renderGroupHeader(row, i == l.selectedIdx)
+			continue
+		}
+		number++
+		blocks[i] = l.renderer.Render(row.instance, number, i == l.selectedIdx, len(l.repos) > 1,
+			l.conflicts.HasConflicts(row.instance.Title), l.marked[row.instance])
+	}
+
+	// Scroll so that the selected row is visible. Every row is followed by an empty line.
+	available := l.height - strings.Count(b.String(), "\n")
+	heightOf := func(from, to int) int {
+		height := -1
+		for i := from; i <= to; i++ {
+			height += lipgloss.Height(blocks[i]) + 1
+		}
+		return height
+	}
+	l.offset = max(min(l.offset, l.selectedIdx), 0)
+	for l.offset < l.selectedIdx && heightOf(l.offset, l.selectedIdx) > available {
+		l.offset++
+	}
+	last := l.offset
+	for last+1 < len(blocks) && heightOf(l.offset, last+1) <= available {
+		last++
+	}
+	for i := l.offset; i <= last && i < len(blocks); i++ {
+		b.WriteString(blocks[i])
+		if i != last {
 			b.WriteString("\n\n")
 		}
 	}
 	return lipgloss.Place(l.width, l.height, lipgloss.Left, lipgloss.Top, b.String())
 }
 
-// Down selects the next item in the list.
-func (l *List) Down() {
-	if len(l.items) == 0 {
-		return
+// statusLine returns the filter bar and the sort order, or an empty line if neither is set.
+func (l *List) statusLine(width int) string {
+	var parts []string
+	if l.editingFilter || l.filterText != "" {
+		filter := "filter: " + l.filterText
+		if l.editingFilter {
+			filter += "█"
+		}
+		shown := 0
+		for _, row := range l.rows {
+			if row.instance != nil {
+				shown++
+			}
+		}
+		// Instances in collapsed groups match the filter too.
+		for _, row := range l.rows {
+			if row.instance == nil && l.collapsed[row.group] {
+				shown += row.count
+			}
+		}
+		parts = append(parts, filter, fmt.Sprintf("%d of %d", shown, len(l.items)))
+	}
+	if l.sortOrder != SortCreated {
+		parts = append(parts, "sorted by "+l.sortOrder.String())
+	}
+	if len(l.marked) > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", len(l.marked)))
+	}
+	if len(parts) == 0 {
+		return ""
 	}
-	if l.selectedIdx < len(l.items)-1 {
+	return listStatusStyle.Render(runewidth.Truncate(" "+strings.Join(parts, " · "), width, "…"))
+}
+
+func (l *List) renderGroupHeader(row listRow, selected bool) string {
+	icon := "▾"
+	if l.collapsed[row.group] {
+		icon = "▸"
+	}
+	style := groupHeaderStyle
+	if selected {
+		style = selectedGroupHeaderStyle
+	}
+	text := runewidth.Truncate(fmt.Sprintf(" %s %s (%d)", icon, row.group, row.count), max(l.renderer.width-1, 0), "…")
+	return style.Render(lipgloss.Place(max(l.renderer.width-1, 0), 1, lipgloss.Left, lipgloss.Center, text))
+}
+
+// Down selects the next row in the list.
+func (l *List) Down() {
+	if l.selectedIdx < len(l.rows)-1 {
 		l.selectedIdx++
 	}
 }
 
-// Kill selects the next item in the list.
+// Kill kills the selected instance and removes it from the list.
 func (l *List) Kill() {
-	if len(l.items) == 0 {
+	targetInstance := l.GetSelectedInstance()
+	if targetInstance == nil {
 		return
 	}
-	targetInstance := l.items[l.selectedIdx]
 
 	// Kill the tmux session
 	if err := targetInstance.Kill(); err != nil {
 		log.ErrorLog.Printf("could not kill instance: %v", err)
 	}
 
-	// If you delete the last one in the list, select the previous one.
-	if l.selectedIdx == len(l.items)-1 {
-		defer l.Up()
-	}
-
 	// Unregister the reponame.
 	repoName, err := targetInstance.RepoName()
 	if err != nil {
@@ -294,20 +536,47 @@ func (l *List) Kill() {
 		l.rmRepo(repoName)
 	}
 
-	// Since there's items after this, the selectedIdx can stay the same.
-	l.items = append(l.items[:l.selectedIdx], l.items[l.selectedIdx+1:]...)
+	// The row after the killed one takes its place, or the previous one if it was the last.
+	l.removeItem(targetInstance)
+}
+
+// Remove removes an instance from the list without killing it, like one which failed to start.
+func (l *List) Remove(instance *session.Instance) {
+	l.removeItem(instance)
+}
+
+func (l *List) removeItem(instance *session.Instance) {
+	delete(l.marked, instance)
+	for idx, item := range l.items {
+		if item == instance {
+			l.items = append(l.items[:idx], l.items[idx+1:]...)
+			break
+		}
+	}
+	l.update()
+}
+
+// RemoveKilled removes an instance which was killed elsewhere, like by the reaper.
+func (l *List) RemoveKilled(instance *session.Instance) {
+	repoName, err := instance.RepoName()
+	if err != nil {
+		log.ErrorLog.Printf("could not get repo name: %v", err)
+	} else {
+		l.rmRepo(repoName)
+	}
+	l.Remove(instance)
 }
 
 func (l *List) Attach() (chan struct{}, error) {
-	targetInstance := l.items[l.selectedIdx]
+	targetInstance := l.GetSelectedInstance()
+	if targetInstance == nil {
+		return nil, fmt.Errorf("no instance selected")
+	}
 	return targetInstance.Attach()
 }
 
-// Up selects the prev item in the list.
+// Up selects the previous row in the list.
 func (l *List) Up() {
-	if len(l.items) == 0 {
-		return
-	}
 	if l.selectedIdx > 0 {
 		l.selectedIdx--
 	}
@@ -336,6 +605,7 @@ func (l *List) rmRepo(repo string) {
 // When creating a new one and entering the name, you want to call the finalizer once the name is done.
 func (l *List) AddInstance(instance *session.Instance) (finalize func()) {
 	l.items = append(l.items, instance)
+	l.update()
 	// The finalizer registers the repo name once the instance is started.
 	return func() {
 		repoName, err := instance.RepoName()
@@ -348,23 +618,150 @@ func (l *List) AddInstance(instance *session.Instance) (finalize func()) {
 	}
 }
 
-// GetSelectedInstance returns the currently selected instance
+// GetSelectedInstance returns the currently selected instance. It returns nil if a group header is selected.
 func (l *List) GetSelectedInstance() *session.Instance {
-	if len(l.items) == 0 {
+	if l.selectedIdx >= len(l.rows) {
 		return nil
 	}
-	return l.items[l.selectedIdx]
+	return l.rows[l.selectedIdx].instance
 }
 
-// SetSelectedInstance sets the selected index. Noop if the index is out of bounds.
-func (l *List) SetSelectedInstance(idx int) {
-	if idx >= len(l.items) {
+// ToggleMark marks the selected instance, or unmarks it if it's marked.
+func (l *List) ToggleMark() {
+	instance := l.GetSelectedInstance()
+	if instance == nil {
 		return
 	}
-	l.selectedIdx = idx
+	if l.marked[instance] {
+		delete(l.marked, instance)
+	} else {
+		l.marked[instance] = true
+	}
+}
+
+// Marked returns the marked instances in the order they were added, including those hidden by the filter.
+func (l *List) Marked() []*session.Instance {
+	var marked []*session.Instance
+	for _, instance := range l.items {
+		if l.marked[instance] {
+			marked = append(marked, instance)
+		}
+	}
+	return marked
+}
+
+// ClearMarks unmarks all instances.
+func (l *List) ClearMarks() {
+	clear(l.marked)
+}
+
+// SelectedGroup returns the group of the selected instance or group header.
+func (l *List) SelectedGroup() string {
+	if l.selectedIdx >= len(l.rows) {
+		return ""
+	}
+	if instance := l.rows[l.selectedIdx].instance; instance != nil {
+		return instance.Group
+	}
+	return l.rows[l.selectedIdx].group
+}
+
+// SelectNext selects the closest visible instance in the direction of step, wrapping around, for which accept
+// returns true. It returns false if there is no other such instance.
+func (l *List) SelectNext(step int, accept func(*session.Instance) bool) bool {
+	for n := 1; n < len(l.rows); n++ {
+		idx := ((l.selectedIdx+n*step)%len(l.rows) + len(l.rows)) % len(l.rows)
+		if instance := l.rows[idx].instance; instance != nil && accept(instance) {
+			l.selectedIdx = idx
+			return true
+		}
+	}
+	return false
+}
+
+// SetSelectedInstance selects the instance with the index among the visible instances. Noop if the index is out of
+// bounds.
+func (l *List) SetSelectedInstance(idx int) {
+	for i, row := range l.rows {
+		if row.instance == nil {
+			continue
+		}
+		if idx == 0 {
+			l.selectedIdx = i
+			return
+		}
+		idx--
+	}
+}
+
+// SelectInstance selects the instance, expanding its group and clearing the filter if they hide it.
+func (l *List) SelectInstance(instance *session.Instance) {
+	if instance.Started() && !l.filter.Match(instance) {
+		l.setFilter("")
+	}
+	delete(l.collapsed, instance.Group)
+	l.update()
+	for i, row := range l.rows {
+		if row.instance == instance {
+			l.selectedIdx = i
+			return
+		}
+	}
+}
+
+// ToggleGroup collapses or expands the group whose header is selected. It returns false if no header is selected.
+func (l *List) ToggleGroup() bool {
+	if l.selectedIdx >= len(l.rows) || l.rows[l.selectedIdx].instance != nil {
+		return false
+	}
+	group := l.rows[l.selectedIdx].group
+	l.collapsed[group] = !l.collapsed[group]
+	l.update()
+	return true
+}
+
+// CycleSort switches to the next sort order and returns it.
+func (l *List) CycleSort() SortOrder {
+	l.sortOrder = (l.sortOrder + 1) % (SortDiffSize + 1)
+	l.update()
+	return l.sortOrder
+}
+
+// StartFilter starts editing the filter. See FilterKey.
+func (l *List) StartFilter() {
+	l.editingFilter = true
+}
+
+// FilterKey edits the filter, which is applied as it is typed. Enter keeps the filter and esc clears it. It returns
+// true once editing is done.
+func (l *List) FilterKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEnter:
+		l.editingFilter = false
+		return true
+	case tea.KeyEsc, tea.KeyCtrlC:
+		l.editingFilter = false
+		l.setFilter("")
+		return true
+	case tea.KeyBackspace:
+		if runes := []rune(l.filterText); len(runes) > 0 {
+			l.setFilter(string(runes[:len(runes)-1]))
+		}
+	case tea.KeyCtrlU:
+		l.setFilter("")
+	case tea.KeyRunes, tea.KeySpace:
+		l.setFilter(l.filterText + string(msg.Runes))
+	}
+	return false
+}
+
+func (l *List) setFilter(text string) {
+	l.filterText = text
+	l.filter = session.ParseFilter(text)
+	l.update()
 }
 
-// GetInstances returns all instances in the list
+// GetInstances returns all instances in the list, including those hidden by the filter or collapsed groups.
 func (l *List) GetInstances() []*session.Instance {
 	return l.items
 }
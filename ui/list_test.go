@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rowNames returns the titles of the visible instances and the names of the group headers in brackets.
+func rowNames(l *List) []string {
+	var names []string
+	for _, row := range l.rows {
+		if row.instance == nil {
+			names = append(names, "["+row.group+"]")
+		} else {
+			names = append(names, row.instance.Title)
+		}
+	}
+	return names
+}
+
+func newTestList(t *testing.T) (*List, map[string]*session.Instance) {
+	s := spinner.New()
+	l := NewList(&s, false)
+	instances := make(map[string]*session.Instance)
+	now := time.Now()
+	for i, data := range []session.InstanceData{
+		{Title: "a", DiffStats: session.DiffStatsData{Added: 1}},
+		{Title: "b", Group: "web", Tags: []string{"urgent"}, DiffStats: session.DiffStatsData{Added: 10}},
+		{Title: "c", Group: "api"},
+		{Title: "d", Tags: []string{"urgent"}, DiffStats: session.DiffStatsData{Added: 3, Removed: 2}},
+	} {
+		data.Status = session.Paused
+		data.LastActivity = now.Add(time.Duration(i) * time.Minute)
+		instance, err := session.FromInstanceData(data)
+		require.NoError(t, err)
+		l.AddInstance(instance)
+		instances[data.Title] = instance
+	}
+	return l, instances
+}
+
+func TestListGroups(t *testing.T) {
+	l, instances := newTestList(t)
+	assert.Equal(t, []string{"a", "d", "[api]", "c", "[web]", "b"}, rowNames(l))
+	assert.Equal(t, instances["a"], l.GetSelectedInstance())
+
+	l.Down()
+	l.Down()
+	assert.Nil(t, l.GetSelectedInstance())
+	require.True(t, l.ToggleGroup())
+	assert.Equal(t, []string{"a", "d", "[api]", "[web]", "b"}, rowNames(l))
+	assert.Equal(t, "api", l.rows[l.selectedIdx].group)
+
+	// Collapsing the group of the selected instance selects its header.
+	l.Down()
+	l.Down()
+	assert.Equal(t, instances["b"], l.GetSelectedInstance())
+	l.collapsed["web"] = true
+	l.update()
+	assert.Equal(t, "web", l.rows[l.selectedIdx].group)
+	assert.Nil(t, l.GetSelectedInstance())
+
+	// Selecting an instance in a collapsed group expands it.
+	l.SelectInstance(instances["c"])
+	assert.Equal(t, []string{"a", "d", "[api]", "c", "[web]"}, rowNames(l))
+	assert.Equal(t, instances["c"], l.GetSelectedInstance())
+
+	l.Up()
+	assert.False(t, l.SelectNext(1, func(i *session.Instance) bool { return i.Title == "b" }))
+	assert.True(t, l.SelectNext(1, func(i *session.Instance) bool { return i.Title == "a" }))
+	assert.Equal(t, instances["a"], l.GetSelectedInstance())
+}
+
+func TestListFilterAndSort(t *testing.T) {
+	l, instances := newTestList(t)
+	l.SetSelectedInstance(3)
+	assert.Equal(t, instances["b"], l.GetSelectedInstance())
+
+	l.StartFilter()
+	for _, r := range "urgent" {
+		assert.False(t, l.FilterKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}))
+	}
+	assert.Equal(t, []string{"d", "[web]", "b"}, rowNames(l))
+	assert.Equal(t, instances["b"], l.GetSelectedInstance())
+	assert.True(t, l.FilterKey(tea.KeyMsg{Type: tea.KeyEnter}))
+	assert.Equal(t, []string{"d", "[web]", "b"}, rowNames(l))
+
+	l.StartFilter()
+	assert.True(t, l.FilterKey(tea.KeyMsg{Type: tea.KeyEsc}))
+	assert.Equal(t, []string{"a", "d", "[api]", "c", "[web]", "b"}, rowNames(l))
+
+	assert.Equal(t, SortUpdated, l.CycleSort())
+	assert.Equal(t, []string{"d", "a", "[api]", "c", "[web]", "b"}, rowNames(l))
+	assert.Equal(t, SortDiffSize, l.CycleSort())
+	assert.Equal(t, []string{"d", "a", "[api]", "c", "[web]", "b"}, rowNames(l))
+	assert.Equal(t, SortCreated, l.CycleSort())
+	assert.Equal(t, instances["b"], l.GetSelectedInstance())
+
+	// Removing the selected instance selects the row which takes its place.
+	l.SelectInstance(instances["a"])
+	l.Remove(instances["a"])
+	assert.Equal(t, instances["d"], l.GetSelectedInstance())
+	assert.Len(t, l.GetInstances(), 3)
+}
+
+func TestListScrollsToSelection(t *testing.T) {
+	l, instances := newTestList(t)
+	l.SetSize(60, 14)
+	l.SelectInstance(instances["b"])
+	out := l.String()
+	assert.Contains(t, out, " b")
+	assert.NotContains(t, out, "1. a")
+	assert.LessOrEqual(t, strings.Count(out, "\n"), 13)
+}
+
+func TestListMarks(t *testing.T) {
+	l, instances := newTestList(t)
+	l.SetSize(60, 40)
+	l.ToggleMark()
+	l.SelectInstance(instances["c"])
+	l.ToggleMark()
+	assert.Equal(t, []*session.Instance{instances["a"], instances["c"]}, l.Marked())
+	assert.Contains(t, l.String(), "2 marked")
+	assert.Contains(t, l.String(), "✓1.")
+
+	l.ToggleMark()
+	l.Remove(instances["a"])
+	assert.Empty(t, l.Marked())
+}
+
+func TestListShowsAutoYes(t *testing.T) {
+	l, instances := newTestList(t)
+	l.SetSize(60, 40)
+	assert.NotContains(t, l.String(), autoYesIcon)
+	instances["b"].AutoYes = true
+	assert.Equal(t, 1, strings.Count(l.String(), autoYesIcon))
+}
@@ -104,6 +104,11 @@ func (t *TextInputOverlay) GetValue() string {
 	return t.textarea.Value()
 }
 
+// InsertString inserts text at the cursor, like a prompt picked from the library.
+func (t *TextInputOverlay) InsertString(text string) {
+	t.textarea.InsertString(text)
+}
+
 // IsSubmitted returns whether the form was submitted.
 func (t *TextInputOverlay) IsSubmitted() bool {
 	return t.Submitted
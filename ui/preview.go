@@ -49,6 +49,25 @@ func (p *PreviewPane) setFallbackState(message string) {
 	}
 }
 
+// setLoadingState shows the last lines of the output of setting up the worktree of an instance which is starting.
+func (p *PreviewPane) setLoadingState(instance *session.Instance) {
+	message := fmt.Sprintf("Setting up '%s'...", instance.Title)
+	output := instance.SetupOutput()
+	if output == "" {
+		p.setFallbackState(message)
+		return
+	}
+	lines := strings.Split(output, "\n")
+	// Leave room for the message and the borders.
+	if maxLines := p.height - 6; maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	p.previewState = previewState{
+		fallback: false,
+		text:     lipgloss.JoinVertical(lipgloss.Left, message, "", strings.Join(lines, "\n")),
+	}
+}
+
 // Updates the preview pane content with the tmux pane content
 func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 	switch {
@@ -70,6 +89,9 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 				)),
 		))
 		return nil
+	case instance.Status == session.Loading:
+		p.setLoadingState(instance)
+		return nil
 	}
 
 	var content string
@@ -245,6 +267,26 @@ func (p *PreviewPane) ScrollDown(instance *session.Instance) error {
 	return nil
 }
 
+// ScrollToLine enters scroll mode with the full history of the instance and scrolls the line to the middle of
+// the pane. Lines are counted from the top of the history, as in the result of PreviewFullHistory.
+func (p *PreviewPane) ScrollToLine(instance *session.Instance, line int) error {
+	if instance == nil || instance.Status == session.Paused {
+		return nil
+	}
+
+	content, err := instance.PreviewFullHistory()
+	if err != nil {
+		return err
+	}
+	footer := lipgloss.NewStyle().
+		Foreground(lipgloss.AdaptiveColor{Light: "#808080", Dark: "#808080"}).
+		Render("ESC to exit scroll mode")
+	p.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, content, footer))
+	p.viewport.SetYOffset(line - p.viewport.Height/2)
+	p.isScrolling = true
+	return nil
+}
+
 // ResetToNormalMode exits scroll mode and returns to normal mode
 func (p *PreviewPane) ResetToNormalMode(instance *session.Instance) error {
 	if instance == nil || instance.Status == session.Paused {
@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptPreviewLines limits how much of the selected prompt the picker shows.
+const promptPreviewLines = 6
+
+// PromptChoice is a prompt chosen in the prompt picker, with the values of its variables. A prompt of the history
+// isn't a template, so only its Text is set.
+type PromptChoice struct {
+	Prompt   config.Prompt
+	Template *session.PromptTemplate
+	Values   map[string]string
+	Text     string
+}
+
+// PromptPicker picks a prompt of the library, filtered by name as the query is typed, and then asks for the
+// values of its variables which aren't built in. The prompts of the history follow those of the library.
+type PromptPicker struct {
+	prompts []config.Prompt
+	history []string
+	query   string
+	// matches are indexes into the prompts followed by the history.
+	matches  []int
+	selected int
+	err      error
+
+	// chosen is the prompt whose variables are being filled in, if any.
+	chosen   *PromptChoice
+	names    []string
+	inputs   []textinput.Model
+	focusIdx int
+
+	width, height int
+}
+
+// NewPromptPicker creates a picker of the given prompts and prompt history.
+func NewPromptPicker(prompts []config.Prompt, history []string) *PromptPicker {
+	p := &PromptPicker{prompts: prompts, history: history}
+	p.filter()
+	return p
+}
+
+// SetSize sets the outer size of the picker.
+func (p *PromptPicker) SetSize(width, height int) {
+	p.width, p.height = width, height
+	for i := range p.inputs {
+		p.inputs[i].Width = max(width-searchBoxStyle.GetHorizontalFrameSize()-2, 10)
+	}
+}
+
+// HandleKeyPress handles a key. It returns true if the picker should be closed, along with the chosen prompt if
+// one was chosen and its variables filled in.
+func (p *PromptPicker) HandleKeyPress(msg tea.KeyMsg) (bool, *PromptChoice) {
+	if p.chosen != nil {
+		return p.handleFormKeyPress(msg)
+	}
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return true, nil
+	case tea.KeyEnter:
+		if len(p.matches) == 0 {
+			return false, nil
+		}
+		idx := p.matches[p.selected]
+		if idx >= len(p.prompts) {
+			return true, &PromptChoice{Text: p.history[idx-len(p.prompts)]}
+		}
+		return p.choose(p.prompts[idx])
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		if p.selected < len(p.matches)-1 {
+			p.selected++
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(p.query); len(runes) > 0 {
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		p.query += string(msg.Runes)
+		p.filter()
+	}
+	return false, nil
+}
+
+// choose parses the prompt and asks for its variables, or returns it right away if it has none.
+func (p *PromptPicker) choose(prompt config.Prompt) (bool, *PromptChoice) {
+	tmpl, err := session.ParsePromptTemplate(prompt.Name, prompt.Text)
+	if err != nil {
+		p.err = err
+		return false, nil
+	}
+	p.err = nil
+	choice := &PromptChoice{Prompt: prompt, Template: tmpl, Values: make(map[string]string)}
+	p.names = tmpl.Variables()
+	if len(p.names) == 0 {
+		return true, choice
+	}
+
+	p.chosen = choice
+	p.inputs = make([]textinput.Model, len(p.names))
+	for i := range p.inputs {
+		p.inputs[i] = textinput.New()
+		p.inputs[i].Prompt = ""
+	}
+	p.focusIdx = 0
+	p.inputs[0].Focus()
+	p.SetSize(p.width, p.height)
+	return false, nil
+}
+
+// handleFormKeyPress handles a key while the variables are filled in. Enter moves to the next variable and
+// submits on the last one, and escape goes back to the list.
+func (p *PromptPicker) handleFormKeyPress(msg tea.KeyMsg) (bool, *PromptChoice) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		p.chosen, p.names, p.inputs = nil, nil, nil
+		return false, nil
+	case tea.KeyCtrlC:
+		return true, nil
+	case tea.KeyEnter:
+		if p.focusIdx == len(p.inputs)-1 {
+			for i, name := range p.names {
+				p.chosen.Values[name] = p.inputs[i].Value()
+			}
+			return true, p.chosen
+		}
+		p.focus(p.focusIdx + 1)
+	case tea.KeyTab, tea.KeyDown:
+		p.focus((p.focusIdx + 1) % len(p.inputs))
+	case tea.KeyShiftTab, tea.KeyUp:
+		p.focus((p.focusIdx - 1 + len(p.inputs)) % len(p.inputs))
+	default:
+		p.inputs[p.focusIdx], _ = p.inputs[p.focusIdx].Update(msg)
+	}
+	return false, nil
+}
+
+func (p *PromptPicker) focus(idx int) {
+	p.inputs[p.focusIdx].Blur()
+	p.focusIdx = idx
+	p.inputs[p.focusIdx].Focus()
+}
+
+// filter keeps the prompts of the library whose name contains the query, and those of the history whose text
+// does, ignoring case.
+func (p *PromptPicker) filter() {
+	p.matches = p.matches[:0]
+	query := strings.ToLower(strings.TrimSpace(p.query))
+	for i, prompt := range p.prompts {
+		if strings.Contains(strings.ToLower(prompt.Name), query) {
+			p.matches = append(p.matches, i)
+		}
+	}
+	for i, prompt := range p.history {
+		if strings.Contains(strings.ToLower(prompt), query) {
+			p.matches = append(p.matches, len(p.prompts)+i)
+		}
+	}
+	p.selected = 0
+}
+
+// text returns the text of a prompt of the library or the history.
+func (p *PromptPicker) text(idx int) string {
+	if idx >= len(p.prompts) {
+		return p.history[idx-len(p.prompts)]
+	}
+	return p.prompts[idx].Text
+}
+
+// Render renders the picker.
+func (p *PromptPicker) Render() string {
+	innerWidth := max(p.width-searchBoxStyle.GetHorizontalFrameSize(), 20)
+	innerHeight := max(p.height-searchBoxStyle.GetVerticalFrameSize(), 8)
+
+	var lines []string
+	var hint string
+	if p.chosen != nil {
+		lines = append(lines, searchHeaderStyle.Render(p.chosen.Prompt.Name)+
+			searchContextStyle.Render("  fill in the variables of the prompt"), "")
+		for i, name := range p.names {
+			label := searchContextStyle.Render(name)
+			if i == p.focusIdx {
+				label = searchSelectedStyle.Render(name)
+			}
+			lines = append(lines, label, p.inputs[i].View(), "")
+		}
+		hint = "tab next · enter on the last one inserts the prompt · esc back"
+	} else {
+		status := fmt.Sprintf("%d prompts, %d recent", len(p.prompts), len(p.history))
+		lines = append(lines, searchHeaderStyle.Render("Prompts")+searchContextStyle.Render("  "+status),
+			"> "+p.query+"█", "")
+		if p.err != nil {
+			lines = append(lines, transcriptCurrentMatchStyle.Render(truncateSearchLine(p.err.Error(), innerWidth)), "")
+		}
+
+		visible := max(innerHeight-len(lines)-promptPreviewLines-3, 1)
+		first := 0
+		if p.selected >= visible {
+			first = p.selected - visible + 1
+		}
+		for i := first; i < len(p.matches) && i < first+visible; i++ {
+			var name string
+			if idx := p.matches[i]; idx >= len(p.prompts) {
+				name, _, _ = strings.Cut(strings.TrimSpace(p.history[idx-len(p.prompts)]), "\n")
+				name = "recent: " + name
+			} else if name = p.prompts[idx].Name; p.prompts[idx].Repo {
+				name += " (repo)"
+			}
+			if i == p.selected {
+				lines = append(lines, searchSelectedStyle.Render("▌ "+truncateSearchLine(name, innerWidth-2)))
+			} else {
+				lines = append(lines, "  "+truncateSearchLine(name, innerWidth-2))
+			}
+		}
+		if len(p.prompts) == 0 && len(p.history) == 0 {
+			lines = append(lines, searchContextStyle.Render(truncateSearchLine(fmt.Sprintf(
+				"no prompts yet, add files to ~/.claude-squad/%s or %s in the repository",
+				config.PromptsDirName, config.RepoPromptsDir), innerWidth)))
+		}
+
+		if len(p.matches) > 0 {
+			lines = append(lines, "")
+			text := strings.Split(strings.TrimSpace(p.text(p.matches[p.selected])), "\n")
+			for i, line := range text {
+				if i == promptPreviewLines {
+					break
+				}
+				lines = append(lines, searchContextStyle.Render(truncateSearchLine(line, innerWidth)))
+			}
+		}
+		hint = "↑/↓ select · enter insert · esc close"
+	}
+
+	for len(lines) < innerHeight-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:innerHeight-1], searchContextStyle.Render(hint))
+	return searchBoxStyle.Width(innerWidth + 2).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptPicker(t *testing.T) {
+	p := NewPromptPicker([]config.Prompt{
+		{Name: "fix-issue", Text: "Fix {{.Issue}} in {{.Area}} on {{.Branch}}"},
+		{Name: "review", Text: "Review {{.Diff}}"},
+	}, []string{"fix the {{flaky}} tests\nplease"})
+	p.SetSize(80, 20)
+	typeText := func(text string) {
+		for _, r := range text {
+			closed, choice := p.HandleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			require.False(t, closed)
+			require.Nil(t, choice)
+		}
+	}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	// A prompt without variables to fill in is chosen right away.
+	typeText("rev")
+	assert.Contains(t, p.Render(), "Review {{.Diff}}")
+	assert.NotContains(t, p.Render(), "fix-issue")
+	closed, choice := p.HandleKeyPress(enter)
+	require.True(t, closed)
+	require.NotNil(t, choice)
+	assert.Equal(t, "review", choice.Prompt.Name)
+
+	// Prompts of the history aren't templates.
+	p = NewPromptPicker(p.prompts, p.history)
+	p.SetSize(80, 20)
+	typeText("flaky")
+	assert.Contains(t, p.Render(), "recent: fix the {{flaky}} tests")
+	closed, choice = p.HandleKeyPress(enter)
+	require.True(t, closed)
+	require.NotNil(t, choice)
+	assert.Nil(t, choice.Template)
+	assert.Equal(t, "fix the {{flaky}} tests\nplease", choice.Text)
+
+	p = NewPromptPicker(p.prompts, p.history)
+	p.SetSize(80, 20)
+	closed, choice = p.HandleKeyPress(enter)
+	require.False(t, closed)
+	require.Nil(t, choice)
+	assert.Contains(t, p.Render(), "Issue")
+	typeText("#42")
+	closed, _ = p.HandleKeyPress(enter)
+	require.False(t, closed)
+	typeText("login")
+	closed, choice = p.HandleKeyPress(enter)
+	require.True(t, closed)
+	require.NotNil(t, choice)
+	assert.Equal(t, map[string]string{"Issue": "#42", "Area": "login"}, choice.Values)
+
+	text, err := choice.Template.Render(session.PromptContext{Branch: "main"}, choice.Values)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix #42 in login on main", text)
+}
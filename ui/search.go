@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// searchResultsPerDocument limits the matches of a single pane or transcript, so that one chatty session doesn't
+// push out the others.
+const searchResultsPerDocument = 20
+
+var (
+	searchBoxStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62")).
+			Padding(0, 1)
+	searchHeaderStyle = lipgloss.NewStyle().Bold(true).
+				Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#ffffff"})
+	searchContextStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#9C9C9C", Dark: "#777777"})
+	searchSelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("62"))
+)
+
+// SearchMatch is the match chosen in the search overlay, with the query that found it.
+type SearchMatch struct {
+	session.SearchMatch
+	Query string
+}
+
+// SearchOverlay searches the panes and transcripts of all instances as the query is typed.
+type SearchOverlay struct {
+	documents []*session.SearchDocument
+	query     string
+	matches   []session.SearchMatch
+	selected  int
+
+	width, height int
+}
+
+// NewSearchOverlay creates an overlay which searches the given documents.
+func NewSearchOverlay(documents []*session.SearchDocument) *SearchOverlay {
+	return &SearchOverlay{documents: documents}
+}
+
+// SetSize sets the outer size of the overlay.
+func (s *SearchOverlay) SetSize(width, height int) {
+	s.width, s.height = width, height
+}
+
+// HandleKeyPress handles a key. It returns true if the overlay should be closed, along with the chosen match if
+// enter was pressed on one.
+func (s *SearchOverlay) HandleKeyPress(msg tea.KeyMsg) (bool, *SearchMatch) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return true, nil
+	case tea.KeyEnter:
+		if len(s.matches) == 0 {
+			return false, nil
+		}
+		return true, &SearchMatch{SearchMatch: s.matches[s.selected], Query: strings.TrimSpace(s.query)}
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+		if s.selected > 0 {
+			s.selected--
+		}
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		if s.selected < len(s.matches)-1 {
+			s.selected++
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(s.query); len(runes) > 0 {
+			s.query = string(runes[:len(runes)-1])
+			s.search()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		s.query += string(msg.Runes)
+		s.search()
+	}
+	return false, nil
+}
+
+func (s *SearchOverlay) search() {
+	s.matches = session.Search(s.documents, s.query, searchResultsPerDocument)
+	s.selected = 0
+}
+
+// Render renders the overlay.
+func (s *SearchOverlay) Render() string {
+	innerWidth := max(s.width-searchBoxStyle.GetHorizontalFrameSize(), 20)
+	innerHeight := max(s.height-searchBoxStyle.GetVerticalFrameSize(), 8)
+
+	status := "type to search the panes and transcripts of all sessions"
+	if s.query != "" {
+		status = fmt.Sprintf("%d matches", len(s.matches))
+	}
+	lines := []string{
+		searchHeaderStyle.Render("Search") + searchContextStyle.Render("  "+status),
+		"> " + s.query + "█",
+		"",
+	}
+
+	// Every match takes a header, the line before, the line itself and the line after.
+	const linesPerMatch = 4
+	visible := max((innerHeight-len(lines)-2)/linesPerMatch, 1)
+	first := 0
+	if s.selected >= visible {
+		first = s.selected - visible + 1
+	}
+	for i := first; i < len(s.matches) && i < first+visible; i++ {
+		match := s.matches[i]
+		before, after := match.Context(1)
+		marker, headerStyle := "  ", searchContextStyle
+		if i == s.selected {
+			marker, headerStyle = searchSelectedStyle.Render("▌ "), searchSelectedStyle
+		}
+		header := fmt.Sprintf("%s · %s · line %d", match.Document.Instance.Title, match.Document.Source, match.Line+1)
+		lines = append(lines, marker+headerStyle.Render(truncateSearchLine(header, innerWidth-2)))
+		for _, line := range before {
+			lines = append(lines, marker+searchContextStyle.Render(truncateSearchLine(line, innerWidth-2)))
+		}
+		lines = append(lines, marker+highlightMatches(truncateSearchLine(match.Text(), innerWidth-2), strings.TrimSpace(s.query),
+			transcriptMatchStyle))
+		for _, line := range after {
+			lines = append(lines, marker+searchContextStyle.Render(truncateSearchLine(line, innerWidth-2)))
+		}
+	}
+
+	for len(lines) < innerHeight-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:innerHeight-1], searchContextStyle.Render("↑/↓ select · enter jump to match · esc close"))
+	return searchBoxStyle.Width(innerWidth + 2).Render(strings.Join(lines, "\n"))
+}
+
+// truncateSearchLine shortens the line to the width, after expanding tabs so the width is right.
+func truncateSearchLine(line string, width int) string {
+	return runewidth.Truncate(strings.ReplaceAll(line, "\t", "    "), width, "…")
+}
@@ -3,7 +3,10 @@ package ui
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"fmt"
+
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
@@ -49,9 +52,10 @@ type TabbedWindow struct {
 	height    int
 	width     int
 
-	preview  *PreviewPane
-	diff     *DiffPane
-	instance *session.Instance
+	preview   *PreviewPane
+	diff      *DiffPane
+	instance  *session.Instance
+	conflicts *session.ConflictReport
 }
 
 func NewTabbedWindow(preview *PreviewPane, diff *DiffPane) *TabbedWindow {
@@ -120,9 +124,24 @@ func (w *TabbedWindow) UpdateDiff(instance *session.Instance) {
 	if w.activeTab != DiffTab {
 		return
 	}
+	if instance != nil {
+		w.diff.SetConflicts(w.conflicts.ForInstance(instance.Title))
+	} else {
+		w.diff.SetConflicts(nil)
+	}
 	w.diff.SetDiff(instance)
 }
 
+// GetDiffPane returns the diff pane so callers can navigate the diff of the selected instance.
+func (w *TabbedWindow) GetDiffPane() *DiffPane {
+	return w.diff
+}
+
+// SetConflicts sets the conflict report used to warn about files shared with other instances in the diff tab.
+func (w *TabbedWindow) SetConflicts(report *session.ConflictReport) {
+	w.conflicts = report
+}
+
 // ResetPreviewToNormalMode resets the preview pane to normal mode
 func (w *TabbedWindow) ResetPreviewToNormalMode(instance *session.Instance) error {
 	return w.preview.ResetToNormalMode(instance)
@@ -151,6 +170,12 @@ func (w *TabbedWindow) ScrollDown() {
 	}
 }
 
+// ShowPreviewLine switches to the preview tab and scrolls the history of the instance to the line.
+func (w *TabbedWindow) ShowPreviewLine(instance *session.Instance, line int) error {
+	w.activeTab = PreviewTab
+	return w.preview.ScrollToLine(instance, line)
+}
+
 // IsInDiffTab returns true if the diff tab is currently active
 func (w *TabbedWindow) IsInDiffTab() bool {
 	return w.activeTab == 1
@@ -161,6 +186,19 @@ func (w *TabbedWindow) IsPreviewInScrollMode() bool {
 	return w.preview.isScrolling
 }
 
+// previewTabTitle adds the window shown in the preview to the title of the tab, if the instance has several.
+func (w *TabbedWindow) previewTabTitle(title string) string {
+	if w.instance == nil {
+		return title
+	}
+	windows := w.instance.Windows()
+	if len(windows) < 2 {
+		return title
+	}
+	selected := w.instance.SelectedWindow()
+	return fmt.Sprintf("%s · %s (%d/%d)", title, windows[selected], selected+1, len(windows))
+}
+
 func (w *TabbedWindow) String() string {
 	if w.width == 0 || w.height == 0 {
 		return ""
@@ -197,6 +235,9 @@ func (w *TabbedWindow) String() string {
 		}
 		style = style.Border(border)
 		style = style.Width(width - 1)
+		if i == 0 {
+			t = runewidth.Truncate(w.previewTabTitle(t), width-1-style.GetHorizontalFrameSize(), "…")
+		}
 		renderedTabs = append(renderedTabs, style.Render(t))
 	}
 
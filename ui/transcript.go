@@ -0,0 +1,236 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	transcriptTitleStyle = lipgloss.NewStyle().Bold(true).
+				Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#ffffff"})
+	transcriptHintStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#9C9C9C", Dark: "#777777"})
+	transcriptMatchStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#FFD700")).Foreground(lipgloss.Color("#1a1a1a"))
+	transcriptCurrentMatchStyle = lipgloss.NewStyle().
+					Background(lipgloss.Color("#FF8C00")).Foreground(lipgloss.Color("#1a1a1a"))
+)
+
+// TranscriptView shows the transcript of an instance in full screen, with scrolling and search.
+type TranscriptView struct {
+	title    string
+	lines    []string
+	viewport viewport.Model
+
+	// searching is true while the query is being typed.
+	searching bool
+	query     string
+	// matches are the indexes of the lines containing the query, and match is the selected one.
+	matches []int
+	match   int
+}
+
+// NewTranscriptView creates a view of the transcript text of the instance with the given title. It starts at the
+// end, since the latest output is usually what's interesting.
+func NewTranscriptView(title, text string) *TranscriptView {
+	v := &TranscriptView{
+		title:    title,
+		lines:    strings.Split(text, "\n"),
+		viewport: viewport.New(0, 0),
+	}
+	v.render()
+	return v
+}
+
+// SetSize sets the size of the whole view, including the title and hint lines. It scrolls to the selected match,
+// or to the end if there is none.
+func (v *TranscriptView) SetSize(width, height int) {
+	v.viewport.Width = width
+	v.viewport.Height = max(height-2, 1)
+	v.render()
+	if len(v.matches) > 0 {
+		v.scrollToMatch()
+	} else {
+		v.viewport.GotoBottom()
+	}
+}
+
+// ShowMatch searches for the query and selects the match on the given line, if there is one.
+func (v *TranscriptView) ShowMatch(query string, line int) {
+	v.query = query
+	v.search()
+	for i, matchLine := range v.matches {
+		if matchLine == line {
+			v.match = i
+			break
+		}
+	}
+	v.render()
+	v.scrollToMatch()
+}
+
+// HandleKeyPress handles a key and returns true if the view should be closed.
+func (v *TranscriptView) HandleKeyPress(msg tea.KeyMsg) bool {
+	if v.searching {
+		switch msg.Type {
+		case tea.KeyEsc:
+			v.searching = false
+			v.query = ""
+			v.search()
+		case tea.KeyEnter:
+			v.searching = false
+		case tea.KeyBackspace:
+			if runes := []rune(v.query); len(runes) > 0 {
+				v.query = string(runes[:len(runes)-1])
+				v.search()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			v.query += string(msg.Runes)
+			v.search()
+		}
+		return false
+	}
+
+	switch msg.String() {
+	case "esc", "q", "T":
+		if v.query != "" && msg.String() == "esc" {
+			v.query = ""
+			v.search()
+			return false
+		}
+		return true
+	case "/":
+		v.searching = true
+		v.query = ""
+		v.search()
+	case "n":
+		v.jumpToMatch(1)
+	case "N":
+		v.jumpToMatch(-1)
+	case "up", "k":
+		v.viewport.LineUp(1)
+	case "down", "j":
+		v.viewport.LineDown(1)
+	case "pgup", "b":
+		v.viewport.ViewUp()
+	case "pgdown", "f", " ":
+		v.viewport.ViewDown()
+	case "g", "home":
+		v.viewport.GotoTop()
+	case "G", "end":
+		v.viewport.GotoBottom()
+	}
+	return false
+}
+
+// search finds the lines containing the query, ignoring case, and jumps to the last one before the bottom of the
+// view so that typing doesn't scroll away from the output being looked at.
+func (v *TranscriptView) search() {
+	v.matches = v.matches[:0]
+	v.match = 0
+	if v.query != "" {
+		query := strings.ToLower(v.query)
+		for i, line := range v.lines {
+			if strings.Contains(strings.ToLower(line), query) {
+				v.matches = append(v.matches, i)
+			}
+		}
+		bottom := v.viewport.YOffset + v.viewport.Height
+		v.match = len(v.matches) - 1
+		for i, line := range v.matches {
+			if line >= bottom {
+				v.match = max(i-1, 0)
+				break
+			}
+		}
+	}
+	v.render()
+	v.scrollToMatch()
+}
+
+// jumpToMatch selects the next match in the given direction, wrapping around.
+func (v *TranscriptView) jumpToMatch(delta int) {
+	if len(v.matches) == 0 {
+		return
+	}
+	v.match = (v.match + delta + len(v.matches)) % len(v.matches)
+	v.render()
+	v.scrollToMatch()
+}
+
+func (v *TranscriptView) scrollToMatch() {
+	if len(v.matches) == 0 {
+		return
+	}
+	line := v.matches[v.match]
+	if line < v.viewport.YOffset || line >= v.viewport.YOffset+v.viewport.Height {
+		v.viewport.SetYOffset(line - v.viewport.Height/2)
+	}
+}
+
+// render sets the content of the viewport with the matches highlighted.
+func (v *TranscriptView) render() {
+	current := -1
+	if len(v.matches) > 0 {
+		current = v.matches[v.match]
+	}
+	rendered := make([]string, len(v.lines))
+	for i, line := range v.lines {
+		rendered[i] = line
+		if v.query == "" {
+			continue
+		}
+		style := transcriptMatchStyle
+		if i == current {
+			style = transcriptCurrentMatchStyle
+		}
+		rendered[i] = highlightMatches(line, v.query, style)
+	}
+	offset := v.viewport.YOffset
+	v.viewport.SetContent(strings.Join(rendered, "\n"))
+	v.viewport.SetYOffset(offset)
+}
+
+// highlightMatches renders every occurrence of the query in the line, ignoring case, with the style.
+func highlightMatches(line, query string, style lipgloss.Style) string {
+	lower := strings.ToLower(line)
+	query = strings.ToLower(query)
+	// Lowercasing can change the length of some runes, in which case the offsets don't apply to the line.
+	if len(lower) != len(line) {
+		return line
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, query)
+		if i < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:i])
+		b.WriteString(style.Render(line[i : i+len(query)]))
+		line, lower = line[i+len(query):], lower[i+len(query):]
+	}
+}
+
+func (v *TranscriptView) String() string {
+	status := fmt.Sprintf("%d lines", len(v.lines))
+	if v.query != "" {
+		if len(v.matches) == 0 {
+			status = fmt.Sprintf("no matches for %q", v.query)
+		} else {
+			status = fmt.Sprintf("match %d/%d for %q", v.match+1, len(v.matches), v.query)
+		}
+	}
+	title := transcriptTitleStyle.Render("Transcript: "+v.title) + transcriptHintStyle.Render("  "+status)
+
+	hint := "↑/↓ scroll · pgup/pgdn page · g/G top/bottom · / search · n/N next/prev match · esc close"
+	if v.searching {
+		hint = "/" + v.query + "█"
+	}
+	view := lipgloss.JoinVertical(lipgloss.Left, title, v.viewport.View(), transcriptHintStyle.Render(hint))
+	return lipgloss.NewStyle().MaxWidth(v.viewport.Width).Render(view)
+}